@@ -0,0 +1,165 @@
+package changelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/diff"
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/urfave/cli"
+)
+
+const changelogTemplate = `# {{.Title}} API changelog
+
+` + "`{{.From}}`" + ` → ` + "`{{.To}}`" + `
+{{if not .Changes}}
+No api changes.
+{{else}}{{if .Breaking}}
+## ⚠️ Breaking changes
+{{range .Breaking}}
+- {{template "change" .}}{{end}}
+{{end}}{{range .Sections}}{{if .Changes}}
+## {{.Title}}
+{{range .Changes}}
+- {{template "change" .}}{{end}}
+{{end}}{{end}}{{end}}`
+
+const changeTemplate = `{{define "change"}}{{if .Breaking}}**BREAKING** {{end}}{{kind .Kind}} {{.Target}} ` + "`{{.Name}}`" + `{{if .Details}}: {{join .Details "; "}}{{end}}{{end}}`
+
+type section struct {
+	Title   string
+	Changes []diff.Change
+}
+
+func ChangelogCommand(c *cli.Context) error {
+	from := c.String("from")
+	if len(from) == 0 {
+		return errors.New("missing -from")
+	}
+	to := c.String("to")
+	if len(to) == 0 {
+		to = "HEAD"
+	}
+	dir := c.String("dir")
+	if len(dir) == 0 {
+		return errors.New("missing -dir")
+	}
+
+	old, err := parseRevision(from, dir)
+	if err != nil {
+		return err
+	}
+	new, err := parseRevision(to, dir)
+	if err != nil {
+		return err
+	}
+
+	text, err := render(from, to, old, new)
+	if err != nil {
+		return err
+	}
+	out := c.String("o")
+	if len(out) == 0 {
+		fmt.Print(text)
+		return nil
+	}
+	file, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(text)
+	return err
+}
+
+// parseRevision parses all the api files in dir at the git revision into one spec.
+func parseRevision(rev, dir string) (*spec.ApiSpec, error) {
+	out, err := git("ls-tree", "-r", "--full-name", "--name-only", rev, "--", dir)
+	if err != nil {
+		return nil, err
+	}
+	var apis []*spec.ApiSpec
+	for _, file := range strings.Split(out, "\n") {
+		if !strings.HasSuffix(file, ".api") {
+			continue
+		}
+		content, err := git("show", rev+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		p, err := parser.NewParserFromStr(content)
+		if err != nil {
+			return nil, fmt.Errorf("parse file: %s at %s, err: %s", file, rev, err.Error())
+		}
+		api, err := p.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("parse file: %s at %s, err: %s", file, rev, err.Error())
+		}
+		apis = append(apis, api)
+	}
+	return diff.Merge(apis...), nil
+}
+
+func git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func render(from, to string, old, new *spec.ApiSpec) (string, error) {
+	changes := diff.Compare(old, new)
+	sections := []*section{
+		{Title: "Routes"},
+		{Title: "Types"},
+	}
+	for _, change := range changes {
+		if change.Target == diff.RouteTarget {
+			sections[0].Changes = append(sections[0].Changes, change)
+		} else {
+			sections[1].Changes = append(sections[1].Changes, change)
+		}
+	}
+
+	title := new.Info.Title
+	if len(title) == 0 {
+		title = new.Service.Name
+	}
+	t, err := template.New("changelog").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"kind": func(kind diff.Kind) string {
+			return strings.Title(string(kind))
+		},
+	}).Parse(changelogTemplate)
+	if err != nil {
+		return "", err
+	}
+	t, err = t.Parse(changeTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = t.Execute(&buffer, map[string]interface{}{
+		"Title":    title,
+		"From":     from,
+		"To":       to,
+		"Changes":  changes,
+		"Breaking": diff.Breaking(changes),
+		"Sections": sections,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,116 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/diff"
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+const baseApi = `info(
+	title: user
+)
+
+type (
+	createRequest struct {
+		name string ` + "`json:\"name\"`" + `
+		nick string ` + "`json:\"nick,optional\"`" + `
+	}
+
+	user struct {
+		name string ` + "`json:\"name\"`" + `
+		age  int    ` + "`json:\"age\"`" + `
+	}
+)
+
+service user-api {
+	@server(
+		handler: CreateUserHandler
+	)
+	post /api/user(createRequest) returns(user)
+
+	@server(
+		handler: PingHandler
+	)
+	get /api/ping()
+}
+`
+
+func parse(t *testing.T, text string) *spec.ApiSpec {
+	p, err := parser.NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	return api
+}
+
+func TestBreakingChanges(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		old, new string
+		change   string
+		breaking bool
+	}{
+		{
+			name:     "removed route",
+			old:      "\n\t@server(\n\t\thandler: PingHandler\n\t)\n\tget /api/ping()\n",
+			new:      "\n",
+			change:   "Removed route `GET /api/ping`",
+			breaking: true,
+		},
+		{
+			name:     "removed member",
+			old:      "\t\tage  int    `json:\"age\"`\n",
+			new:      "",
+			change:   "Removed member `user.age`",
+			breaking: true,
+		},
+		{
+			name:     "optional member made required",
+			old:      "`json:\"nick,optional\"`",
+			new:      "`json:\"nick\"`",
+			change:   "Changed member `createRequest.nick`: now required",
+			breaking: true,
+		},
+		{
+			name:     "type changed",
+			old:      "age  int    `json:\"age\"`",
+			new:      "age  int64  `json:\"age\"`",
+			change:   "Changed member `user.age`: type changed from int to int64",
+			breaking: true,
+		},
+		{
+			name:     "member added",
+			old:      "\t\tage  int    `json:\"age\"`\n",
+			new:      "\t\tage  int    `json:\"age\"`\n\t\tmail string `json:\"mail\"`\n",
+			change:   "Added member `user.mail`",
+			breaking: false,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.True(t, strings.Contains(baseApi, c.old))
+			old, new := parse(t, baseApi), parse(t, strings.Replace(baseApi, c.old, c.new, 1))
+			changes := diff.Compare(old, new)
+			assert.Len(t, changes, 1)
+			assert.Equal(t, c.breaking, changes[0].Breaking)
+
+			text, err := render("v1", "v2", old, new)
+			assert.Nil(t, err)
+			if c.breaking {
+				assert.Contains(t, text, "## ⚠️ Breaking changes\n\n- **BREAKING** "+c.change+"\n")
+			} else {
+				assert.NotContains(t, text, "Breaking changes")
+				assert.Contains(t, text, "\n- "+c.change+"\n")
+			}
+		})
+	}
+}
+
+func TestNoChanges(t *testing.T) {
+	text, err := render("v1", "v2", parse(t, baseApi), parse(t, baseApi))
+	assert.Nil(t, err)
+	assert.Equal(t, "# user API changelog\n\n`v1` → `v2`\n\nNo api changes.\n", text)
+}
@@ -0,0 +1,50 @@
+package chigen
+
+import (
+	"strings"
+
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/urfave/cli"
+)
+
+var framework = &servergen.Framework{
+	Name:               "chi",
+	ConfigExt:          "json",
+	EtcTemplate:        servergen.JsonEtcTemplate,
+	ConfigTemplate:     servergen.JsonConfigTemplate,
+	MainTemplate:       mainTemplate,
+	HttpxTemplate:      httpxTemplate,
+	ContextTemplate:    contextTemplate,
+	MiddlewareTemplate: middlewareTemplate,
+	JwtTemplate:        jwtMiddlewareTemplate,
+	LimitTemplate:      limitMiddlewareTemplate,
+	HandlerTemplate:    servergen.NetHttpHandlerTemplate,
+	RoutesTemplate:     routesTemplate,
+	Methods: map[string]string{
+		"delete": "Delete",
+		"get":    "Get",
+		"head":   "Head",
+		"post":   "Post",
+		"put":    "Put",
+		"patch":  "Patch",
+		"all":    "HandleFunc",
+	},
+	Path:       getPattern,
+	HtmlParams: "w http.ResponseWriter, r *http.Request",
+	HtmlImport: `"net/http"`,
+}
+
+func GoCommand(c *cli.Context) error {
+	return servergen.GoCommand(c, framework)
+}
+
+// getPattern converts the path to a pattern of chi, e.g. /users/{id} for /users/:id.
+func getPattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,202 @@
+package chigen
+
+const (
+	mainTemplate = `package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"{{.configPkg}}"
+	"{{.handlerPkg}}"
+	"{{.svcPkg}}"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+var configFile = flag.String("f", "{{.configFile}}", "the config file")
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	config.MustLoad(*configFile, &c)
+
+	ctx := svc.NewServiceContext(c)
+	router := chi.NewRouter()
+	router.Use(middleware.Logger, middleware.Recoverer)
+	handler.RegisterHandlers(router, ctx)
+
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	fmt.Printf("Starting server at %s...\n", addr)
+	log.Fatal(http.ListenAndServe(addr, router))
+}
+`
+	httpxTemplate = `package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+{{template "codeError"}}
+{{template "httpResponse"}}
+// Parse parses the json body, the path, form and header parameters of the request into v by the tags
+// of its fields, the parameters take precedence over the body.
+func Parse(r *http.Request, v any) error {
+	return parse(r, func(name string) string {
+		return chi.URLParam(r, name)
+	}, v)
+}
+{{template "parse"}}
+{{template "checkFiles"}}
+{{template "writeBinary"}}`
+	contextTemplate = `package svc
+
+import ({{if .middlewares}}
+	"net/http"
+{{end}}
+	"{{.configPkg}}"{{if .middlewares}}
+	"{{.middlewarePkg}}"{{end}}
+)
+
+type ServiceContext struct {
+	Config config.Config
+	{{- range .middlewares}}
+	{{.}} func(http.Handler) http.Handler
+	{{- end}}
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+		{{- range .middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+		{{- end}}
+	}
+}
+`
+	middlewareTemplate = `package middleware
+
+import "net/http"
+
+type {{.name}}Middleware struct {
+}
+
+func New{{.name}}Middleware() *{{.name}}Middleware {
+	return &{{.name}}Middleware{}
+}
+
+func (m *{{.name}}Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// todo: add your logic here, return without calling next to reject the request
+
+		next.ServeHTTP(w, r)
+	})
+}
+`
+	jwtMiddlewareTemplate = `package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"{{.httpxPkg}}"
+)
+
+// Jwt rejects the requests without a valid token signed by secret as the bearer of the Authorization header,
+// the claims of the token are set as the values of the request context.
+func Jwt(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, e := parseToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), []byte(secret))
+			if e != nil {
+				httpx.Error(w, httpx.NewCodeError(http.StatusUnauthorized, e.Error()))
+				return
+			}
+			ctx := r.Context()
+			for k, v := range claims {
+				ctx = context.WithValue(ctx, k, v)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+{{template "parseToken"}}`
+	limitMiddlewareTemplate = `package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// Timeout responds 503 if the handler doesn't finish in d.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable))
+	}
+}
+
+// MaxBytes limits the request bodies to n bytes.
+func MaxBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+`
+	// the groups of chi share the routes of the router, so the groups with the same prefix don't conflict
+	routesTemplate = `// DO NOT EDIT, generated by goctl
+package handler
+
+import ({{if or .time .deprecated}}{{if .deprecated}}
+	"net/http"{{end}}{{if .time}}
+	"time"{{end}}
+{{end}}
+	{{.imports}}
+
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterHandlers(router chi.Router, serverCtx *svc.ServiceContext) {
+	{{- range .groups}}
+	router.Group(func(r chi.Router) {
+		{{- if .Middlewares}}
+		r.Use({{join .Middlewares}})
+		{{- end}}
+		{{- range .Routes}}
+		r{{if .Middlewares}}.With({{join .Middlewares}}){{end}}.{{.Method}}("{{.FullPath}}", {{.Handler}})
+		{{- end}}
+	})
+	{{- end}}
+}
+{{- if .deprecated}}
+{{template "deprecated"}}
+{{- end}}
+`
+)
@@ -0,0 +1,52 @@
+package csharpgen
+
+import (
+	"errors"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+	"github.com/urfave/cli"
+)
+
+func CSharpCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+
+	namespace := c.String("namespace")
+	if namespace == "" {
+		namespace = strcase.ToCamel(api.Info.Title) + "Client"
+	}
+	e = genProject(dir, namespace, api)
+	if e != nil {
+		return e
+	}
+	e = genBase(dir, namespace)
+	if e != nil {
+		return e
+	}
+	e = genModels(dir, namespace, api)
+	if e != nil {
+		return e
+	}
+	return genApi(dir, namespace, api)
+}
@@ -0,0 +1,628 @@
+package csharpgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	projectTemplate = `<Project Sdk="Microsoft.NET.Sdk">
+
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+    <Nullable>enable</Nullable>
+    <LangVersion>latest</LangVersion>
+    <RootNamespace>{{.namespace}}</RootNamespace>
+    <PackageId>{{.namespace}}</PackageId>
+    <Version>{{.version}}</Version>
+    <Description>{{html .desc}}</Description>
+  </PropertyGroup>
+
+</Project>
+`
+	baseTemplate = `// Code generated by goctlr. DO NOT EDIT.
+using System;
+using System.Collections.Generic;
+using System.Globalization;
+using System.Net.Http;
+using System.Net.Http.Headers;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using System.Threading;
+using System.Threading.Tasks;
+
+namespace {{.}};
+
+/// <summary>The error payload written by the server.</summary>
+public class ErrorCode
+{
+    [JsonPropertyName("code")]
+    public long Code { get; set; }
+
+    [JsonPropertyName("desc")]
+    public string Desc { get; set; } = "";
+}
+
+/// <summary>Thrown for a non-2xx response, StatusCode is the http status of the response.</summary>
+public class ApiException : Exception
+{
+    public int StatusCode { get; }
+    public ErrorCode Error { get; }
+
+    public ApiException(int statusCode, ErrorCode error)
+        : base($"{statusCode}: {error.Code} {error.Desc}")
+    {
+        StatusCode = statusCode;
+        Error = error;
+    }
+}
+
+/// <summary>A file uploaded as a multipart field, or downloaded from a binary response.</summary>
+public class ApiFile
+{
+    public string Name { get; set; } = "";
+    public string ContentType { get; set; } = "";
+    public byte[] Content { get; set; } = Array.Empty<byte>();
+}
+
+/// <summary>Sends the requests of the generated apis.</summary>
+public class ApiClient
+{
+    public static readonly JsonSerializerOptions JsonOptions = new()
+    {
+        DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+    };
+
+    private readonly HttpClient http;
+
+    public string BaseUrl { get; }
+
+    /// <summary>Provides the value of the Authorization header, null or empty means anonymous.</summary>
+    public Func<CancellationToken, Task<string?>>? TokenProvider { get; set; }
+
+    public IDictionary<string, string> DefaultHeaders { get; } = new Dictionary<string, string>();
+
+    /// <param name="http">timeouts and handlers are configured on the given client, e.g. from IHttpClientFactory.</param>
+    public ApiClient(string baseUrl, HttpClient? http = null)
+    {
+        BaseUrl = baseUrl.TrimEnd('/');
+        this.http = http ?? new HttpClient();
+    }
+
+    /// <summary>Formats a path, query or header value the way the server parses it.</summary>
+    public static string Format(object value) => value switch
+    {
+        bool b => b ? "true" : "false",
+        DateTimeOffset t => t.ToString("O", CultureInfo.InvariantCulture),
+        IFormattable f => f.ToString(null, CultureInfo.InvariantCulture),
+        _ => value.ToString() ?? "",
+    };
+
+    public async Task<T> SendAsync<T>(
+        HttpMethod method,
+        string path,
+        IEnumerable<KeyValuePair<string, string>>? query,
+        IDictionary<string, string>? headers,
+        object? body,
+        CancellationToken cancellationToken)
+    {
+        var data = await SendAsync(method, path, query, headers, body, cancellationToken).ConfigureAwait(false);
+        return JsonSerializer.Deserialize<T>(data, JsonOptions)!;
+    }
+
+    /// <summary>Sends the request and returns the body of a 2xx response, otherwise throws ApiException.</summary>
+    public async Task<byte[]> SendAsync(
+        HttpMethod method,
+        string path,
+        IEnumerable<KeyValuePair<string, string>>? query,
+        IDictionary<string, string>? headers,
+        object? body,
+        CancellationToken cancellationToken)
+    {
+        using var req = await CreateRequestAsync(method, path, query, headers, body, cancellationToken).ConfigureAwait(false);
+        using var resp = await SendAsync(req, cancellationToken).ConfigureAwait(false);
+        return await resp.Content.ReadAsByteArrayAsync(cancellationToken).ConfigureAwait(false);
+    }
+
+    public async Task<T> UploadAsync<T>(
+        HttpMethod method,
+        string path,
+        IDictionary<string, string>? headers,
+        IEnumerable<KeyValuePair<string, string>>? fields,
+        IEnumerable<KeyValuePair<string, ApiFile>> files,
+        CancellationToken cancellationToken)
+    {
+        var data = await UploadAsync(method, path, headers, fields, files, cancellationToken).ConfigureAwait(false);
+        return JsonSerializer.Deserialize<T>(data, JsonOptions)!;
+    }
+
+    /// <summary>Sends the fields and files as multipart/form-data and returns the body of a 2xx response, otherwise throws ApiException.</summary>
+    public async Task<byte[]> UploadAsync(
+        HttpMethod method,
+        string path,
+        IDictionary<string, string>? headers,
+        IEnumerable<KeyValuePair<string, string>>? fields,
+        IEnumerable<KeyValuePair<string, ApiFile>> files,
+        CancellationToken cancellationToken)
+    {
+        using var req = await CreateRequestAsync(method, path, null, headers, null, cancellationToken).ConfigureAwait(false);
+        var form = new MultipartFormDataContent();
+        if (fields != null)
+        {
+            foreach (var field in fields)
+            {
+                form.Add(new StringContent(field.Value), field.Key);
+            }
+        }
+        foreach (var file in files)
+        {
+            var content = new ByteArrayContent(file.Value.Content);
+            if (!string.IsNullOrEmpty(file.Value.ContentType))
+            {
+                content.Headers.ContentType = MediaTypeHeaderValue.Parse(file.Value.ContentType);
+            }
+            form.Add(content, file.Key, file.Value.Name);
+        }
+        req.Content = form;
+
+        using var resp = await SendAsync(req, cancellationToken).ConfigureAwait(false);
+        return await resp.Content.ReadAsByteArrayAsync(cancellationToken).ConfigureAwait(false);
+    }
+
+    /// <summary>Sends the request and returns the file of a 2xx binary response, named by its Content-Disposition header.</summary>
+    public async Task<ApiFile> DownloadAsync(
+        HttpMethod method,
+        string path,
+        IEnumerable<KeyValuePair<string, string>>? query,
+        IDictionary<string, string>? headers,
+        object? body,
+        CancellationToken cancellationToken)
+    {
+        using var req = await CreateRequestAsync(method, path, query, headers, body, cancellationToken).ConfigureAwait(false);
+        using var resp = await SendAsync(req, cancellationToken).ConfigureAwait(false);
+        var disposition = resp.Content.Headers.ContentDisposition;
+        return new ApiFile
+        {
+            Name = disposition?.FileNameStar ?? disposition?.FileName?.Trim('"') ?? "",
+            ContentType = resp.Content.Headers.ContentType?.ToString() ?? "",
+            Content = await resp.Content.ReadAsByteArrayAsync(cancellationToken).ConfigureAwait(false),
+        };
+    }
+
+    private async Task<HttpRequestMessage> CreateRequestAsync(
+        HttpMethod method,
+        string path,
+        IEnumerable<KeyValuePair<string, string>>? query,
+        IDictionary<string, string>? headers,
+        object? body,
+        CancellationToken cancellationToken)
+    {
+        var url = new StringBuilder(BaseUrl).Append(path);
+        if (query != null)
+        {
+            var sep = '?';
+            foreach (var item in query)
+            {
+                url.Append(sep).Append(Uri.EscapeDataString(item.Key)).Append('=').Append(Uri.EscapeDataString(item.Value));
+                sep = '&';
+            }
+        }
+
+        var req = new HttpRequestMessage(method, url.ToString());
+        foreach (var header in DefaultHeaders)
+        {
+            req.Headers.TryAddWithoutValidation(header.Key, header.Value);
+        }
+        if (TokenProvider != null)
+        {
+            var token = await TokenProvider(cancellationToken).ConfigureAwait(false);
+            if (!string.IsNullOrEmpty(token))
+            {
+                req.Headers.TryAddWithoutValidation("Authorization", token);
+            }
+        }
+        if (headers != null)
+        {
+            foreach (var header in headers)
+            {
+                req.Headers.Remove(header.Key);
+                req.Headers.TryAddWithoutValidation(header.Key, header.Value);
+            }
+        }
+        if (body != null)
+        {
+            var json = JsonSerializer.Serialize(body, body.GetType(), JsonOptions);
+            req.Content = new StringContent(json, Encoding.UTF8, "application/json");
+        }
+        return req;
+    }
+
+    /// <summary>Returns a 2xx response, otherwise throws ApiException.</summary>
+    private async Task<HttpResponseMessage> SendAsync(HttpRequestMessage req, CancellationToken cancellationToken)
+    {
+        var resp = await http.SendAsync(req, cancellationToken).ConfigureAwait(false);
+        if (!resp.IsSuccessStatusCode)
+        {
+            using var _ = resp;
+            var data = await resp.Content.ReadAsByteArrayAsync(cancellationToken).ConfigureAwait(false);
+            var status = (int)resp.StatusCode;
+            ErrorCode? error = null;
+            try
+            {
+                error = JsonSerializer.Deserialize<ErrorCode>(data, JsonOptions);
+            }
+            catch (JsonException)
+            {
+            }
+            throw new ApiException(status, error ?? new ErrorCode { Code = status, Desc = Encoding.UTF8.GetString(data) });
+        }
+        return resp;
+    }
+}
+`
+	modelsTemplate = `// Code generated by goctlr. DO NOT EDIT.
+using System;
+using System.Collections.Generic;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace {{.namespace}};
+{{range .types}}
+public class {{.Name}}
+{{"{"}}{{range $i, $m := .Members}}{{if $i}}
+{{end}}{{if ne $m.Comment ""}}
+    /// <summary>{{$m.Comment}}</summary>{{end}}
+    {{$m.Attr}}
+    public {{$m.Type}} {{$m.Name}} { get; set; }{{if ne $m.Default ""}} = {{$m.Default}};{{end}}{{end}}
+}
+{{end}}`
+	apiTemplate = `// Code generated by goctlr. DO NOT EDIT.
+using System;
+using System.Collections.Generic;
+using System.Net.Http;
+using System.Threading;
+using System.Threading.Tasks;
+
+namespace {{.namespace}};
+
+{{if ne .desc ""}}/// <summary>{{.desc}}</summary>
+{{end}}public class {{.name}}
+{
+    private readonly ApiClient client;
+
+    public {{.name}}(ApiClient client)
+    {
+        this.client = client;
+    }
+{{range .routes}}
+{{if ne .Doc ""}}    /// <summary>{{.Doc}}</summary>
+{{end}}{{if ne .Deprecated ""}}    [Obsolete("{{.Deprecated}}")]
+{{end}}    public {{if eq .Response ""}}Task{{else}}Task<{{.Response}}>{{end}} {{.Func}}({{if ne .Request ""}}{{.Request}} req, {{end}}CancellationToken cancellationToken = default)
+    {
+{{if .Query}}        var query = new List<KeyValuePair<string, string>>();
+{{range .Query}}        {{.}}
+{{end}}{{end}}{{if .Headers}}        var headers = new Dictionary<string, string>();
+{{range .Headers}}        {{.}}
+{{end}}{{end}}{{if .Files}}        var files = new List<KeyValuePair<string, ApiFile>>();
+{{range .Files}}        {{.}}
+{{end}}        return client.UploadAsync{{if ne .Response ""}}<{{.Response}}>{{end}}(HttpMethod.{{.Method}}, {{.Path}}, {{if .Headers}}headers{{else}}null{{end}}, {{if .Query}}query{{else}}null{{end}}, files, cancellationToken);
+{{else}}        return client.{{if .Binary}}DownloadAsync{{else}}SendAsync{{if ne .Response ""}}<{{.Response}}>{{end}}{{end}}(HttpMethod.{{.Method}}, {{.Path}}, {{if .Query}}query{{else}}null{{end}}, {{if .Headers}}headers{{else}}null{{end}}, {{if .Body}}req{{else}}null{{end}}, cancellationToken);
+{{end}}    }
+{{end}}{{if .errors}}
+    /// <summary>The codes of the errors declared in the api file, e.g. e.Error.Code == {{.name}}.ErrorCodes.{{with index .errors 0}}{{.Name}}{{end}}</summary>
+    public static class ErrorCodes
+    {
+{{range .errors}}        /// <summary>{{.Desc}}</summary>
+        public const int {{.Name}} = {{.Code}};
+{{end}}    }
+{{end}}}
+`
+)
+
+type (
+	csMember struct {
+		Attr    string
+		Name    string
+		Type    string
+		Default string
+		Comment string
+	}
+	csType struct {
+		Name    string
+		Members []csMember
+	}
+	csRoute struct {
+		Doc      string
+		Method   string
+		Path     string
+		Func     string
+		Request  string
+		Response string
+		Query    []string
+		Headers  []string
+		Files    []string
+		Body     bool
+		Binary   bool
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+)
+
+var versionRe = regexp.MustCompile(`^\d+(\.\d+){0,3}$`)
+
+func genProject(dir, namespace string, api *spec.ApiSpec) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, namespace+".csproj")
+	if _, e := os.Stat(path); e == nil {
+		log.Println(namespace + ".csproj already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	version := "1.0.0"
+	if versionRe.MatchString(api.Info.Version) {
+		version = api.Info.Version
+	}
+	desc := api.Info.Desc
+	if len(desc) == 0 {
+		desc = "client of " + api.Service.Name
+	}
+	t, e := template.New("csproj").Parse(projectTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"namespace": namespace,
+		"version":   version,
+		"desc":      desc,
+	})
+}
+
+func genBase(dir, namespace string) error {
+	return writeFile(dir, "ApiClient.cs", baseTemplate, namespace)
+}
+
+func genModels(dir, namespace string, api *spec.ApiSpec) error {
+	var types []csType
+	for _, tp := range api.Types {
+		types = append(types, buildCsType(api, tp))
+	}
+	return writeFile(dir, "Models.cs", modelsTemplate, map[string]interface{}{
+		"namespace": namespace,
+		"types":     types,
+	})
+}
+
+func genApi(dir, namespace string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "C#")
+	name := strcase.ToCamel(api.Info.Title + "Api")
+	var routes []csRoute
+	for _, route := range api.Service.Routes {
+		routes = append(routes, buildCsRoute(api, route))
+	}
+	return writeFile(dir, name+".cs", apiTemplate, map[string]interface{}{
+		"namespace": namespace,
+		"name":      name,
+		"desc":      strings.TrimSpace(api.Info.Desc),
+		"routes":    routes,
+		"errors":    api.Errors,
+	})
+}
+
+func writeFile(dir, name, text string, data interface{}) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New(name).Parse(text)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, data)
+}
+
+func buildCsType(api *spec.ApiSpec, tp spec.Type) csType {
+	result := csType{Name: strcase.ToCamel(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		typ, optional := toCsType(member)
+		m := csMember{
+			Name:    csName(result.Name, member.Name),
+			Type:    typ,
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+		}
+		if optional {
+			m.Type += "?"
+		} else {
+			m.Default = csDefaultValue(member.Type)
+		}
+		if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+			// sent as path, query or header, not in the json body
+			m.Attr = "[JsonIgnore]"
+		} else {
+			m.Attr = fmt.Sprintf("[JsonPropertyName(%q)]", member.GetTagName())
+		}
+		result.Members = append(result.Members, m)
+	}
+	return result
+}
+
+func buildCsRoute(api *spec.ApiSpec, route spec.Route) csRoute {
+	members := util.GetRequestMembers(api, route)
+	result := csRoute{
+		Doc:        strings.TrimSpace(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strcase.ToCamel(strings.ToLower(route.Method)),
+		Func:       strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)) + "Async",
+		Body:       len(members.Body) > 0,
+	}
+	requestName := strcase.ToCamel(route.RequestType.Name)
+	path := util.ConvertPath(route.Path, func(name string) string {
+		member, ok := members.GetPathMember(name)
+		if !ok {
+			return ":" + name
+		}
+		return "{Uri.EscapeDataString(ApiClient.Format(req." + csName(requestName, member.Name) + "))}"
+	})
+	if len(members.Path) > 0 {
+		result.Path = `$"` + path + `"`
+	} else {
+		result.Path = `"` + path + `"`
+	}
+	if len(route.RequestType.Name) > 0 {
+		result.Request = requestName
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		result.Response = "ApiFile"
+		result.Binary = true
+	}
+
+	for _, member := range members.Query {
+		if member.IsFile() {
+			result.Files = append(result.Files, addValue(requestName, member, func(value string) string {
+				return fmt.Sprintf(`files.Add(new KeyValuePair<string, ApiFile>("%s", %s));`, member.GetTagName(), value)
+			}))
+			continue
+		}
+		result.Query = append(result.Query, addValue(requestName, member, func(value string) string {
+			return fmt.Sprintf(`query.Add(new KeyValuePair<string, string>("%s", ApiClient.Format(%s)));`, member.GetTagName(), value)
+		}))
+	}
+	for _, member := range members.Header {
+		result.Headers = append(result.Headers, addValue(requestName, member, func(value string) string {
+			return fmt.Sprintf(`headers["%s"] = ApiClient.Format(%s);`, member.GetTagName(), value)
+		}))
+	}
+	return result
+}
+
+// addValue returns the statement sending the member, null values are skipped
+// and every element of a list is sent.
+func addValue(typeName string, member spec.Member, fn func(value string) string) string {
+	field := "req." + csName(typeName, member.Name)
+	_, optional := toCsType(member)
+	if strings.HasPrefix(strings.TrimPrefix(member.Type, "*"), "[]") {
+		items := field
+		if optional {
+			items = "(" + field + " ?? new())"
+		}
+		return fmt.Sprintf(`foreach (var value in %s)
+        {
+            %s
+        }`, items, fn("value"))
+	}
+	if optional {
+		return fmt.Sprintf(`if (%s != null)
+        {
+            %s
+        }`, field, fn(field))
+	}
+	return fn(field)
+}
+
+// csName returns the property name, which can't be the same as the enclosing type.
+func csName(typeName, name string) string {
+	name = strcase.ToCamel(name)
+	if name == typeName {
+		return name + "Value"
+	}
+	return name
+}
+
+// toCsType returns the c# type of the member and whether it is nullable.
+func toCsType(member spec.Member) (string, bool) {
+	optional := member.IsOptional() || member.IsOmitempty() || strings.HasPrefix(member.Type, "*")
+	return csType2(member.Type), optional
+}
+
+func csType2(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "List<" + csType2(t[2:]) + ">"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "Dictionary<" + csType2(tys[0]) + ", " + csType2(tys[1]) + ">"
+	}
+
+	switch t {
+	case "string":
+		return "string"
+	case "int", "int64":
+		return "long"
+	case "int8":
+		return "sbyte"
+	case "int16":
+		return "short"
+	case "int32":
+		return "int"
+	case "uint", "uint64":
+		return "ulong"
+	case "uint8":
+		return "byte"
+	case "uint16":
+		return "ushort"
+	case "uint32":
+		return "uint"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	case "bool":
+		return "bool"
+	case "interface{}":
+		return "JsonElement"
+	case "time.Time":
+		return "DateTimeOffset"
+	case spec.FileTypeName:
+		return "ApiFile"
+	default:
+		return strcase.ToCamel(t)
+	}
+}
+
+func csDefaultValue(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	switch {
+	case strings.HasPrefix(t, "[]"), strings.HasPrefix(t, "map"):
+		return "new()"
+	case t == "string":
+		return `""`
+	}
+	switch csType2(t) {
+	case "long", "sbyte", "short", "int", "ulong", "byte", "ushort", "uint", "float", "double",
+		"bool", "JsonElement", "DateTimeOffset":
+		return ""
+	default:
+		return "new()"
+	}
+}
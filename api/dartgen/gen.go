@@ -2,9 +2,9 @@ package dartgen
 
 import (
 	"errors"
-	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/gofaith/go-zero/core/logx"
@@ -16,148 +16,421 @@ import (
 )
 
 const (
-	apiBaseTemplate = `import 'dart:async';
+	apiBaseTemplate = `// Code generated by goctlr. DO NOT EDIT.
+import 'dart:async';
 import 'dart:convert';
-import 'dart:io';
 
+import 'package:http/http.dart' as http;
+
+/// Provides the base url of the server, e.g. https://api.example.com
+typedef BaseUrlProvider = FutureOr<String> Function();
+
+/// Provides the value of the Authorization header, null or empty means anonymous.
+typedef TokenProvider = FutureOr<String?> Function();
+
+/// The error payload written by the server.
 class ErrorCode {
-	int code;
-	String desc;
-	ErrorCode({
-	this.code = 0,
-	this.desc = '',
-	});
-	factory ErrorCode.fromJson(Map<String, dynamic> jsonObject) => ErrorCode(
-		code: jsonObject['code'],
-		desc: jsonObject['desc'],
-		);
-	Map<String, dynamic> toJson() => {
-		'code': code,
-		'desc': desc,
-		};
-}
-
-
-Future apiRequest(String method, String uri, dynamic body,Future Function(String)? onOk, Function(ErrorCode)? onFail, Function()? eventually) async {
-	final sp = await SharedPreferences.getInstance();
-	BaseRequest req = Request(method, Uri.parse(await _getServer(sp) + uri));
-	final token = sp.getString('token') ?? '';
-  
-	try {
-	  if (body is MultipartRequest) {
-		// Multipart
-		final r = MultipartRequest(method, Uri.parse(await _getServer(sp) + uri));
-		if (token.isNotEmpty) {
-		  r.headers['Authorization'] = token;
-		}
-		body.fields.forEach((key, value) {
-		  r.fields[key] = value;
-		});
-		r.files.addAll(body.files);
-		req = r;
-	  } else if (body is File) {
-		// File
-		final r = Request(method, Uri.parse(await _getServer(sp) + uri));
-		r.headers['Content-Type'] = 'application/octet-stream';
-		if (token.isNotEmpty) {
-		  r.headers['Authorization'] = token;
-		}
-		final fi = body;
-		r.bodyBytes = List.from(fi.readAsBytesSync());
-		req = r;
-	  } else if (body != null) {
-		// Json
-		final r = Request(method, Uri.parse(await _getServer(sp) + uri));
-		r.headers['Content-Type'] = 'application/json; charset=utf-8';
-		if (token.isNotEmpty) {
-		  r.headers['Authorization'] = token;
-		}
-		r.body = jsonEncode(body);
-		req = r;
-	  } else {
-		if (token.isNotEmpty) {
-		  req.headers['Authorization'] = token;
-		}
-	  }
-  
-	  final res = await req.send();
-	  final str = await res.stream.bytesToString();
-	  if (res.statusCode == 200) {
-		await onOk?.call(str);
-	  } else {
-		try {
-		  onFail?.call(ErrorCode.fromJson(jsonDecode(str)));
-		} catch (e) {
-		  onFail?.call(ErrorCode(code: res.statusCode, desc: '${res.statusCode}:$str'));
-		}
-	  }
-	} catch (e, stack) {
-	  // ignore: avoid_print
-	  print(stack);
-	  updateServer(sp);
-	  onFail?.call(ErrorCode(desc: e.toString()));
-	}
-	eventually?.call();
-  }
-  
-  const _serverKey = '--server--';
-  Future<String> _getServer(SharedPreferences sp) async {
-	final s = sp.getString(_serverKey) ?? '';
-	if (s.isEmpty) {
-	  return await updateServer(sp);
-	}
-	return s;
-  }
-  
-  Future<String> updateServer(SharedPreferences sp) async {
-	final results = await DnsUtils.lookupRecord('_server.example.com', RRecordType.TXT);
-	if (results != null && results.isNotEmpty) {
-	  final s = results[0].data;
-	  sp.setString(_serverKey, s);
-	  if (kDebugMode) {
-		print('server updated:$s');
-	  }
-	  return s;
-	}
-	return '';
+  final int code;
+  final String desc;
+
+  const ErrorCode({this.code = 0, this.desc = ''});
+
+  factory ErrorCode.fromJson(Map<String, dynamic> json) => ErrorCode(
+        code: (json['code'] as num?)?.toInt() ?? 0,
+        desc: json['desc'] as String? ?? '',
+      );
+
+  Map<String, dynamic> toJson() => {'code': code, 'desc': desc};
+
+  @override
+  String toString() => 'ErrorCode($code, $desc)';
+}
+
+/// Thrown when the server responds with a non-2xx status code.
+class ApiException implements Exception {
+  final int statusCode;
+  final ErrorCode error;
+
+  const ApiException(this.statusCode, this.error);
+
+  @override
+  String toString() => 'ApiException($statusCode): ${error.code} ${error.desc}';
+}
+
+/// Thrown when the server can't be reached or doesn't answer in time.
+class NetworkException implements Exception {
+  final Object cause;
+
+  const NetworkException(this.cause);
+
+  @override
+  String toString() => 'NetworkException: $cause';
+}
+
+/// Thrown when the response body doesn't match the declared response type.
+class DecodeException implements Exception {
+  final String body;
+  final Object cause;
+
+  const DecodeException(this.body, this.cause);
+
+  @override
+  String toString() => 'DecodeException: $cause';
+}
+
+/// A response of a route with multiple responses, the body is empty if the response has none.
+class ApiResponse {
+  final int statusCode;
+  final String body;
+
+  const ApiResponse(this.statusCode, this.body);
+}
+
+/// A file uploaded as a multipart field, or downloaded from a binary response.
+class ApiFile {
+  final String name;
+  final List<int> bytes;
+
+  /// The content type of a download, an upload's is guessed by the server from its name.
+  final String? contentType;
+
+  const ApiFile(this.name, this.bytes, {this.contentType});
+}
+
+class ApiClient {
+  final BaseUrlProvider baseUrl;
+  final TokenProvider? token;
+  final http.Client httpClient;
+  final Duration timeout;
+  final Map<String, String> defaultHeaders;
+
+  ApiClient({
+    required this.baseUrl,
+    this.token,
+    http.Client? httpClient,
+    this.timeout = const Duration(seconds: 10),
+    this.defaultHeaders = const {},
+  }) : httpClient = httpClient ?? http.Client();
+
+  /// Sends the request and returns the response body of a 2xx response.
+  /// A List<int> body is sent as application/octet-stream, any other non-null body is encoded as json.
+  Future<String> request(
+    String method,
+    String path, {
+    Map<String, String>? query,
+    Map<String, String>? headers,
+    Object? body,
+  }) async {
+    final req = http.Request(method, await _uri(path, query));
+    await _addHeaders(req, headers);
+    _setBody(req, body);
+    return _guard(() async {
+      final res = await _send(req);
+      return res.stream.bytesToString().timeout(timeout);
+    });
   }
+
+  /// Sends the request and returns the response of a 2xx status or one of statuses,
+  /// which are the responses declared by a route with multiple responses.
+  Future<ApiResponse> requestStatus(
+    String method,
+    String path,
+    List<int> statuses, {
+    Map<String, String>? query,
+    Map<String, String>? headers,
+    Object? body,
+  }) async {
+    final req = http.Request(method, await _uri(path, query));
+    await _addHeaders(req, headers);
+    _setBody(req, body);
+    return _guard(() async {
+      final res = await _send(req, statuses);
+      return ApiResponse(res.statusCode, await res.stream.bytesToString().timeout(timeout));
+    });
+  }
+
+  /// Sends the fields and files as multipart/form-data and returns the response body of a 2xx response.
+  Future<String> upload(
+    String method,
+    String path, {
+    Map<String, String>? headers,
+    Map<String, String>? fields,
+    Map<String, List<ApiFile>>? files,
+  }) async {
+    final req = http.MultipartRequest(method, await _uri(path, null));
+    await _addHeaders(req, headers);
+    if (fields != null) {
+      req.fields.addAll(fields);
+    }
+    files?.forEach((field, list) {
+      for (final file in list) {
+        req.files.add(http.MultipartFile.fromBytes(field, file.bytes, filename: file.name));
+      }
+    });
+    return _guard(() async {
+      final res = await _send(req);
+      return res.stream.bytesToString().timeout(timeout);
+    });
+  }
+
+  /// Sends the request and returns the file of a 2xx binary response, named by its Content-Disposition header.
+  Future<ApiFile> download(
+    String method,
+    String path, {
+    Map<String, String>? query,
+    Map<String, String>? headers,
+    Object? body,
+  }) async {
+    final req = http.Request(method, await _uri(path, query));
+    await _addHeaders(req, headers);
+    _setBody(req, body);
+    return _guard(() async {
+      final res = await _send(req);
+      final bytes = await res.stream.toBytes().timeout(timeout);
+      return ApiFile(_fileName(res.headers['content-disposition']), bytes, contentType: res.headers['content-type']);
+    });
+  }
+
+  Future<Uri> _uri(String path, Map<String, String>? query) async {
+    var uri = Uri.parse(await baseUrl() + path);
+    if (query != null && query.isNotEmpty) {
+      uri = uri.replace(queryParameters: {...uri.queryParameters, ...query});
+    }
+    return uri;
+  }
+
+  Future<void> _addHeaders(http.BaseRequest req, Map<String, String>? headers) async {
+    req.headers.addAll(defaultHeaders);
+    final t = await token?.call();
+    if (t != null && t.isNotEmpty) {
+      req.headers['Authorization'] = t;
+    }
+    if (headers != null) {
+      req.headers.addAll(headers);
+    }
+  }
+
+  void _setBody(http.Request req, Object? body) {
+    if (body is List<int>) {
+      req.headers['Content-Type'] = 'application/octet-stream';
+      req.bodyBytes = body;
+    } else if (body != null) {
+      req.headers['Content-Type'] = 'application/json; charset=utf-8';
+      req.body = jsonEncode(body);
+    }
+  }
+
+  /// Sends the request and returns a 2xx response or one of statuses,
+  /// the error of any other response is thrown as an ApiException.
+  Future<http.StreamedResponse> _send(http.BaseRequest req, [List<int> statuses = const []]) async {
+    final res = await httpClient.send(req).timeout(timeout);
+    if (res.statusCode >= 200 && res.statusCode < 300 || statuses.contains(res.statusCode)) {
+      return res;
+    }
+
+    final str = await res.stream.bytesToString().timeout(timeout);
+    ErrorCode err;
+    try {
+      err = ErrorCode.fromJson(jsonDecode(str) as Map<String, dynamic>);
+    } catch (_) {
+      err = ErrorCode(code: res.statusCode, desc: str);
+    }
+    throw ApiException(res.statusCode, err);
+  }
+
+  Future<T> _guard<T>(Future<T> Function() fn) async {
+    try {
+      return await fn();
+    } on TimeoutException catch (e) {
+      throw NetworkException(e);
+    } on http.ClientException catch (e) {
+      throw NetworkException(e);
+    }
+  }
+
+  /// Returns the file name of a Content-Disposition header, filename* is preferred.
+  static String _fileName(String? disposition) {
+    if (disposition == null) {
+      return '';
+    }
+    final encoded = RegExp(r"filename\*=[^']*'[^']*'([^;]+)", caseSensitive: false).firstMatch(disposition);
+    if (encoded != null) {
+      return Uri.decodeComponent(encoded.group(1)!);
+    }
+    final plain = RegExp(r'filename="?([^";]+)"?', caseSensitive: false).firstMatch(disposition);
+    return plain?.group(1) ?? '';
+  }
+
+  T decode<T>(String body, T Function(Map<String, dynamic>) fromJson) {
+    try {
+      return fromJson(jsonDecode(body) as Map<String, dynamic>);
+    } catch (e) {
+      throw DecodeException(body, e);
+    }
+  }
+
+  void close() => httpClient.close();
+}
 `
-	apiApiTemplate = `import 'dart:convert';
-import './base.dart';
+	dnsTemplate = `// Code generated by goctlr. DO NOT EDIT.
+import 'package:basic_utils/basic_utils.dart';
+import 'package:shared_preferences/shared_preferences.dart';
+
+/// Discovers the base url from the TXT record of [record] and caches it in SharedPreferences.
+///
+///     final dns = DnsTxtBaseUrlProvider('{{.}}');
+///     final client = ApiClient(baseUrl: dns.call);
+///
+/// Call [refresh] after a NetworkException to look the record up again.
+class DnsTxtBaseUrlProvider {
+  final String record;
+  final String cacheKey;
+
+  DnsTxtBaseUrlProvider(this.record, {this.cacheKey = '--server--'});
+
+  Future<String> call() async {
+    final sp = await SharedPreferences.getInstance();
+    final s = sp.getString(cacheKey) ?? '';
+    if (s.isNotEmpty) {
+      return s;
+    }
+    return refresh();
+  }
+
+  Future<String> refresh() async {
+    final results = await DnsUtils.lookupRecord(record, RRecordType.TXT);
+    if (results == null || results.isEmpty) {
+      return '';
+    }
+    final s = results[0].data;
+    final sp = await SharedPreferences.getInstance();
+    await sp.setString(cacheKey, s);
+    return s;
+  }
+}
+`
+	pubspecTemplate = `name: {{.name}}
+description: {{.desc}}
+version: 1.0.0
+publish_to: none
+
+environment:
+  sdk: '>=2.17.0 <4.0.0'
+
+dependencies:
+  http: ^1.1.0
+  json_annotation: ^4.8.0{{if .dns}}
+  basic_utils: ^5.5.0
+  shared_preferences: ^2.2.0{{end}}
+
+dev_dependencies:
+  build_runner: ^2.4.0
+  json_serializable: ^6.7.0
+`
+	apiApiTemplate = `// Code generated by goctlr. DO NOT EDIT.
 import 'package:json_annotation/json_annotation.dart';
 
-part '{{snakeCase .Info.Title}}.g.dart';
+import 'base.dart';
 
+part '{{snakeCase .Info.Title}}.g.dart';
 {{range .Types}}
-@JsonSerializable()
-class {{.Name}} {
-	{{range .Members}}
-	/// {{.Comment}}
-	{{toDartType .Type}} {{tagGet .Tag "json"}};{{end}}
-	{{.Name}}({{if ne 0 (len .Members)}}{ {{range .Members}}
-		this.{{tagGet .Tag "json"}}{{if ne (dartDefaultValue .Type) ""}} = {{dartDefaultValue .Type}}{{end}},{{end}}
-	}{{end}});
-	factory {{.Name}}.fromJson(Map<String, dynamic> jsonObject) => _${{.Name}}FromJson(jsonObject);
-	Map<String, dynamic> toJson() => _${{.Name}}ToJson(this);
-}
+@JsonSerializable(explicitToJson: true)
+class {{camelCase .Name}} { {{range members .}}{{with comment .}}
+  /// {{.}}{{end}}
+  {{jsonKey .}}
+  {{dartMemberType .}} {{lowCamelCase .Name}};
 {{end}}
+  {{camelCase .Name}}({{with members .}}{ {{range .}}
+    this.{{lowCamelCase .Name}}{{with dartMemberDefault .}} = {{.}}{{end}},{{end}}
+  }{{end}});
+
+  factory {{camelCase .Name}}.fromJson(Map<String, dynamic> json) => _${{camelCase .Name}}FromJson(json);
+
+  Map<String, dynamic> toJson() => _${{camelCase .Name}}ToJson(this);
+}
+{{end}}{{range .Service.Routes}}{{if .Responses}}{{$result := resultName .}}
+/// The responses of {{routeToFuncName .Method .Path}} told apart by their status, e.g. {{$result}}{{statusName (index .Responses 0).Status}}.
+abstract class {{$result}} {
+  final int statusCode;
 
-class {{with .Info}}{{.Title}}{{end}} { {{with .Service}}{{range .Routes}}
-	static Future {{routeToFuncName .Method .Path}}(
-		{{with .RequestType}}{{if ne .Name ""}}{{.Name}}{{else}}dynamic{{end}} req,{{end}}
-		{Future Function({{if ne .ResponseType.Name ""}}{{.ResponseType.Name}} res{{end}})? onOk,
-		Function(ErrorCode e)? onFail,
-		Function()? eventually}
-	) async {
-		await apiRequest('{{upperCase .Method}}', '{{.Path}}',req,(data)async {
-			if (onOk != null){ {{with .ResponseType}}{{if ne .Name ""}}
-				final res = {{.Name}}.fromJson(jsonDecode(data));
-				await onOk(res);{{else}}
-				await onOk();{{end}}
-			}{{end}}
-		},onFail,eventually);
-	}{{end}}
+  const {{$result}}(this.statusCode);
 }
+{{range .Responses}}
+class {{$result}}{{statusName .Status}} extends {{$result}} { {{- if ne .Type.Name ""}}
+  final {{camelCase .Type.Name}} body;
+
+  const {{$result}}{{statusName .Status}}(this.body) : super({{.Status}});
+{{- else}}
+  const {{$result}}{{statusName .Status}}() : super({{.Status}});
+{{- end}}
+}
+{{end}}{{end}}{{end}}
+class {{.Info.Title}} {
+  final ApiClient client;
+
+  {{.Info.Title}}(this.client);
+{{range .Service.Routes}}{{if ne .Summary ""}}
+  /// {{.Summary}}{{end}}{{if ne .Desc ""}}
+  /// {{.Desc}}{{end}}{{if .Deprecation}}
+  @Deprecated('{{.Deprecation.Message}}'){{end}}
+  Future<{{if .Binary}}ApiFile{{else if and .Responses (not (isMultipart .))}}{{resultName .}}{{else if eq .ResponseType.Name ""}}void{{else}}{{camelCase .ResponseType.Name}}{{end}}> {{routeToFuncName .Method .Path}}({{if ne .RequestType.Name ""}}{{camelCase .RequestType.Name}} req{{end}}) async {
+    {{- if isMultipart .}}
+    {{if ne .ResponseType.Name ""}}final res = {{end}}await client.upload(
+      '{{upperCase .Method}}',
+      {{dartPath .}},{{with headerMembers .}}
+      headers: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}{{with formMembers .}}
+      fields: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}
+      files: { {{range fileMembers .}}
+        {{dartFileEntry .}},{{end}}
+      },
+    );{{if ne .ResponseType.Name ""}}
+    return client.decode(res, {{camelCase .ResponseType.Name}}.fromJson);{{end}}
+  }
+{{else if and .Responses (not .Binary)}}{{$result := resultName .}}
+    final res = await client.requestStatus(
+      '{{upperCase .Method}}',
+      {{dartPath .}},
+      [{{range $i, $r := .Responses}}{{if $i}}, {{end}}{{$r.Status}}{{end}}],{{with queryMembers .}}
+      query: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}{{with headerMembers .}}
+      headers: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}{{if hasBody .}}
+      body: req,{{end}}
+    );
+    switch (res.statusCode) { {{- range .Responses}}
+      case {{.Status}}:
+        return {{$result}}{{statusName .Status}}({{if ne .Type.Name ""}}client.decode(res.body, {{camelCase .Type.Name}}.fromJson){{end}});
+    {{- end}}
+    }
+    throw DecodeException(res.body, 'unexpected status ${res.statusCode}');
+  }
+{{else}}
+    {{if .Binary}}return {{else if ne .ResponseType.Name ""}}final res = {{end}}await client.{{if .Binary}}download{{else}}request{{end}}(
+      '{{upperCase .Method}}',
+      {{dartPath .}},{{with queryMembers .}}
+      query: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}{{with headerMembers .}}
+      headers: { {{range .}}
+        {{dartMapEntry .}},{{end}}
+      },{{end}}{{if hasBody .}}
+      body: req,{{end}}
+    );{{if ne .ResponseType.Name ""}}
+    return client.decode(res, {{camelCase .ResponseType.Name}}.fromJson);{{end}}
+  }
+{{end}}{{end}}}
+{{if .Errors}}
+/// The codes of the errors declared in the api file, e.g. e.code == ErrorCodes.{{with index .Errors 0}}{{lowCamelCase .Name}}{{end}}
+class ErrorCodes { {{range .Errors}}
+  /// {{.Desc}}
+  static const int {{lowCamelCase .Name}} = {{.Code}};
+{{end}}}
 {{end}}`
 )
 
@@ -167,8 +440,41 @@ func genBase(dir string, api *spec.ApiSpec) error {
 		return e
 	}
 	path := filepath.Join(dir, "base.dart")
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	_, e = file.WriteString(apiBaseTemplate)
+	return e
+}
+
+func genDns(dir, record string) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, "dns_discovery.dart"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("dns").Parse(dnsTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, record)
+}
+
+func genPubspec(dir, pkg string, api *spec.ApiSpec, dns bool) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "pubspec.yaml")
 	if _, e := os.Stat(path); e == nil {
-		log.Println("base.dart already exists, skipped it.")
 		return nil
 	}
 
@@ -178,11 +484,23 @@ func genBase(dir string, api *spec.ApiSpec) error {
 	}
 	defer file.Close()
 
-	file.WriteString(apiBaseTemplate)
-	return nil
+	desc := api.Info.Desc
+	if len(desc) == 0 {
+		desc = "client of " + api.Service.Name
+	}
+	t, e := template.New("pubspec").Parse(pubspecTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"name": pkg,
+		"desc": desc,
+		"dns":  dns,
+	})
 }
 
 func genApi(dir string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "dart")
 	e := os.MkdirAll(dir, 0755)
 	if e != nil {
 		return e
@@ -196,16 +514,129 @@ func genApi(dir string, api *spec.ApiSpec) error {
 	}
 	defer file.Close()
 
-	t, e := template.New("api").Funcs(util.FuncsMap).Parse(apiApiTemplate)
+	t, e := template.New("api").Funcs(util.FuncsMap).Funcs(funcsMap(api)).Parse(apiApiTemplate)
 	if e != nil {
 		return e
 	}
 	return t.Execute(file, api)
 }
 
+func funcsMap(api *spec.ApiSpec) template.FuncMap {
+	return template.FuncMap{
+		"members": func(ty spec.Type) []spec.Member {
+			return util.FlattenMembers(api.Types, ty)
+		},
+		"queryMembers": func(route spec.Route) []spec.Member {
+			return util.GetRequestMembers(api, route).Query
+		},
+		"headerMembers": func(route spec.Route) []spec.Member {
+			return util.GetRequestMembers(api, route).Header
+		},
+		"hasBody": func(route spec.Route) bool {
+			return len(util.GetRequestMembers(api, route).Body) > 0
+		},
+		"isMultipart": func(route spec.Route) bool {
+			return util.IsMultipart(api, route)
+		},
+		"formMembers": func(route spec.Route) []spec.Member {
+			var members []spec.Member
+			for _, member := range util.GetRequestMembers(api, route).Query {
+				if !member.IsFile() {
+					members = append(members, member)
+				}
+			}
+			return members
+		},
+		"fileMembers": func(route spec.Route) []spec.Member {
+			var members []spec.Member
+			for _, member := range util.GetRequestMembers(api, route).Query {
+				if member.IsFile() {
+					members = append(members, member)
+				}
+			}
+			return members
+		},
+		"dartPath": func(route spec.Route) string {
+			return dartPath(util.GetRequestMembers(api, route), route.Path)
+		},
+		"resultName": func(route spec.Route) string {
+			return strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)) + "Result"
+		},
+		"statusName":        util.StatusName,
+		"comment":           comment,
+		"jsonKey":           jsonKey,
+		"dartMemberType":    dartMemberType,
+		"dartMemberDefault": dartMemberDefault,
+		"dartMapEntry":      dartMapEntry,
+		"dartFileEntry":     dartFileEntry,
+	}
+}
+
+func comment(member spec.Member) string {
+	return strings.TrimSpace(strings.TrimPrefix(member.Comment, "//"))
+}
+
+func jsonKey(member spec.Member) string {
+	if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+		return "@JsonKey(includeFromJson: false, includeToJson: false)"
+	}
+	return "@JsonKey(name: '" + member.GetTagName() + "')"
+}
+
+func dartMemberType(member spec.Member) string {
+	t := util.ToDartType(member.Type)
+	if member.IsOptional() && !strings.HasSuffix(t, "?") && t != "dynamic" {
+		return t + "?"
+	}
+	return t
+}
+
+func dartMemberDefault(member spec.Member) string {
+	if member.IsOptional() {
+		return ""
+	}
+	return util.DartDefaultValue(member.Type)
+}
+
+// dartMapEntry returns a query or header map entry, optional members are only sent when they are not null.
+func dartMapEntry(member spec.Member) string {
+	field := strcase.ToLowerCamel(member.Name)
+	entry := "'" + member.GetTagName() + "': '${req." + field + "}'"
+	if strings.HasSuffix(dartMemberType(member), "?") {
+		return "if (req." + field + " != null) " + entry
+	}
+	return entry
+}
+
+// dartFileEntry returns a files map entry of a file member, a single file is only sent when it's not null.
+func dartFileEntry(member spec.Member) string {
+	field := strcase.ToLowerCamel(member.Name)
+	if member.IsFileList() {
+		if strings.HasSuffix(dartMemberType(member), "?") {
+			return "if (req." + field + " != null) '" + member.GetTagName() + "': req." + field + "!"
+		}
+		return "'" + member.GetTagName() + "': req." + field
+	}
+	return "if (req." + field + " != null) '" + member.GetTagName() + "': [req." + field + "!]"
+}
+
+// dartPath converts /api/user/:name to '/api/user/${Uri.encodeComponent(req.name.toString())}', the path values
+// are escaped like url.PathEscape, e.g. a/b is sent as a%2Fb
+func dartPath(members util.RequestMembers, path string) string {
+	return "'" + util.ConvertPath(path, func(name string) string {
+		member, ok := members.GetPathMember(name)
+		if !ok {
+			return ":" + name
+		}
+		return "${Uri.encodeComponent(req." + strcase.ToLowerCamel(member.Name) + ".toString())}"
+	}) + "'"
+}
+
 func DartCommand(c *cli.Context) error {
 	apiFile := c.String("api")
 	dir := c.String("dir")
+	pkg := c.String("package")
+	dns := c.String("dns")
 	if len(apiFile) == 0 {
 		return errors.New("missing -api")
 	}
@@ -221,8 +652,19 @@ func DartCommand(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	api, err = util.ScopeVersion(api, c.String("version"))
+	if err != nil {
+		return err
+	}
 
+	if len(pkg) > 0 {
+		logx.Must(genPubspec(dir, pkg, api, len(dns) > 0))
+		dir = filepath.Join(dir, "lib")
+	}
 	logx.Must(genBase(dir, api))
+	if len(dns) > 0 {
+		logx.Must(genDns(dir, dns))
+	}
 	logx.Must(genApi(dir, api))
 	return nil
 }
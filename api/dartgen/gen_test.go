@@ -0,0 +1,75 @@
+package dartgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathEscape(t *testing.T) {
+	const text = `info(
+	title: user
+)
+
+type request struct {
+	name string ` + "`path:\"name\"`" + `
+	id   int    ` + "`path:\"id\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name/:id(request)
+}
+`
+	p, err := parser.NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, genApi(dir, api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "user_api.dart"))
+	assert.Nil(t, err)
+	// a name like a/b is sent as a%2Fb instead of adding a segment to the path
+	assert.Contains(t, string(b),
+		"'/api/user/${Uri.encodeComponent(req.name.toString())}/${Uri.encodeComponent(req.id.toString())}'")
+}
+
+func TestSkipStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+	p, err := parser.NewParserFromStr(`info(
+	title: user
+)
+
+type event struct {
+	text string ` + "`json:\"text\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: EventsHandler
+	)
+	get /api/events() stream(event)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, genApi(dir, api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "user_api.dart"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "/api/events")
+	assert.Contains(t, buffer.String(), "the sse stream GET /api/events is skipped")
+}
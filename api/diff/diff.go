@@ -0,0 +1,327 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+)
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+
+	RouteTarget  Target = "route"
+	TypeTarget   Target = "type"
+	MemberTarget Target = "member"
+)
+
+type (
+	Kind   string
+	Target string
+
+	// Change is a single difference between two api specs.
+	Change struct {
+		Kind   Kind
+		Target Target
+		// the route key (e.g. GET /api/user/:name), the type name or the type.member name
+		Name string
+		// what exactly changed, empty for added and removed items
+		Details  []string
+		Breaking bool
+	}
+)
+
+// Compare returns the structural differences from old to new. Routes are identified by method and path,
+// types by name and members by their type and name, so the result doesn't depend on the order in the files.
+// The changes are sorted by target, then by name.
+func Compare(old, new *spec.ApiSpec) []Change {
+	var changes []Change
+	changes = append(changes, compareRoutes(old, new)...)
+	changes = append(changes, compareTypes(old, new)...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Target != changes[j].Target {
+			return targetOrder(changes[i].Target) < targetOrder(changes[j].Target)
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// Breaking returns the breaking changes only.
+func Breaking(changes []Change) []Change {
+	var result []Change
+	for _, c := range changes {
+		if c.Breaking {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Merge combines the types and routes of several api specs, e.g. all the api files of a project.
+func Merge(apis ...*spec.ApiSpec) *spec.ApiSpec {
+	result := new(spec.ApiSpec)
+	for _, api := range apis {
+		if len(result.Info.Title) == 0 {
+			result.Info = api.Info
+		}
+		if len(result.Service.Name) == 0 {
+			result.Service.Name = api.Service.Name
+		}
+		result.Types = append(result.Types, api.Types...)
+		result.Service.Routes = append(result.Service.Routes, api.Service.Routes...)
+		result.Service.Groups = append(result.Service.Groups, api.Service.Groups...)
+	}
+	return result
+}
+
+func targetOrder(t Target) int {
+	switch t {
+	case RouteTarget:
+		return 0
+	case TypeTarget:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type routeInfo struct {
+	route spec.Route
+	jwt   bool
+}
+
+func routeKey(route spec.Route) string {
+	return strings.ToUpper(route.Method) + " " + route.Path
+}
+
+func collectRoutes(api *spec.ApiSpec) map[string]routeInfo {
+	result := make(map[string]routeInfo)
+	for _, g := range api.Service.Groups {
+		for _, route := range g.Routes {
+			result[routeKey(route)] = routeInfo{route: route, jwt: g.Jwt}
+		}
+	}
+	return result
+}
+
+func compareRoutes(old, new *spec.ApiSpec) []Change {
+	var changes []Change
+	oldRoutes, newRoutes := collectRoutes(old), collectRoutes(new)
+	for key := range oldRoutes {
+		if _, ok := newRoutes[key]; !ok {
+			changes = append(changes, Change{Kind: Removed, Target: RouteTarget, Name: key, Breaking: true})
+		}
+	}
+	for key, n := range newRoutes {
+		o, ok := oldRoutes[key]
+		if !ok {
+			changes = append(changes, Change{Kind: Added, Target: RouteTarget, Name: key})
+			continue
+		}
+
+		change := Change{Kind: Changed, Target: RouteTarget, Name: key}
+		if o.route.RequestType.Name != n.route.RequestType.Name {
+			change.Details = append(change.Details, fmt.Sprintf("request type %s", describeTypeChange(o.route.RequestType.Name, n.route.RequestType.Name)))
+			// a new request type is only compatible if there was none and there is nothing to send now
+			change.Breaking = change.Breaking || len(o.route.RequestType.Name) > 0 || hasRequiredMember(new, n.route.RequestType)
+		}
+		if o.route.ResponseType.Name != n.route.ResponseType.Name {
+			change.Details = append(change.Details, fmt.Sprintf("response type %s", describeTypeChange(o.route.ResponseType.Name, n.route.ResponseType.Name)))
+			change.Breaking = change.Breaking || len(o.route.ResponseType.Name) > 0
+		}
+		if or, nr := describeResponses(o.route), describeResponses(n.route); or != nr {
+			// the clients tell the responses apart by status, so their results change along with them
+			change.Details = append(change.Details, fmt.Sprintf("responses changed from %q to %q", or, nr))
+			change.Breaking = true
+		}
+		if o.route.Stream != n.route.Stream {
+			change.Details = append(change.Details, fmt.Sprintf("stream changed from %q to %q", o.route.Stream, n.route.Stream))
+			change.Breaking = true
+		}
+		if o.jwt != n.jwt {
+			if n.jwt {
+				change.Details = append(change.Details, "jwt authentication required")
+				change.Breaking = true
+			} else {
+				change.Details = append(change.Details, "jwt authentication no longer required")
+			}
+		}
+		if o.route.Summary != n.route.Summary {
+			change.Details = append(change.Details, fmt.Sprintf("summary changed from %q to %q", o.route.Summary, n.route.Summary))
+		}
+		if len(change.Details) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func describeTypeChange(old, new string) string {
+	switch {
+	case len(old) == 0:
+		return fmt.Sprintf("%s added", new)
+	case len(new) == 0:
+		return fmt.Sprintf("%s removed", old)
+	default:
+		return fmt.Sprintf("changed from %s to %s", old, new)
+	}
+}
+
+// describeResponses returns the responses of a route as declared by returns, e.g. 200: user, 404: notFound
+func describeResponses(route spec.Route) string {
+	var items []string
+	for _, r := range route.Responses {
+		if len(r.Type.Name) > 0 {
+			items = append(items, fmt.Sprintf("%d: %s", r.Status, r.Type.Name))
+		} else {
+			items = append(items, strconv.Itoa(r.Status))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+func hasRequiredMember(api *spec.ApiSpec, tp spec.Type) bool {
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		if isRequired(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeUsage tells whether a type is sent in a request and/or received in a response, nested types included.
+type typeUsage struct {
+	request  map[string]bool
+	response map[string]bool
+}
+
+func collectUsage(api *spec.ApiSpec) typeUsage {
+	usage := typeUsage{request: make(map[string]bool), response: make(map[string]bool)}
+	for _, g := range api.Service.Groups {
+		for _, route := range g.Routes {
+			rts, rpts := util.GetAllTypes(api, route)
+			for _, tp := range rts {
+				usage.request[tp.Name] = true
+			}
+			for _, tp := range rpts {
+				usage.response[tp.Name] = true
+			}
+		}
+	}
+	return usage
+}
+
+func collectTypes(api *spec.ApiSpec) map[string]spec.Type {
+	result := make(map[string]spec.Type)
+	for _, tp := range api.Types {
+		result[tp.Name] = tp
+	}
+	return result
+}
+
+func compareTypes(old, new *spec.ApiSpec) []Change {
+	var changes []Change
+	oldTypes, newTypes := collectTypes(old), collectTypes(new)
+	oldUsage, newUsage := collectUsage(old), collectUsage(new)
+	for name := range oldTypes {
+		if _, ok := newTypes[name]; !ok {
+			// the routes and members using a removed type are reported as changed already
+			changes = append(changes, Change{Kind: Removed, Target: TypeTarget, Name: name})
+		}
+	}
+	for name, n := range newTypes {
+		o, ok := oldTypes[name]
+		if !ok {
+			changes = append(changes, Change{Kind: Added, Target: TypeTarget, Name: name})
+			continue
+		}
+		// a type is checked as it is used now, and as it was used before if it's still used the same way
+		request := newUsage.request[name] && oldUsage.request[name]
+		response := newUsage.response[name] && oldUsage.response[name]
+		changes = append(changes, compareMembers(o, n, request, response)...)
+	}
+	return changes
+}
+
+func compareMembers(old, new spec.Type, request, response bool) []Change {
+	var changes []Change
+	oldMembers := make(map[string]spec.Member)
+	for _, member := range old.Members {
+		oldMembers[member.Name] = member
+	}
+	newMembers := make(map[string]spec.Member)
+	for _, member := range new.Members {
+		newMembers[member.Name] = member
+	}
+
+	for name := range oldMembers {
+		if _, ok := newMembers[name]; !ok {
+			changes = append(changes, Change{Kind: Removed, Target: MemberTarget, Name: new.Name + "." + name,
+				// the server ignores a field it doesn't know any more, but the clients may rely on it
+				Breaking: response})
+		}
+	}
+	for name, n := range newMembers {
+		o, ok := oldMembers[name]
+		if !ok {
+			changes = append(changes, Change{Kind: Added, Target: MemberTarget, Name: new.Name + "." + name,
+				Breaking: request && isRequired(n)})
+			continue
+		}
+
+		change := Change{Kind: Changed, Target: MemberTarget, Name: new.Name + "." + name}
+		if o.Type != n.Type {
+			change.Details = append(change.Details, fmt.Sprintf("type changed from %s to %s", o.Type, n.Type))
+			change.Breaking = change.Breaking || request || response
+		}
+		if location(o) != location(n) || o.GetTagName() != n.GetTagName() {
+			change.Details = append(change.Details, fmt.Sprintf("sent as %s instead of %s", describeLocation(n), describeLocation(o)))
+			change.Breaking = change.Breaking || request || response
+		}
+		if isRequired(o) != isRequired(n) {
+			if isRequired(n) {
+				change.Details = append(change.Details, "now required")
+				change.Breaking = change.Breaking || request
+			} else {
+				change.Details = append(change.Details, "now optional")
+				change.Breaking = change.Breaking || response
+			}
+		}
+		if len(change.Details) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func isRequired(member spec.Member) bool {
+	return !member.IsOptional() && !member.IsOmitempty() && !strings.HasPrefix(member.Type, "*")
+}
+
+func location(member spec.Member) string {
+	switch {
+	case member.IsInline:
+		return ""
+	case member.IsPathMember():
+		return spec.PathTag
+	case member.IsFormMember():
+		return spec.FormTag
+	case member.IsHeaderMember():
+		return spec.HeaderTag
+	default:
+		return spec.BodyTag
+	}
+}
+
+func describeLocation(member spec.Member) string {
+	if member.IsInline {
+		return "embedded"
+	}
+	return fmt.Sprintf("%s %q", location(member), member.GetTagName())
+}
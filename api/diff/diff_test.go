@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+const oldApi = `
+type (
+	getRequest struct {
+		name string ` + "`path:\"name\"`" + `
+	}
+
+	getResponse struct {
+		name string ` + "`json:\"name\"`" + `
+		age  int    ` + "`json:\"age\"`" + `
+	}
+
+	createRequest struct {
+		name string ` + "`json:\"name\"`" + `
+	}
+)
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(getRequest) returns(getResponse)
+
+	@server(
+		handler: CreateUserHandler
+	)
+	post /api/user(createRequest)
+
+	@server(
+		handler: PingHandler
+	)
+	get /api/ping()
+}
+`
+
+const newApi = `
+type (
+	createRequest struct {
+		name  string ` + "`json:\"name\"`" + `
+		email string ` + "`json:\"email\"`" + `
+		nick  string ` + "`json:\"nick,optional\"`" + `
+	}
+
+	getRequest struct {
+		name string ` + "`path:\"name\"`" + `
+	}
+
+	getResponse struct {
+		name  string ` + "`json:\"name\"`" + `
+		age   int64  ` + "`json:\"age\"`" + `
+		email string ` + "`json:\"email\"`" + `
+	}
+)
+
+service user-api {
+	@server(
+		handler: CreateUserHandler
+	)
+	post /api/user(createRequest)
+
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(getRequest) returns(getResponse)
+
+	@server(
+		handler: ListUserHandler
+	)
+	get /api/users() returns(getResponse)
+}
+`
+
+func parse(t *testing.T, text string) *spec.ApiSpec {
+	p, err := parser.NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	return api
+}
+
+func TestCompare(t *testing.T) {
+	changes := Compare(parse(t, oldApi), parse(t, newApi))
+	assert.Equal(t, []Change{
+		{Kind: Removed, Target: RouteTarget, Name: "GET /api/ping", Breaking: true},
+		{Kind: Added, Target: RouteTarget, Name: "GET /api/users"},
+		{Kind: Added, Target: MemberTarget, Name: "createRequest.email", Breaking: true},
+		{Kind: Added, Target: MemberTarget, Name: "createRequest.nick"},
+		{Kind: Changed, Target: MemberTarget, Name: "getResponse.age", Details: []string{"type changed from int to int64"}, Breaking: true},
+		{Kind: Added, Target: MemberTarget, Name: "getResponse.email"},
+	}, changes)
+	assert.Len(t, Breaking(changes), 3)
+}
+
+func TestCompareSame(t *testing.T) {
+	assert.Empty(t, Compare(parse(t, newApi), parse(t, newApi)))
+}
@@ -0,0 +1,36 @@
+package echogen
+
+import (
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/urfave/cli"
+)
+
+var framework = &servergen.Framework{
+	Name:               "echo",
+	ConfigExt:          "json",
+	EtcTemplate:        servergen.JsonEtcTemplate,
+	ConfigTemplate:     servergen.JsonConfigTemplate,
+	MainTemplate:       mainTemplate,
+	HttpxTemplate:      httpxTemplate,
+	ContextTemplate:    contextTemplate,
+	MiddlewareTemplate: middlewareTemplate,
+	JwtTemplate:        jwtMiddlewareTemplate,
+	LimitTemplate:      limitMiddlewareTemplate,
+	HandlerTemplate:    handlerTemplate,
+	RoutesTemplate:     routesTemplate,
+	Methods: map[string]string{
+		"delete": "DELETE",
+		"get":    "GET",
+		"head":   "HEAD",
+		"post":   "POST",
+		"put":    "PUT",
+		"patch":  "PATCH",
+		"all":    "Any",
+	},
+	HtmlParams: "c echo.Context",
+	HtmlImport: `"github.com/labstack/echo/v4"`,
+}
+
+func GoCommand(c *cli.Context) error {
+	return servergen.GoCommand(c, framework)
+}
@@ -0,0 +1,292 @@
+package echogen
+
+const (
+	mainTemplate = `package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"{{.configPkg}}"
+	"{{.handlerPkg}}"
+	"{{.svcPkg}}"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+var configFile = flag.String("f", "{{.configFile}}", "the config file")
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	config.MustLoad(*configFile, &c)
+
+	ctx := svc.NewServiceContext(c)
+	server := echo.New()
+	server.HideBanner = true
+	server.Use(middleware.Logger(), middleware.Recover())
+	handler.RegisterHandlers(server, ctx)
+
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	fmt.Printf("Starting server at %s...\n", addr)
+	log.Fatal(server.Start(addr))
+}
+`
+	httpxTemplate = `package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+{{template "codeError"}}
+// Error writes err as a CodeError, the status is its code if it's an http error status, otherwise 400.
+func Error(c echo.Context, err error) error {
+	return c.JSON(toCodeError(err))
+}
+
+// Parse parses the json body, the path, form and header parameters of the request into v by the tags
+// of its fields, the parameters take precedence over the body.
+func Parse(c echo.Context, v any) error {
+	return parse(c.Request(), c.Param, v)
+}
+{{template "parse"}}
+{{template "checkFiles"}}
+{{template "writeBinary"}}`
+	contextTemplate = `package svc
+
+import (
+	"{{.configPkg}}"{{if .middlewares}}
+	"{{.middlewarePkg}}"
+
+	"github.com/labstack/echo/v4"{{end}}
+)
+
+type ServiceContext struct {
+	Config config.Config
+	{{- range .middlewares}}
+	{{.}} echo.MiddlewareFunc
+	{{- end}}
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+		{{- range .middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+		{{- end}}
+	}
+}
+`
+	middlewareTemplate = `package middleware
+
+import "github.com/labstack/echo/v4"
+
+type {{.name}}Middleware struct {
+}
+
+func New{{.name}}Middleware() *{{.name}}Middleware {
+	return &{{.name}}Middleware{}
+}
+
+func (m *{{.name}}Middleware) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// todo: add your logic here, return without calling next to reject the request
+
+		return next(c)
+	}
+}
+`
+	jwtMiddlewareTemplate = `package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"{{.httpxPkg}}"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Jwt rejects the requests without a valid token signed by secret as the bearer of the Authorization header,
+// the claims of the token are set as the values of the echo context.
+func Jwt(secret string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, e := parseToken(strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer "), []byte(secret))
+			if e != nil {
+				return httpx.Error(c, httpx.NewCodeError(http.StatusUnauthorized, e.Error()))
+			}
+			for k, v := range claims {
+				c.Set(k, v)
+			}
+			return next(c)
+		}
+	}
+}
+{{template "parseToken"}}`
+	limitMiddlewareTemplate = `package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Timeout cancels the context of the requests after d, the logic should return once the context is done.
+func Timeout(d time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// MaxBytes limits the request bodies to n bytes.
+func MaxBytes(n int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			r.Body = http.MaxBytesReader(c.Response(), r.Body, n)
+			return next(c)
+		}
+	}
+}
+`
+	handlerTemplate = `package {{.package}}
+
+import ({{if or .files (not (or .html .binary))}}
+	"net/http"
+{{end}}
+	logic "{{.logicPkg}}"
+	"{{.pkg}}/internal/httpx"
+	"{{.pkg}}/internal/svc"{{if .request}}
+	"{{.pkg}}/internal/types"{{end}}
+
+	"github.com/labstack/echo/v4"
+)
+
+func {{.handler}}(svcCtx *svc.ServiceContext) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		{{- if .files}}
+		// the files are limited by their maxSize, the form fields by the rest
+		c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, {{.maxBytes}})
+		{{- end}}
+		{{- if .request}}
+		var req types.{{.request}}
+		if e := httpx.Parse(c, &req); e != nil {
+			return httpx.Error(c, e)
+		}
+		{{- range .files}}
+		if e := httpx.CheckFiles("{{.Field}}", {{.MaxSize}}, {{.Optional}}, req.{{.Name}}{{if .List}}...{{end}}); e != nil {
+			return httpx.Error(c, e)
+		}
+		{{- end}}
+		{{- end}}
+
+		l := logic.New{{.name}}Logic(c.Request().Context(), svcCtx)
+		{{- if .html}}
+		if e := l.{{.name}}(c{{if .request}}, req{{end}}); e != nil {
+			return httpx.Error(c, e)
+		}
+		return nil
+		{{- else if .binary}}
+		name, content, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			return httpx.Error(c, e)
+		}
+		httpx.WriteBinary(c.Response(), c.Request(), name, content)
+		return nil
+		{{- else if .responses}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			return httpx.Error(c, e)
+		}
+		if resp == nil {
+			return c.String(http.StatusInternalServerError, "no response")
+		}
+		if !resp.HasBody() {
+			return c.NoContent(resp.Status)
+		}
+		return c.JSON(resp.Status, resp.Body)
+		{{- else if .response}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			return httpx.Error(c, e)
+		}
+		return c.JSON(http.StatusOK, resp)
+		{{- else}}
+		if e := l.{{.name}}({{if .request}}req{{end}}); e != nil {
+			return httpx.Error(c, e)
+		}
+		return c.NoContent(http.StatusOK)
+		{{- end}}
+	}
+}
+`
+	// the middlewares are passed to the routes, a group of echo with middlewares would also answer
+	// the unknown paths under its prefix
+	routesTemplate = `// DO NOT EDIT, generated by goctl
+package handler
+
+import ({{if .time}}
+	"time"
+{{end}}
+	{{.imports}}
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterHandlers(server *echo.Echo, serverCtx *svc.ServiceContext) {
+	{{- range .groups}}{{$group := .}}
+	{{- range .Routes}}
+	server.{{.Method}}("{{.FullPath}}", {{.Handler}}{{range $group.Middlewares}}, {{.}}{{end}}{{range .Middlewares}}, {{.}}{{end}})
+	{{- end}}
+	{{- end}}
+}
+{{- if .deprecated}}
+
+// deprecated sets the Deprecation, Sunset and Link headers of the responses of a deprecated route, the empty ones aren't set.
+func deprecated(next echo.HandlerFunc, deprecation, sunset, link string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Response().Header()
+		header.Set("Deprecation", deprecation)
+		if len(sunset) > 0 {
+			header.Set("Sunset", sunset)
+		}
+		if len(link) > 0 {
+			header.Add("Link", link)
+		}
+		return next(c)
+	}
+}
+{{- end}}
+`
+)
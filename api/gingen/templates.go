@@ -0,0 +1,329 @@
+package gingen
+
+const (
+	etcTemplate = `Name: {{.serviceName}}
+Host: {{.host}}
+Port: {{.port}}
+{{- range .auths}}
+{{.}}:
+  # the secret signing the jwt tokens
+  AccessSecret: change-me
+  AccessExpire: 86400
+{{- end}}
+`
+	configTemplate = `package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Name string ` + "`yaml:\"Name\"`" + `
+	Host string ` + "`yaml:\"Host\"`" + `
+	Port int    ` + "`yaml:\"Port\"`" + `
+	{{- range .auths}}
+	{{.}} struct {
+		AccessSecret string ` + "`yaml:\"AccessSecret\"`" + `
+		AccessExpire int64  ` + "`yaml:\"AccessExpire\"`" + `
+	} ` + "`yaml:\"{{.}}\"`" + `
+	{{- end}}
+}
+
+// MustLoad loads the yaml config file into c, it panics on errors.
+func MustLoad(file string, c *Config) {
+	b, e := os.ReadFile(file)
+	if e != nil {
+		panic(e)
+	}
+	if e := yaml.Unmarshal(b, c); e != nil {
+		panic(e)
+	}
+}
+`
+	mainTemplate = `package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"{{.configPkg}}"
+	"{{.handlerPkg}}"
+	"{{.svcPkg}}"
+
+	"github.com/gin-gonic/gin"
+)
+
+var configFile = flag.String("f", "{{.configFile}}", "the config file")
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	config.MustLoad(*configFile, &c)
+
+	ctx := svc.NewServiceContext(c)
+	engine := gin.New()
+	engine.Use(gin.Logger(), gin.Recovery())
+	handler.RegisterRoutes(engine, ctx)
+
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	fmt.Printf("Starting server at %s...\n", addr)
+	log.Fatal(engine.Run(addr))
+}
+`
+	httpxTemplate = `package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+{{template "codeError"}}
+// Error aborts the request with err as a CodeError, the status is its code if it's an http error status,
+// otherwise 400.
+func Error(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(toCodeError(err))
+}
+
+// Parse parses the json body, the path, form and header parameters of the request into v by the tags
+// of its fields, the parameters take precedence over the body.
+func Parse(c *gin.Context, v any) error {
+	return parse(c.Request, c.Param, v)
+}
+{{template "parse"}}
+{{template "checkFiles"}}
+{{template "writeBinary"}}`
+	contextTemplate = `package svc
+
+import (
+	"{{.configPkg}}"{{if .middlewares}}
+	"{{.middlewarePkg}}"
+
+	"github.com/gin-gonic/gin"{{end}}
+)
+
+type ServiceContext struct {
+	Config config.Config
+	{{- range .middlewares}}
+	{{.}} gin.HandlerFunc
+	{{- end}}
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+		{{- range .middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+		{{- end}}
+	}
+}
+`
+	middlewareTemplate = `package middleware
+
+import "github.com/gin-gonic/gin"
+
+type {{.name}}Middleware struct {
+}
+
+func New{{.name}}Middleware() *{{.name}}Middleware {
+	return &{{.name}}Middleware{}
+}
+
+func (m *{{.name}}Middleware) Handle(c *gin.Context) {
+	// todo: add your logic here, call c.Abort or httpx.Error to reject the request
+
+	c.Next()
+}
+`
+	jwtMiddlewareTemplate = `package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"{{.httpxPkg}}"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jwt rejects the requests without a valid token signed by secret as the bearer of the Authorization header,
+// the claims of the token are set as the keys of the gin context.
+func Jwt(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, e := parseToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "), []byte(secret))
+		if e != nil {
+			httpx.Error(c, httpx.NewCodeError(http.StatusUnauthorized, e.Error()))
+			return
+		}
+		for k, v := range claims {
+			c.Set(k, v)
+		}
+		c.Next()
+	}
+}
+{{template "parseToken"}}`
+	limitMiddlewareTemplate = `package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout cancels the context of the requests after d, the logic should return once the context is done.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// MaxBytes limits the request bodies to n bytes.
+func MaxBytes(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		c.Next()
+	}
+}
+`
+	handlerTemplate = `package {{.package}}
+
+import ({{if or .files (not (or .html .binary))}}
+	"net/http"
+{{end}}
+	logic "{{.logicPkg}}"
+	"{{.pkg}}/internal/httpx"
+	"{{.pkg}}/internal/svc"{{if .request}}
+	"{{.pkg}}/internal/types"{{end}}
+
+	"github.com/gin-gonic/gin"
+)
+
+func {{.handler}}(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		{{- if .files}}
+		// the files are limited by their maxSize, the form fields by the rest
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, {{.maxBytes}})
+		{{- end}}
+		{{- if .request}}
+		var req types.{{.request}}
+		if e := httpx.Parse(c, &req); e != nil {
+			httpx.Error(c, e)
+			return
+		}
+		{{- range .files}}
+		if e := httpx.CheckFiles("{{.Field}}", {{.MaxSize}}, {{.Optional}}, req.{{.Name}}{{if .List}}...{{end}}); e != nil {
+			httpx.Error(c, e)
+			return
+		}
+		{{- end}}
+		{{- end}}
+
+		l := logic.New{{.name}}Logic(c.Request.Context(), svcCtx)
+		{{- if .html}}
+		if e := l.{{.name}}(c{{if .request}}, req{{end}}); e != nil {
+			httpx.Error(c, e)
+		}
+		{{- else if .binary}}
+		name, content, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(c, e)
+			return
+		}
+		httpx.WriteBinary(c.Writer, c.Request, name, content)
+		{{- else if .responses}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(c, e)
+		} else if resp == nil {
+			c.String(http.StatusInternalServerError, "no response")
+		} else if !resp.HasBody() {
+			c.Status(resp.Status)
+		} else {
+			c.JSON(resp.Status, resp.Body)
+		}
+		{{- else if .response}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(c, e)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+		{{- else}}
+		if e := l.{{.name}}({{if .request}}req{{end}}); e != nil {
+			httpx.Error(c, e)
+			return
+		}
+		c.Status(http.StatusOK)
+		{{- end}}
+	}
+}
+`
+	routesTemplate = `// DO NOT EDIT, generated by goctl
+package handler
+
+import ({{if .time}}
+	"time"
+{{end}}
+	{{.imports}}
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(engine *gin.Engine, serverCtx *svc.ServiceContext) {
+	{{- range .groups}}
+	{
+		group := engine.Group("{{.Prefix}}")
+		{{- if .Middlewares}}
+		group.Use({{join .Middlewares}})
+		{{- end}}
+		{{- range .Routes}}
+		group.{{.Method}}("{{.Path}}", {{range .Middlewares}}{{.}}, {{end}}{{.Handler}})
+		{{- end}}
+	}
+	{{- end}}
+}
+{{- if .deprecated}}
+
+// deprecated sets the Deprecation, Sunset and Link headers of the responses of a deprecated route, the empty ones aren't set.
+func deprecated(next gin.HandlerFunc, deprecation, sunset, link string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if len(sunset) > 0 {
+			c.Header("Sunset", sunset)
+		}
+		if len(link) > 0 {
+			c.Writer.Header().Add("Link", link)
+		}
+		next(c)
+	}
+}
+{{- end}}
+`
+)
@@ -1,5 +0,0 @@
-package gingen
-
-const (
-	SERVER_TYPE_HTML = "html"
-)
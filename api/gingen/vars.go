@@ -1,12 +0,0 @@
-package gingen
-
-const (
-	interval       = "internal/"
-	typesPacket    = "types"
-	configDir      = interval + "config"
-	contextDir     = interval + "svc"
-	handlerDir     = interval + "handler"
-	logicDir       = interval + "logic"
-	typesDir       = interval + typesPacket
-	folderProperty = "folder"
-)
@@ -0,0 +1,211 @@
+package gocligen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const fakeTemplate = `package {{.pkg}}
+
+import (
+	"context"
+	{{- if .hasFile}}
+	"strings"
+	{{- end}}
+	"sync"
+)
+
+var _ {{.api}} = (*{{.fake}})(nil)
+
+// {{.fake}} is an in-memory {{.api}} for the tests, it records the calls and answers them by the functions set,
+// the calls without a function succeed with empty responses. The zero value is ready to use.
+type {{.fake}} struct {
+	{{- range .funcs}}
+	{{.Name}}Func func({{.Params}}) {{.Results}}
+	{{- end}}
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// FakeCall is a call recorded by a fake api, Req is nil for the routes without request.
+type FakeCall struct {
+	Method string
+	Req    interface{}
+}
+
+// Calls returns the calls recorded so far.
+func (f *{{.fake}}) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// CallsOf returns the requests of the recorded calls of method, e.g. {{with index .funcs 0}}{{.Name}}{{end}}.
+func (f *{{.fake}}) CallsOf(method string) []interface{} {
+	var reqs []interface{}
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			reqs = append(reqs, call.Req)
+		}
+	}
+	return reqs
+}
+
+// Reset forgets the recorded calls.
+func (f *{{.fake}}) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *{{.fake}}) record(method string, req interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Method: method, Req: req})
+}
+{{range .funcs}}
+func (f *{{$.fake}}) {{.Name}}({{.Params}}) {{.Results}} {
+	f.record("{{.Name}}", {{if .Request}}req{{else}}nil{{end}})
+	if f.{{.Name}}Func != nil {
+		return f.{{.Name}}Func({{.Args}})
+	}
+	return {{.Zero}}
+}
+{{end}}`
+
+// apiFunc is the signature of an api function, which is shared by the interface, the client and the fake.
+type apiFunc struct {
+	Name    string
+	Params  string
+	Results string
+	// Args passes the parameters on, e.g. ctx, req, opts...
+	Args    string
+	Request bool
+	// Zero is the result of a call without a function set
+	Zero string
+	// Deprecated is the message of the deprecated route, empty if it isn't
+	Deprecated string
+}
+
+// apiName returns the name of the interface of the service, e.g. UserApi for user-api.
+func apiName(api *spec.ApiSpec) string {
+	return strcase.ToCamel(strings.TrimSuffix(api.Service.Name, "-api")) + "Api"
+}
+
+// clientName returns the name of the type calling the server, e.g. UserApiClient implementing UserApi.
+func clientName(api *spec.ApiSpec) string {
+	return apiName(api) + "Client"
+}
+
+// apiFuncs returns the api functions of the json routes.
+func apiFuncs(api *spec.ApiSpec) []apiFunc {
+	var funcs []apiFunc
+	for _, route := range api.Service.Routes {
+		req := typeName(route.RequestType.Name)
+		res := typeName(route.ResponseType.Name)
+		f := apiFunc{
+			Name:       strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)),
+			Request:    len(req) > 0,
+			Deprecated: route.Deprecation.Message(),
+		}
+		params := []string{"ctx context.Context"}
+		args := []string{"ctx"}
+		if f.Request {
+			params = append(params, "req "+req)
+			args = append(args, "req")
+		}
+		switch {
+		case len(route.Stream) > 0:
+			if route.Stream == "ws" && f.Request {
+				params = append(params, "messages <-chan *"+req)
+				args = append(args, "messages")
+			}
+			params = append(params, "onEvent func(*"+res+") error")
+			args = append(args, "onEvent")
+			f.Results, f.Zero = "error", "nil"
+		case route.Binary:
+			f.Results, f.Zero = "(*ApiFile, error)", `&ApiFile{Content: strings.NewReader("")}, nil`
+		case len(route.Responses) > 0:
+			f.Results, f.Zero = resultFunc(f.Name, route)
+		case len(res) > 0:
+			f.Results, f.Zero = "(*"+res+", error)", "&"+res+"{}, nil"
+		default:
+			f.Results, f.Zero = "error", "nil"
+		}
+		f.Params = strings.Join(append(params, "opts ...CallOption"), ", ")
+		f.Args = strings.Join(append(args, "opts..."), ", ")
+		funcs = append(funcs, f)
+	}
+	return funcs
+}
+
+// resultFunc returns the results of a route with multiple responses and their zero, which is the first 2xx response.
+func resultFunc(name string, route spec.Route) (string, string) {
+	result := name + "Result"
+	for _, response := range route.Responses {
+		if response.Status/100 != 2 {
+			continue
+		}
+		if len(response.Type.Name) == 0 {
+			return "(*" + result + ", error)", fmt.Sprintf("&%s{Status: %d}, nil", result, response.Status)
+		}
+		return "(*" + result + ", error)", fmt.Sprintf("&%s{Status: %d, %s: &%s{}}, nil", result, response.Status,
+			util.StatusName(response.Status), typeName(response.Type.Name))
+	}
+	return "(*" + result + ", error)", "&" + result + "{}, nil"
+}
+
+// genFake writes <name>fake.go with the in-memory implementation of the interface of the service.
+func genFake(dir, pkg, name string, api *spec.ApiSpec, funcs []apiFunc) error {
+	if len(funcs) == 0 {
+		return nil
+	}
+	file, e := os.OpenFile(filepath.Join(dir, name+"fake.go"), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	hasFile := false
+	for _, f := range funcs {
+		hasFile = hasFile || strings.Contains(f.Results, "*ApiFile")
+	}
+	t, e := template.New(name + "fake.go").Parse(fakeTemplate)
+	if e != nil {
+		return e
+	}
+	return executeFormatted(file, t, map[string]interface{}{
+		"pkg":     pkg,
+		"api":     apiName(api),
+		"fake":    "Fake" + apiName(api),
+		"funcs":   funcs,
+		"hasFile": hasFile,
+	})
+}
+
+// protobufApiFunc returns the api function taking and returning the compiled messages.
+func protobufApiFunc(name, req, res string) apiFunc {
+	f := apiFunc{Name: name, Request: len(req) > 0}
+	params := []string{"ctx context.Context"}
+	args := []string{"ctx"}
+	if f.Request {
+		params = append(params, "req *"+req)
+		args = append(args, "req")
+	}
+	if len(res) > 0 {
+		f.Results, f.Zero = "(*"+res+", error)", "&"+res+"{}, nil"
+	} else {
+		f.Results, f.Zero = "error", "nil"
+	}
+	f.Params = strings.Join(append(params, "opts ...CallOption"), ", ")
+	f.Args = strings.Join(append(args, "opts..."), ", ")
+	return f
+}
@@ -1,36 +1,67 @@
 package gocligen
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"go/format"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/protogen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
 	"github.com/urfave/cli"
 )
 
 const (
-	apiTemplate = `package api
+	// ErrorsTemplate declares the codes of the errors block of the api file, which is executed with Errors.
+	ErrorsTemplate = `{{if .Errors}}
+// the codes of the errors declared in the api file, e.g. errors.As(err, &errorCode) && errorCode.Code == {{with index .Errors 0}}Code{{.Name}}{{end}}
+const (
+	{{- range .Errors}}
+	// Code{{.Name}} {{.Desc}}
+	Code{{.Name}} = {{.Code}}
+	{{- end}}
+)
+{{end}}`
+	// ClientTemplate is api.go, the Client sending the requests, which is executed with the package as pkg
+	// and whether the messages are compiled from the proto file as protobuf.
+	ClientTemplate = `package {{.pkg}}
 
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"strconv"
+	"net/url"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
+// ErrorCode is the error of a call, Status is the http status of the response and 0 if there is no response.
 type ErrorCode struct {
-	Code int    ` + "`" + `json:"code"` + "`" + `
-	Desc string ` + "`" + `json:"desc"` + "`" + `
+	Status int    ` + "`" + `json:"status,omitempty"` + "`" + `
+	Code   int    ` + "`" + `json:"code"` + "`" + `
+	Desc   string ` + "`" + `json:"desc"` + "`" + `
+	// Err is the error of the transport or the decoding, e.g. context.DeadlineExceeded
+	Err error ` + "`" + `json:"-"` + "`" + `
+	// body is the body of the response, which is decoded by the routes with multiple responses
+	body []byte
 }
 
 func (e *ErrorCode) Error() string {
@@ -38,116 +69,828 @@ func (e *ErrorCode) Error() string {
 	return string(b)
 }
 
-const (
-	server = "http://localhost"
-)
+func (e *ErrorCode) Unwrap() error {
+	return e.Err
+}
 
-var (
-	client = http.Client{
-		Timeout: time.Second * 5,
+// StatusCode returns the http status of the result of a call, it's 200 without errors and 0 if the server isn't reached.
+func StatusCode(e error) int {
+	if e == nil {
+		return http.StatusOK
 	}
-)
+	var err *ErrorCode
+	if errors.As(e, &err) {
+		return err.Status
+	}
+	return 0
+}
+
+// Client sends the requests to the server of baseURL, it's safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+	gzip       bool
+	{{- if .protobuf}}
+	// encoding is how the messages are sent and asked for
+	encoding Encoding
+	{{- end}}
+	requestHooks  []func(r *http.Request) error
+	responseHooks []func(r *http.Request, res *http.Response, e error)
+	// deprecationHook is called with the responses of the deprecated routes, see WithDeprecationHook
+	deprecationHook func(r *http.Request, res *http.Response)
+	// warned are the deprecated routes logged, keyed by the method and the path
+	warned sync.Map
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// NewClient returns a client of the server of baseURL, e.g. http://localhost:8888
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+		timeout:    10 * time.Second,
+		backoff:    100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithHTTPClient sends the requests by httpClient, its Timeout also applies to the streams and the files.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader sets a header of every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithTimeout limits every call but the streams and the files, 10s by default and 0 for no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry retries the GET, HEAD, PUT, DELETE and OPTIONS calls failed by the network or answered 429, 502, 503 or 504,
+// the nth retry waits backoff*2^(n-1).
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithGzip compresses the request bodies, the servers must accept Content-Encoding: gzip.
+func WithGzip() Option {
+	return func(c *Client) {
+		c.gzip = true
+	}
+}
+
+// WithRequestHook calls fn before sending every request, e.g. to set a token or to inject a trace, an error of fn fails the call.
+func WithRequestHook(fn func(r *http.Request) error) Option {
+	return func(c *Client) {
+		c.requestHooks = append(c.requestHooks, fn)
+	}
+}
+
+// WithResponseHook calls fn after every attempt with its response or error, e.g. to end a trace span.
+func WithResponseHook(fn func(r *http.Request, res *http.Response, e error)) Option {
+	return func(c *Client) {
+		c.responseHooks = append(c.responseHooks, fn)
+	}
+}
+
+// WithDeprecationHook calls fn with the responses having the Deprecation header of a deprecated route, instead of
+// logging a warning once per route, e.g. to report the calls to the routes being removed by their Sunset header.
+func WithDeprecationHook(fn func(r *http.Request, res *http.Response)) Option {
+	return func(c *Client) {
+		c.deprecationHook = fn
+	}
+}
+
+type callOptions struct {
+	header http.Header
+}
+
+// CallOption configures a call.
+type CallOption func(o *callOptions)
+
+// CallHeader sets a header of the request of a call.
+func CallHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// call sends req as json and decodes the json response into res unless it's nil.
+func (c *Client) call(ctx context.Context, method, uri string, req, res interface{}, opts []CallOption) error {
+	_, e := c.callStatus(ctx, method, uri, req, func(status int) (interface{}, bool) {
+		return res, status < 300
+	}, opts)
+	return e
+}
+
+// callStatus sends req as json and decodes the body of the response into the value result returns for its status,
+// the responses of the statuses result doesn't accept are ErrorCodes unless they are 2xx.
+func (c *Client) callStatus(ctx context.Context, method, uri string, req interface{}, result func(status int) (interface{}, bool), opts []CallOption) (int, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-func apiRequest(method, uri string, req interface{}) (string, error) {
-	var bodyReader io.Reader
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	var body []byte
 	if req != nil {
 		b, e := json.Marshal(req)
 		if e != nil {
-			log.Println(e)
-			return "", &ErrorCode{Desc: e.Error()}
+			return 0, &ErrorCode{Desc: e.Error(), Err: e}
 		}
-		bodyReader = bytes.NewReader(b)
+		body = b
+		header.Set("Content-Type", "application/json")
 	}
-	r, e := http.NewRequest(method, server+uri, bodyReader)
+	var status int
+	var b []byte
+	rp, e := c.send(ctx, method, uri, header, body, opts)
 	if e != nil {
-		log.Println(e)
-		return "", &ErrorCode{Desc: e.Error()}
+		var err *ErrorCode
+		if !errors.As(e, &err) || err.Status == 0 {
+			return 0, e
+		}
+		if _, ok := result(err.Status); !ok {
+			return err.Status, e
+		}
+		status, b = err.Status, err.body
+	} else {
+		status = rp.StatusCode
+		if b, e = readBody(rp); e != nil {
+			return status, e
+		}
+	}
+	res, _ := result(status)
+	if res == nil || len(b) == 0 {
+		return status, nil
+	}
+	if e := json.Unmarshal(b, res); e != nil {
+		return status, &ErrorCode{Status: status, Desc: e.Error(), Err: e}
 	}
-	r.Header.Set("Content-Type", "application/json")
+	return status, nil
+}
 
-	//response
-	res, e := client.Do(r)
-	if e != nil {
-		log.Println(e)
-		return "", &ErrorCode{Desc: e.Error()}
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	defer res.Body.Close()
+	return context.WithTimeout(ctx, c.timeout)
+}
 
-	var rp string
-	if res.Header.Get("Content-Encoding") == "gzip" {
-		zr, e := gzip.NewReader(res.Body)
+// send sends the request until it succeeds or can't be retried, the body of the 2xx response returned is the caller's to close.
+func (c *Client) send(ctx context.Context, method, uri string, header http.Header, body []byte, opts []CallOption) (*http.Response, error) {
+	if c.gzip && len(body) > 0 {
+		b, e := gzipBytes(body)
 		if e != nil {
-			log.Println(e)
-			return "", &ErrorCode{Desc: e.Error()}
+			return nil, &ErrorCode{Desc: e.Error(), Err: e}
 		}
-		defer zr.Close()
-		buf := new(bytes.Buffer)
-		_, e = io.Copy(buf, zr)
+		body = b
+		header.Set("Content-Encoding", "gzip")
+	}
+	retries := 0
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		retries = c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		r, e := c.newRequest(ctx, method, uri, header, reader, opts)
 		if e != nil {
-			log.Println(e)
-			return "", &ErrorCode{Desc: e.Error()}
+			return nil, e
 		}
-		rp = buf.String()
-	} else {
-		b, e := io.ReadAll(res.Body)
+		rp, e := c.do(r)
+		if e == nil {
+			return rp, nil
+		}
+		if attempt >= retries || ctx.Err() != nil || !retryable(StatusCode(e)) {
+			return nil, e
+		}
+		select {
+		case <-time.After(c.backoff << attempt):
+		case <-ctx.Done():
+			return nil, &ErrorCode{Desc: ctx.Err().Error(), Err: ctx.Err()}
+		}
+	}
+}
+
+// do sends r and returns its 2xx response, whose body is the caller's to close.
+func (c *Client) do(r *http.Request) (*http.Response, error) {
+	rp, e := c.httpClient.Do(r)
+	for _, hook := range c.responseHooks {
+		hook(r, rp, e)
+	}
+	if e != nil {
+		return nil, &ErrorCode{Desc: e.Error(), Err: e}
+	}
+	if len(rp.Header.Get("Deprecation")) > 0 {
+		c.deprecated(r, rp)
+	}
+	if rp.StatusCode < 200 || rp.StatusCode >= 300 {
+		b, _ := readBody(rp)
+		return nil, newErrorCode(rp.StatusCode, b)
+	}
+	return rp, nil
+}
+
+// deprecated warns that the route of r is deprecated, the Sunset and Link headers of res tell when it's removed and why.
+func (c *Client) deprecated(r *http.Request, res *http.Response) {
+	if c.deprecationHook != nil {
+		c.deprecationHook(r, res)
+		return
+	}
+	if _, warned := c.warned.LoadOrStore(r.Method+" "+r.URL.Path, true); warned {
+		return
+	}
+	message := fmt.Sprintf("warning: %s %s is deprecated", r.Method, r.URL.Path)
+	if sunset := res.Header.Get("Sunset"); len(sunset) > 0 {
+		message += ", it will be removed on " + sunset
+	}
+	if link := res.Header.Get("Link"); len(link) > 0 {
+		message += ", see " + link
+	}
+	log.Println(message)
+}
+
+// newRequest returns the request of uri with the headers of the client, the call and header, the request hooks are called on it.
+func (c *Client) newRequest(ctx context.Context, method, uri string, header http.Header, body io.Reader, opts []CallOption) (*http.Request, error) {
+	r, e := http.NewRequestWithContext(ctx, method, c.baseURL+uri, body)
+	if e != nil {
+		return nil, &ErrorCode{Desc: e.Error(), Err: e}
+	}
+	call := callOptions{header: http.Header{}}
+	for _, opt := range opts {
+		opt(&call)
+	}
+	for _, h := range []http.Header{c.header, header, call.header} {
+		for k, v := range h {
+			r.Header[k] = v
+		}
+	}
+	for _, hook := range c.requestHooks {
+		if e := hook(r); e != nil {
+			return nil, &ErrorCode{Desc: e.Error(), Err: e}
+		}
+	}
+	return r, nil
+}
+
+// retryable tells whether a call failed by the network or answered status can be retried.
+func retryable(status int) bool {
+	switch status {
+	case 0, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// readBody reads and closes the body of rp, which is gunzipped if it's still compressed.
+func readBody(rp *http.Response) ([]byte, error) {
+	defer rp.Body.Close()
+	var reader io.Reader = rp.Body
+	if rp.Header.Get("Content-Encoding") == "gzip" {
+		zr, e := gzip.NewReader(rp.Body)
 		if e != nil {
-			log.Println(e)
-			return "", &ErrorCode{Desc: e.Error()}
+			return nil, &ErrorCode{Status: rp.StatusCode, Desc: e.Error(), Err: e}
 		}
-		rp = string(b)
+		defer zr.Close()
+		reader = zr
 	}
+	b, e := io.ReadAll(reader)
+	if e != nil {
+		return nil, &ErrorCode{Status: rp.StatusCode, Desc: e.Error(), Err: e}
+	}
+	return b, nil
+}
 
-	switch res.StatusCode {
-	case 200:
-		return rp, nil
-	case 400:
-		var err ErrorCode
-		if strings.HasPrefix(rp, "{") {
-			e = json.Unmarshal([]byte(rp), &err)
-			if e != nil {
-				log.Println(e)
-				return "", &ErrorCode{Desc: e.Error()}
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, e := zw.Write(b); e != nil {
+		return nil, e
+	}
+	if e := zw.Close(); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// newErrorCode returns the error of a response of status, whose body is the json of an ErrorCode or a text.
+func newErrorCode(status int, b []byte) *ErrorCode {
+	err := &ErrorCode{}
+	if json.Unmarshal(b, err) != nil || (err.Code == 0 && len(err.Desc) == 0) {
+		err = &ErrorCode{Desc: strings.TrimSpace(string(b))}
+	}
+	err.Status = status
+	err.body = b
+	return err
+}
+
+// apiQuery encodes the name value pairs as a query string, slices are sent as repeated parameters and nil pointers are skipped.
+func apiQuery(pairs ...interface{}) string {
+	values := apiFormValues(pairs...)
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// apiFormValues returns the name value pairs as form values, slices are sent as repeated values and nil pointers are skipped.
+func apiFormValues(pairs ...interface{}) url.Values {
+	values := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name := pairs[i].(string)
+		v := reflect.ValueOf(pairs[i+1])
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
 			}
-		} else {
-			err.Desc = strconv.Itoa(res.StatusCode) + ":" + rp
+			v = v.Elem()
 		}
-		return "", &err
-	default:
-		return "", &ErrorCode{Desc: strconv.Itoa(res.StatusCode) + ":" + rp}
+		if v.Kind() == reflect.Slice {
+			for j := 0; j < v.Len(); j++ {
+				values.Add(name, fmt.Sprint(v.Index(j).Interface()))
+			}
+			continue
+		}
+		values.Add(name, fmt.Sprint(v.Interface()))
 	}
+	return values
 }
-	
 `
 	apiFilesTemplate = `package {{.Info.Desc}}
 	
 import (
-	"encoding/json"
+	{{routeImports}}
 )
 
-type {{camelCase .Info.Title}}Api struct {
+// {{clientName}} calls the routes of the server, every call can be given CallOption.
+type {{clientName}} struct {
+	*Client
+}
+
+// New{{clientName}} returns the api of the server of baseURL, e.g. http://localhost:8888
+func New{{clientName}}(baseURL string, opts ...Option) *{{clientName}} {
+	return &{{clientName}}{Client: NewClient(baseURL, opts...)}
 }
 
+// {{apiName}} is the api of the {{.Service.Name}} service, {{clientName}} calls the server and Fake{{apiName}} answers in memory.
+type {{apiName}} interface { {{range apiFuncs}}{{if .Deprecated}}
+	// Deprecated: {{.Deprecated}}{{end}}
+	{{.Name}}({{.Params}}) {{.Results}}{{end}}
+}
+
+var _ {{apiName}} = (*{{clientName}})(nil)
+` + ErrorsTemplate + `
 type ({{range .Types}}
-	{{if eq 0 (len .Members)}}{{.Name}} struct{} {{else}}{{.Name}} struct{ {{range .Members}}
-		{{.Name}}	{{.Type}}	` + "`" + `json:"{{tagGet .Tag "json"}}"` + "`" + ` {{end}}
+	{{if eq 0 (len .Members)}}{{typeName .Name}} struct{} {{else}}{{typeName .Name}} struct{ {{range .Members}}
+		{{fieldName .Name}}	{{goType .Type}}	` + "`" + `json:"{{if .IsBodyMember}}{{tagGet .Tag "json"}}{{else}}-{{end}}"` + "`" + ` {{end}}
 	}{{end}}{{end}}
 )
-{{with .Service}}
-{{range .Routes}}func (api *{{camelCase $.Info.Title}}Api) {{camelCase (routeToFuncName .Method .Path)}}({{if ne .RequestType.Name ""}}req {{.RequestType.Name}}{{end}}) {{if ne .ResponseType.Name ""}}(*{{.ResponseType.Name}}, error){{else}}error{{end}} {
-	{{if ne .ResponseType.Name ""}}res{{else}}_{{end}}, e:= apiRequest("{{upperCase .Method}}", "{{.Path}}", {{if ne .RequestType.Name ""}}req{{else}}nil{{end}})
+{{with .Service}}{{range .Routes}}
+{{if eq .Stream "sse"}}{{template "deprecated" .}}func (api *{{clientName}}) {{camelCase (routeToFuncName .Method .Path)}}(ctx context.Context, {{if ne .RequestType.Name ""}}req {{typeName .RequestType.Name}}, {{end}}onEvent func(*{{typeName .ResponseType.Name}}) error, opts ...CallOption) error {
+	return api.events(ctx, {{routeUri .}}, func(data []byte) error {
+		ev := {{typeName .ResponseType.Name}}{}
+		if e := json.Unmarshal(data, &ev); e != nil {
+			return &ErrorCode{Desc: e.Error(), Err: e}
+		}
+		return onEvent(&ev)
+	}, {{callOpts .}})
+}
+{{else if eq .Stream "ws"}}{{template "deprecated" .}}func (api *{{clientName}}) {{camelCase (routeToFuncName .Method .Path)}}(ctx context.Context, {{if ne .RequestType.Name ""}}req {{typeName .RequestType.Name}}, messages <-chan *{{typeName .RequestType.Name}}, {{end}}onEvent func(*{{typeName .ResponseType.Name}}) error, opts ...CallOption) error {
+	return api.socket(ctx, {{routeUri .}}, {{if ne .RequestType.Name ""}}func(ctx context.Context) (interface{}, bool) {
+		select {
+		case message, ok := <-messages:
+			return message, ok
+		case <-ctx.Done():
+			return nil, false
+		}
+	}{{else}}nil{{end}}, func(data []byte) error {
+		ev := {{typeName .ResponseType.Name}}{}
+		if e := json.Unmarshal(data, &ev); e != nil {
+			return &ErrorCode{Desc: e.Error(), Err: e}
+		}
+		return onEvent(&ev)
+	}, {{callOpts .}})
+}
+{{else if .Binary}}{{template "deprecated" .}}func (api *{{clientName}}) {{camelCase (routeToFuncName .Method .Path)}}(ctx context.Context, {{if ne .RequestType.Name ""}}req {{typeName .RequestType.Name}}, {{end}}opts ...CallOption) (*ApiFile, error) {
+	return api.download(ctx, "{{upperCase .Method}}", {{fileUri .}}, {{if ne .RequestType.Name ""}}apiFormValues({{formPairs .}}){{else}}nil{{end}}, {{if hasBody .}}req{{else}}nil{{end}}, {{callOpts .}})
+}
+{{else if isMultipart .}}{{template "deprecated" .}}func (api *{{clientName}}) {{camelCase (routeToFuncName .Method .Path)}}(ctx context.Context, req {{typeName .RequestType.Name}}, opts ...CallOption) {{if ne .ResponseType.Name ""}}(*{{typeName .ResponseType.Name}}, error){{else}}error{{end}} {
+	{{if ne .ResponseType.Name ""}}res{{else}}_{{end}}, e := api.upload(ctx, "{{upperCase .Method}}", {{fileUri .}}, apiFormValues({{formPairs .}}), map[string][]*ApiFile{ {{range fileMembers .}}
+		"{{.GetTagName}}": {{if .IsFileList}}req.{{fieldName .Name}}{{else}}{req.{{fieldName .Name}}}{{end}},{{end}}
+	}, {{callOpts .}})
 	{{if eq .ResponseType.Name ""}}return e{{else}}if e != nil {
 		return nil, e
 	}
 
-	rp := {{.ResponseType.Name}}{}
-	e = json.Unmarshal([]byte(res), &rp)
+	rp := {{typeName .ResponseType.Name}}{}
+	if e := json.Unmarshal(res, &rp); e != nil {
+		return nil, &ErrorCode{Desc: e.Error(), Err: e}
+	}
+	return &rp, nil{{end}}
+}
+{{else if .Responses}}{{$func := camelCase (routeToFuncName .Method .Path)}}// {{$func}}Result is the response of {{$func}}, the field of its Status is set if the response has a body.
+type {{$func}}Result struct {
+	Status int{{range .Responses}}{{if ne .Type.Name ""}}
+	{{statusName .Status}} *{{typeName .Type.Name}}{{end}}{{end}}
+}
+
+{{template "deprecated" .}}func (api *{{clientName}}) {{$func}}(ctx context.Context, {{if ne .RequestType.Name ""}}req {{typeName .RequestType.Name}}, {{end}}opts ...CallOption) (*{{$func}}Result, error) {
+	res := &{{$func}}Result{}
+	status, e := api.callStatus(ctx, "{{upperCase .Method}}", {{routeUri .}}, {{if hasBody .}}req{{else}}nil{{end}}, func(status int) (interface{}, bool) {
+		switch status { {{range .Responses}}
+		case {{.Status}}:
+			{{if ne .Type.Name ""}}res.{{statusName .Status}} = &{{typeName .Type.Name}}{}
+			return res.{{statusName .Status}}, true{{else}}return nil, true{{end}}{{end}}
+		}
+		return nil, false
+	}, {{callOpts .}})
 	if e != nil {
-		return nil, &ErrorCode{Desc: e.Error()}
+		return nil, e
+	}
+	res.Status = status
+	return res, nil
+}
+{{else}}{{template "deprecated" .}}func (api *{{clientName}}) {{camelCase (routeToFuncName .Method .Path)}}(ctx context.Context, {{if ne .RequestType.Name ""}}req {{typeName .RequestType.Name}}, {{end}}opts ...CallOption) {{if ne .ResponseType.Name ""}}(*{{typeName .ResponseType.Name}}, error){{else}}error{{end}} {
+	{{if eq .ResponseType.Name ""}}return api.call(ctx, "{{upperCase .Method}}", {{routeUri .}}, {{if hasBody .}}req{{else}}nil{{end}}, nil, {{callOpts .}}){{else}}rp := {{typeName .ResponseType.Name}}{}
+	if e := api.call(ctx, "{{upperCase .Method}}", {{routeUri .}}, {{if hasBody .}}req{{else}}nil{{end}}, &rp, {{callOpts .}}); e != nil {
+		return nil, e
 	}
 	return &rp, nil{{end}}
 }
+{{end}}{{end}}{{end}}{{define "deprecated"}}{{if .Deprecation}}// Deprecated: {{.Deprecation.Message}}
 {{end}}{{end}}
 `
+	streamTemplate = `package {{.pkg}}
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// events reads the server-sent events of uri until the stream ends, a fail event is returned as an ErrorCode.
+func (c *Client) events(ctx context.Context, uri string, onData func(data []byte) error, opts []CallOption) error {
+	header := http.Header{}
+	header.Set("Accept", "text/event-stream")
+	res, e := c.send(ctx, http.MethodGet, uri, header, nil, opts)
+	if e != nil {
+		return e
+	}
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				payload := []byte(strings.Join(data, "\n"))
+				if event == "fail" {
+					var desc string
+					json.Unmarshal(payload, &desc)
+					return &ErrorCode{Status: res.StatusCode, Desc: desc}
+				}
+				if e := onData(payload); e != nil {
+					return e
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(line[len("data:"):], " "))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if e := scanner.Err(); e != nil {
+		return &ErrorCode{Status: res.StatusCode, Desc: e.Error(), Err: e}
+	}
+	return nil
+}
+
+// socket sends the messages returned by next as json over the websocket of uri and passes the received events to onData,
+// until the server closes the socket or ctx is done. next returns false once there are no more messages.
+func (c *Client) socket(ctx context.Context, uri string, next func(ctx context.Context) (interface{}, bool), onData func(data []byte) error, opts []CallOption) error {
+	r, e := c.newRequest(ctx, http.MethodGet, uri, nil, nil, opts)
+	if e != nil {
+		return e
+	}
+	conn, res, e := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(r.URL.String(), "http"), r.Header)
+	for _, hook := range c.responseHooks {
+		hook(r, res, e)
+	}
+	if e != nil {
+		if res != nil {
+			b, _ := readBody(res)
+			return newErrorCode(res.StatusCode, b)
+		}
+		return &ErrorCode{Desc: e.Error(), Err: e}
+	}
+	defer conn.Close()
+
+	socketCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// unblocks the reading below
+		<-socketCtx.Done()
+		conn.Close()
+	}()
+	if next != nil {
+		go func() {
+			for {
+				message, ok := next(socketCtx)
+				if !ok {
+					return
+				}
+				if e := conn.WriteJSON(message); e != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		_, data, e := conn.ReadMessage()
+		if e != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(e, &closeErr) {
+				if closeErr.Code == websocket.CloseNormalClosure {
+					return nil
+				}
+				return &ErrorCode{Desc: closeErr.Text, Err: e}
+			}
+			return &ErrorCode{Desc: e.Error(), Err: e}
+		}
+		if e := onData(data); e != nil {
+			return e
+		}
+	}
+}
+`
+	fileTemplate = `package {{.pkg}}
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+// ApiFile is a file uploaded as a multipart field, or downloaded from a binary response.
+// The Content of a download is the response body, which must be closed once read.
+type ApiFile struct {
+	Name        string
+	ContentType string
+	Content     io.Reader
+}
+
+// Close closes the content if it's an io.Closer.
+func (f *ApiFile) Close() error {
+	if closer, ok := f.Content.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// upload streams the fields and files as multipart/form-data and returns the response body,
+// nil files are skipped. The uploads aren't retried and last as long as their content takes.
+func (c *Client) upload(ctx context.Context, method, uri string, fields url.Values, files map[string][]*ApiFile, opts []CallOption) ([]byte, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(writeForm(form, fields, files))
+	}()
+
+	header := http.Header{}
+	header.Set("Content-Type", form.FormDataContentType())
+	r, e := c.newRequest(ctx, method, uri, header, body, opts)
+	if e != nil {
+		body.CloseWithError(e)
+		return nil, e
+	}
+	res, e := c.do(r)
+	if e != nil {
+		return nil, e
+	}
+	return readBody(res)
+}
+
+func writeForm(form *multipart.Writer, fields url.Values, files map[string][]*ApiFile) error {
+	for name, values := range fields {
+		for _, value := range values {
+			if e := form.WriteField(name, value); e != nil {
+				return e
+			}
+		}
+	}
+	for field, list := range files {
+		for _, file := range list {
+			if file == nil {
+				continue
+			}
+			contentType := file.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": file.Name}))
+			header.Set("Content-Type", contentType)
+			w, e := form.CreatePart(header)
+			if e != nil {
+				return e
+			}
+			if _, e := io.Copy(w, file.Content); e != nil {
+				return e
+			}
+		}
+	}
+	return form.Close()
+}
+
+// download sends the request and returns the file of a binary response named by its Content-Disposition header,
+// the downloads last as long as their content takes.
+func (c *Client) download(ctx context.Context, method, uri string, query url.Values, req interface{}, opts []CallOption) (*ApiFile, error) {
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+	header := http.Header{}
+	var body []byte
+	if req != nil {
+		b, e := json.Marshal(req)
+		if e != nil {
+			return nil, &ErrorCode{Desc: e.Error(), Err: e}
+		}
+		body = b
+		header.Set("Content-Type", "application/json")
+	}
+	res, e := c.send(ctx, method, uri, header, body, opts)
+	if e != nil {
+		return nil, e
+	}
+
+	file := &ApiFile{ContentType: res.Header.Get("Content-Type"), Content: res.Body}
+	if _, params, e := mime.ParseMediaType(res.Header.Get("Content-Disposition")); e == nil {
+		file.Name = params["filename"]
+	}
+	return file, nil
+}
+`
+	// ProtobufTemplate is protobuf.go, the calls of the Client with the messages compiled from the proto file.
+	ProtobufTemplate = `package {{.pkg}}
+
+import (
+	"context"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type Encoding int
+
+const (
+	Json Encoding = iota
+	Protobuf
+)
+
+// WithEncoding sends the messages and asks for the responses in encoding, Json by default.
+func WithEncoding(encoding Encoding) Option {
+	return func(c *Client) {
+		c.encoding = encoding
+	}
+}
+
+// callMessage sends req and decodes the response into res unless it's nil, the server may answer json or protobuf.
+func (c *Client) callMessage(ctx context.Context, method, uri string, req, res proto.Message, opts []CallOption) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	contentType := "application/json"
+	marshal := protojson.Marshal
+	if c.encoding == Protobuf {
+		contentType = "application/x-protobuf"
+		marshal = proto.Marshal
+	}
+	header := http.Header{}
+	header.Set("Accept", contentType)
+	var body []byte
+	if req != nil {
+		b, e := marshal(req)
+		if e != nil {
+			return &ErrorCode{Desc: e.Error(), Err: e}
+		}
+		body = b
+		header.Set("Content-Type", contentType)
+	}
+	rp, e := c.send(ctx, method, uri, header, body, opts)
+	if e != nil {
+		return e
+	}
+	b, e := readBody(rp)
+	if e != nil {
+		return e
+	}
+	if res == nil || len(b) == 0 {
+		return nil
+	}
+	// decodes what the server sent, which isn't always what was asked for
+	if t, _, _ := mime.ParseMediaType(rp.Header.Get("Content-Type")); t == "application/x-protobuf" || t == "application/protobuf" {
+		e = proto.Unmarshal(b, res)
+	} else {
+		e = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, res)
+	}
+	if e != nil {
+		return &ErrorCode{Status: rp.StatusCode, Desc: e.Error(), Err: e}
+	}
+	return nil
+}
+`
+	protobufApiFilesTemplate = `package {{.pkg}}
+{{if .imports}}
+import (
+	{{.imports}}
+)
+{{end}}
+// {{.api}} calls the routes of the server, every call can be given CallOption.
+type {{.api}} struct {
+	*Client
+}
+
+// New{{.api}} returns the api of the server of baseURL, e.g. http://localhost:8888
+func New{{.api}}(baseURL string, opts ...Option) *{{.api}} {
+	return &{{.api}}{Client: NewClient(baseURL, opts...)}
+}
+
+// {{.interface}} is the api of the {{.service}} service, {{.api}} calls the server and Fake{{.interface}} answers in memory.
+type {{.interface}} interface { {{range .funcs}}{{if .Deprecated}}
+	// Deprecated: {{.Deprecated}}{{end}}
+	{{.Name}}({{.Params}}) {{.Results}}{{end}}
+}
+
+var _ {{.interface}} = (*{{.api}})(nil)
+` + ErrorsTemplate + `{{if .types}}
+// the messages compiled from the proto file, they are sent as json or protobuf according to WithEncoding
+type ({{range .types}}
+	{{.Name}} = pb.{{.Message}}{{end}}
+)
+{{end}}{{range .routes}}{{if .Deprecated}}
+// Deprecated: {{.Deprecated}}{{end}}
+func (api *{{$.api}}) {{.Func}}(ctx context.Context, {{if .Request}}req *{{.Request}}, {{end}}opts ...CallOption) {{if .Response}}(*{{.Response}}, error){{else}}error{{end}} {
+	{{- if .Response}}
+	rp := &{{.Response}}{}
+	if e := api.callMessage(ctx, "{{.Method}}", {{.Path}}, {{if .Request}}req{{else}}nil{{end}}, rp, {{.Opts}}); e != nil {
+		return nil, e
+	}
+	return rp, nil
+	{{- else}}
+	return api.callMessage(ctx, "{{.Method}}", {{.Path}}, {{if .Request}}req{{else}}nil{{end}}, nil, {{.Opts}})
+	{{- end}}
+}
+{{end}}`
 )
 
 func GocliCommand(c *cli.Context) error {
@@ -169,7 +912,27 @@ func GocliCommand(c *cli.Context) error {
 	if e != nil {
 		return e
 	}
-	e = genApi(dir, pkg, api)
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+	pb := c.String("pb")
+	e = genApi(dir, pkg, pb != "")
+	if e != nil {
+		return e
+	}
+	if pb != "" {
+		e = genProtobuf(dir, pkg)
+		if e != nil {
+			return e
+		}
+		return genProtobufApiFiles(dir, pkg, pb, api)
+	}
+	e = genStream(dir, pkg, api)
+	if e != nil {
+		return e
+	}
+	e = genFile(dir, pkg, api)
 	if e != nil {
 		return e
 	}
@@ -180,12 +943,119 @@ func GocliCommand(c *cli.Context) error {
 	return nil
 }
 
-func genApi(dir, pkg string, api *spec.ApiSpec) error {
+// genApi writes api.go with the Client, whose encoding can be chosen if protobuf is true.
+func genApi(dir, pkg string, protobuf bool) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	return genOnce(dir, "api.go", ClientTemplate, map[string]interface{}{
+		"pkg":      pkg,
+		"protobuf": protobuf,
+	})
+}
+
+func genApiFiles(dir, pkg string, api *spec.ApiSpec) error {
+	name := strings.ToLower(api.Info.Title + "api")
+	path := filepath.Join(dir, name+".go")
+	api.Info.Title = name
+	api.Info.Desc = pkg
 	e := os.MkdirAll(dir, 0755)
 	if e != nil {
 		return e
 	}
-	path := filepath.Join(dir, "api.go")
+
+	file, e := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	funcs := apiFuncs(api)
+	t, e := template.New(name).Funcs(util.FuncsMap).Funcs(template.FuncMap{
+		"routeImports": func() string {
+			return routeImports(api)
+		},
+		"apiName": func() string {
+			return apiName(api)
+		},
+		"clientName": func() string {
+			return clientName(api)
+		},
+		"apiFuncs": func() []apiFunc {
+			return funcs
+		},
+		"routeUri": func(route spec.Route) string {
+			return routeUri(api, route, true)
+		},
+		"fileUri": func(route spec.Route) string {
+			// the form members are sent in the multipart body or by download
+			return routeUri(api, route, false)
+		},
+		"goType": func(t string) string {
+			return goType(api, t)
+		},
+		"typeName":   typeName,
+		"fieldName":  fieldName,
+		"statusName": util.StatusName,
+		"isMultipart": func(route spec.Route) bool {
+			return util.IsMultipart(api, route)
+		},
+		"hasBody": func(route spec.Route) bool {
+			return len(util.GetRequestMembers(api, route).Body) > 0
+		},
+		"callOpts": func(route spec.Route) string {
+			return CallOptions(util.GetRequestMembers(api, route).Header, func(member spec.Member) string {
+				return "req." + fieldName(member.Name)
+			})
+		},
+		"formPairs": func(route spec.Route) string {
+			var pairs []string
+			for _, member := range util.GetRequestMembers(api, route).Query {
+				if !member.IsFile() {
+					pairs = append(pairs, fmt.Sprintf("%q, req.%s", member.GetTagName(), fieldName(member.Name)))
+				}
+			}
+			return strings.Join(pairs, ", ")
+		},
+		"fileMembers": func(route spec.Route) []spec.Member {
+			var members []spec.Member
+			for _, member := range util.GetRequestMembers(api, route).Query {
+				if member.IsFile() {
+					members = append(members, member)
+				}
+			}
+			return members
+		},
+	}).Parse(apiFilesTemplate)
+	if e != nil {
+		return e
+	}
+	e = executeFormatted(file, t, api)
+	if e != nil {
+		return e
+	}
+	return genFake(dir, pkg, name, api, funcs)
+}
+
+// genStream writes stream.go with the server-sent events and websocket helpers if the api has streams.
+func genStream(dir, pkg string, api *spec.ApiSpec) error {
+	if !hasStream(api) {
+		return nil
+	}
+	return genOnce(dir, "stream.go", streamTemplate, map[string]interface{}{"pkg": pkg})
+}
+
+// genFile writes file.go with the multipart upload and binary download helpers if the api has such routes.
+func genFile(dir, pkg string, api *spec.ApiSpec) error {
+	if !util.HasFileRoute(api) {
+		return nil
+	}
+	return genOnce(dir, "file.go", fileTemplate, map[string]interface{}{"pkg": pkg})
+}
+
+func genOnce(dir, name, text string, data interface{}) error {
+	path := filepath.Join(dir, name)
 	if _, e := os.Stat(path); e == nil {
 		return nil
 	}
@@ -195,33 +1065,249 @@ func genApi(dir, pkg string, api *spec.ApiSpec) error {
 		return e
 	}
 	defer file.Close()
-	api.Info.Desc = pkg
-	t, e := template.New("api.go").Parse(apiTemplate)
+	t, e := template.New(name).Parse(text)
 	if e != nil {
 		return e
 	}
-	return t.Execute(file, pkg)
+	return executeFormatted(file, t, data)
 }
 
-func genApiFiles(dir, pkg string, api *spec.ApiSpec) error {
+// the identifiers of a go type, which are qualified by the package, e.g. user and time.Time of map[string]time.Time
+var identRe = regexp.MustCompile(`[A-Za-z_][\w.]*`)
+
+// typeName returns the exported go name of an api type, so that it can be used outside the package, e.g. GetRequest for getRequest.
+func typeName(name string) string {
+	return strcase.ToCamel(name)
+}
+
+// fieldName returns the exported go name of a member, e.g. Name for name.
+func fieldName(name string) string {
+	return strings.Title(name)
+}
+
+// goType returns the go type of a member, the api types are exported and the files are sent as *ApiFile.
+func goType(api *spec.ApiSpec, t string) string {
+	switch t {
+	case spec.FileTypeName:
+		return "*ApiFile"
+	case "[]" + spec.FileTypeName:
+		return "[]*ApiFile"
+	}
+	return identRe.ReplaceAllStringFunc(t, func(name string) string {
+		for _, tp := range api.Types {
+			if tp.Name == name {
+				return typeName(name)
+			}
+		}
+		return name
+	})
+}
+
+func hasStream(api *spec.ApiSpec) bool {
+	for _, route := range api.Service.Routes {
+		if len(route.Stream) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// routeImports returns the imports the api functions need.
+func routeImports(api *spec.ApiSpec) string {
+	var imports []string
+	if len(api.Service.Routes) > 0 {
+		imports = append(imports, `"context"`)
+	}
+	for _, route := range api.Service.Routes {
+		// the events and the uploaded responses are decoded by the api functions
+		if len(route.Stream) > 0 || (util.IsMultipart(api, route) && len(route.ResponseType.Name) > 0) {
+			imports = append(imports, `"encoding/json"`)
+			break
+		}
+	}
+	// the path members are escaped, and they and the header members are formatted by fmt
+	var usePath, useFmt bool
+	for _, route := range api.Service.Routes {
+		if len(route.RequestType.Name) == 0 {
+			continue
+		}
+		usePath = usePath || len(route.GetPathParams()) > 0
+		useFmt = useFmt || len(route.GetPathParams()) > 0 || len(util.GetRequestMembers(api, route).Header) > 0
+	}
+	if useFmt {
+		imports = append(imports, `"fmt"`)
+	}
+	if usePath {
+		imports = append(imports, `"net/url"`)
+	}
+	return strings.Join(imports, "\n\t")
+}
+
+// routeUri returns the go expression of the uri of a route, the path members of req are put into it
+// and so are the form members if query is true,
+// e.g. "/api/chat/" + url.PathEscape(fmt.Sprint(req.Room)) + apiQuery("topic", req.Topic)
+func routeUri(api *spec.ApiSpec, route spec.Route, query bool) string {
+	if len(route.RequestType.Name) == 0 {
+		return strconv.Quote(route.Path)
+	}
+	members := util.GetRequestMembers(api, route)
+	uri := strconv.Quote(util.ConvertPath(route.Path, func(name string) string {
+		field := fieldName(name)
+		if member, ok := members.GetPathMember(name); ok {
+			field = fieldName(member.Name)
+		}
+		return "\x00" + field + "\x00"
+	}))
+	// the path variables were marked by zero bytes, which are quoted as \x00
+	parts := strings.Split(uri, `\x00`)
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = `" + url.PathEscape(fmt.Sprint(req.` + parts[i] + `)) + "`
+	}
+	uri = strings.TrimSuffix(strings.Join(parts, ""), ` + ""`)
+	if !query {
+		return uri
+	}
+
+	var params []string
+	for _, member := range members.Query {
+		params = append(params, fmt.Sprintf("%q, req.%s", member.GetTagName(), fieldName(member.Name)))
+	}
+	if len(params) > 0 {
+		uri += " + apiQuery(" + strings.Join(params, ", ") + ")"
+	}
+	return uri
+}
+
+// CallOptions returns the go expression of the options of a call, the header members are set before opts by CallHeader,
+// e.g. append([]CallOption{CallHeader("X-Token", fmt.Sprint(req.Token))}, opts...)
+func CallOptions(members []spec.Member, field func(member spec.Member) string) string {
+	if len(members) == 0 {
+		return "opts"
+	}
+	var headers []string
+	for _, member := range members {
+		headers = append(headers, fmt.Sprintf("CallHeader(%q, fmt.Sprint(%s))", member.GetTagName(), field(member)))
+	}
+	return "append([]CallOption{" + strings.Join(headers, ", ") + "}, opts...)"
+}
+
+// genProtobuf writes protobuf.go with the calls sending the compiled messages.
+func genProtobuf(dir, pkg string) error {
+	return genOnce(dir, "protobuf.go", ProtobufTemplate, map[string]interface{}{"pkg": pkg})
+}
+
+// genProtobufApiFiles generates the api functions with the messages compiled from the proto file of the api, see goctlr api proto.
+func genProtobufApiFiles(dir, pkg, pb string, api *spec.ApiSpec) error {
+	type (
+		alias struct {
+			Name    string
+			Message string
+		}
+		route struct {
+			Func     string
+			Method   string
+			Path     string
+			Request  string
+			Response string
+			// Opts is the expression of the call options, see CallOptions
+			Opts string
+			// Deprecated is the message of the deprecated route, empty if it isn't
+			Deprecated string
+		}
+	)
+	var types []alias
+	for _, tp := range api.Types {
+		types = append(types, alias{Name: typeName(tp.Name), Message: protogen.MessageName(tp.Name)})
+	}
+	var routes []route
+	var funcs []apiFunc
+	usePath, useFmt := false, false
+	for _, r := range api.Service.Routes {
+		if len(r.Stream) > 0 {
+			return fmt.Errorf("the stream %s can't be generated with -pb", r.Path)
+		}
+		if util.IsFileRoute(api, r) {
+			return fmt.Errorf("the file route %s can't be generated with -pb", r.Path)
+		}
+		item := route{
+			Func:       strcase.ToCamel(util.RouteToFuncName(r.Method, r.Path)),
+			Method:     strings.ToUpper(r.Method),
+			Path:       strconv.Quote(r.Path),
+			Request:    typeName(r.RequestType.Name),
+			Response:   typeName(r.ResponseType.Name),
+			Opts:       "opts",
+			Deprecated: r.Deprecation.Message(),
+		}
+		if len(item.Request) > 0 {
+			item.Path = protogen.GoPath(api, r, "req")
+			usePath = usePath || len(r.GetPathParams()) > 0
+			headers := util.GetRequestMembers(api, r).Header
+			item.Opts = CallOptions(headers, func(member spec.Member) string {
+				return "req.Get" + protogen.GoFieldName(member) + "()"
+			})
+			useFmt = useFmt || len(headers) > 0
+		}
+		routes = append(routes, item)
+		f := protobufApiFunc(item.Func, item.Request, item.Response)
+		f.Deprecated = item.Deprecated
+		funcs = append(funcs, f)
+	}
+
+	var imports []string
+	if len(routes) > 0 {
+		imports = append(imports, `"context"`)
+	}
+	if usePath || useFmt {
+		imports = append(imports, `"fmt"`)
+	}
+	if usePath {
+		imports = append(imports, `"net/url"`)
+	}
+	if len(imports) > 0 {
+		imports = append(imports, "")
+	}
+	if len(types) > 0 {
+		imports = append(imports, strconv.Quote(pb))
+	}
+
 	name := strings.ToLower(api.Info.Title + "api")
-	path := filepath.Join(dir, name+".go")
-	api.Info.Title = name
-	api.Info.Desc = pkg
-	e := os.MkdirAll(dir, 0755)
+	file, e := os.OpenFile(filepath.Join(dir, name+".go"), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
 	if e != nil {
 		return e
 	}
+	defer file.Close()
 
-	file, e := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	t, e := template.New(name).Parse(protobufApiFilesTemplate)
 	if e != nil {
 		return e
 	}
-	defer file.Close()
-
-	t, e := template.New(name).Funcs(util.FuncsMap).Parse(apiFilesTemplate)
+	e = executeFormatted(file, t, map[string]interface{}{
+		"pkg":       pkg,
+		"api":       clientName(api),
+		"interface": apiName(api),
+		"service":   api.Service.Name,
+		"imports":   strings.TrimSpace(strings.Join(imports, "\n\t")),
+		"types":     types,
+		"routes":    routes,
+		"funcs":     funcs,
+		"Errors":    api.Errors,
+	})
 	if e != nil {
 		return e
 	}
-	return t.Execute(file, api)
+	return genFake(dir, pkg, name, api, funcs)
+}
+
+// executeFormatted writes the go source of t executed with data, it's written as is if it can't be formatted.
+func executeFormatted(w io.Writer, t *template.Template, data interface{}) error {
+	buffer := new(bytes.Buffer)
+	if e := t.Execute(buffer, data); e != nil {
+		return e
+	}
+	b, e := format.Source(buffer.Bytes())
+	if e != nil {
+		b = buffer.Bytes()
+	}
+	_, e = w.Write(b)
+	return e
 }
@@ -0,0 +1,200 @@
+package gocligen
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// genModule generates the client of the api into the userapi package of a new module,
+// and writes the files of the caller package, which uses it from the outside, next to it.
+func genModule(t *testing.T, text string, caller map[string]string) string {
+	if _, e := exec.LookPath("go"); e != nil {
+		t.Skip("go is not installed")
+	}
+	p, e := parser.NewParserFromStr(text)
+	assert.Nil(t, e)
+	api, e := p.Parse()
+	assert.Nil(t, e)
+
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.16\n"), 0644))
+	pkg := filepath.Join(dir, "userapi")
+	assert.Nil(t, genApi(pkg, "userapi", false))
+	assert.Nil(t, genFile(pkg, "userapi", api))
+	assert.Nil(t, genApiFiles(pkg, "userapi", api))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "caller"), 0755))
+	for name, content := range caller {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "caller", name), []byte(content), 0644))
+	}
+	return dir
+}
+
+// goTest runs the tests of the module, the caller package fails them if the client sends a bad request.
+func goTest(t *testing.T, dir string) {
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, e := cmd.CombinedOutput()
+	assert.Nil(t, e, string(out))
+}
+
+func TestHeaderMembers(t *testing.T) {
+	dir := genModule(t, `info(
+	title: user
+)
+
+type GetRequest struct {
+	Name  string `+"`path:\"name\"`"+`
+	Token string `+"`header:\"X-Token\"`"+`
+}
+
+type SetRequest struct {
+	Name  string `+"`path:\"name\"`"+`
+	Token string `+"`header:\"X-Token\"`"+`
+	Age   int    `+"`json:\"age\"`"+`
+}
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(GetRequest)
+
+	@server(
+		handler: SetUserHandler
+	)
+	post /api/user/:name(SetRequest)
+}
+`, map[string]string{"caller_test.go": `package caller
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/app/userapi"
+)
+
+func TestCall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if token := r.Header.Get("X-Token"); token != "secret" {
+			t.Errorf("%s: got X-Token %q", r.Method, token)
+		}
+		if r.Method == http.MethodGet && len(b) > 0 {
+			t.Errorf("got a body %s of GET", b)
+		}
+		// the path and header members aren't sent in the body
+		if r.Method == http.MethodPost && strings.TrimSpace(string(b)) != ` + "`" + `{"age":1}` + "`" + ` {
+			t.Errorf("got the body %s of POST", b)
+		}
+	}))
+	defer ts.Close()
+
+	api := userapi.NewUserApiClient(ts.URL)
+	if e := api.GetApiUserWithName(context.Background(), userapi.GetRequest{Name: "kim", Token: "secret"}); e != nil {
+		t.Fatal(e)
+	}
+	if e := api.PostApiUserWithName(context.Background(), userapi.SetRequest{Name: "kim", Token: "secret", Age: 1}); e != nil {
+		t.Fatal(e)
+	}
+}
+`})
+	goTest(t, dir)
+}
+
+func TestExportedNames(t *testing.T) {
+	// the types and members are lowercase as written in the api file
+	dir := genModule(t, `info(
+	title: user
+)
+
+type tag struct {
+	label string `+"`json:\"label\"`"+`
+}
+
+type getRequest struct {
+	name string `+"`path:\"name\"`"+`
+}
+
+type getResponse struct {
+	name string         `+"`json:\"name\"`"+`
+	tags []tag          `+"`json:\"tags\"`"+`
+	byId map[string]tag `+"`json:\"byId\"`"+`
+}
+
+type notFound struct {
+	reason string `+"`json:\"reason\"`"+`
+}
+
+type avatarRequest struct {
+	avatar file `+"`form:\"avatar\"`"+`
+}
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(getRequest) returns(getResponse)
+
+	@server(
+		handler: GetStatusHandler
+	)
+	get /api/status/:name(getRequest) returns(200: getResponse, 404: notFound)
+
+	@server(
+		handler: SetAvatarHandler
+	)
+	post /api/avatar(avatarRequest) returns(getResponse)
+}
+`, map[string]string{"caller_test.go": `package caller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"example.com/app/userapi"
+)
+
+func TestCaller(t *testing.T) {
+	fake := &userapi.FakeUserApi{}
+	fake.GetApiUserWithNameFunc = func(ctx context.Context, req userapi.GetRequest, opts ...userapi.CallOption) (*userapi.GetResponse, error) {
+		return &userapi.GetResponse{Name: req.Name, Tags: []userapi.Tag{{Label: "admin"}}}, nil
+	}
+	fake.GetApiStatusWithNameFunc = func(ctx context.Context, req userapi.GetRequest, opts ...userapi.CallOption) (*userapi.GetApiStatusWithNameResult, error) {
+		return &userapi.GetApiStatusWithNameResult{Status: http.StatusNotFound, NotFound: &userapi.NotFound{Reason: "gone"}}, nil
+	}
+	var api userapi.UserApi = fake
+
+	res, e := api.GetApiUserWithName(context.Background(), userapi.GetRequest{Name: "kim"})
+	if e != nil || res.Name != "kim" || res.Tags[0].Label != "admin" {
+		t.Fatalf("got %v, %v", res, e)
+	}
+	status, e := api.GetApiStatusWithName(context.Background(), userapi.GetRequest{Name: "kim"})
+	if e != nil || status.NotFound.Reason != "gone" {
+		t.Fatalf("got %v, %v", status, e)
+	}
+	avatar := &userapi.ApiFile{Name: "a.png", Content: strings.NewReader("png")}
+	if _, e := api.PostApiAvatar(context.Background(), userapi.AvatarRequest{Avatar: avatar}); e != nil {
+		t.Fatal(e)
+	}
+	if reqs := fake.CallsOf("PostApiAvatar"); len(reqs) != 1 {
+		t.Fatalf("got the calls %v", reqs)
+	}
+	api = userapi.NewUserApiClient("http://localhost")
+	_ = userapi.GetResponse{ById: map[string]userapi.Tag{}}
+}
+`})
+	goTest(t, dir)
+}
@@ -29,6 +29,7 @@ func GoCommand(c *cli.Context) error {
 	dir := c.String("dir")
 	proto := c.String("proto")
 	onlyTypes := c.Bool("onlyTypes")
+	observability := c.Bool("observability")
 	if len(apiFile) == 0 {
 		return errors.New("missing -api")
 	}
@@ -96,21 +97,24 @@ func GoCommand(c *cli.Context) error {
 				logx.Must(genTypes(dir, api))
 				continue
 			}
-			logx.Must(genEtc(dir, api))
-			logx.Must(genConfig(dir))
-			logx.Must(genServiceContext(dir, api))
+			logx.Must(genEtc(dir, observability, api))
+			logx.Must(genConfig(dir, observability, api))
+			logx.Must(genMiddlewares(dir, api))
+			logx.Must(genServiceContext(dir, observability, api))
+			logx.Must(genObservability(dir, observability))
 			if len(proto) == 0 {
 				logx.Must(genTypes(dir, api))
 			}
-			logx.Must(genHandlers(dir, proto, api))
-			logx.Must(genRoutes(dir, api))
-			logx.Must(genLogic(dir, proto, api))
+			logx.Must(genErrorx(dir, api))
+			logx.Must(genHandlers(dir, proto, observability, api))
+			logx.Must(genRoutes(dir, observability, api))
+			logx.Must(genLogic(dir, proto, observability, api))
 			if c.Bool("clitest") {
-				logx.Must(genClient(dir, api))
-				logx.Must(genTest(dir, api))
+				logx.Must(genClient(dir, proto, api))
+				logx.Must(genTest(dir, proto, observability, api))
 			}
 			api.Service.Name = "application"
-			logx.Must(genMain(dir, api))
+			logx.Must(genMain(dir, observability, api))
 		}
 	} else {
 		p, e := parser.NewParser(apiFile)
@@ -129,19 +133,22 @@ func GoCommand(c *cli.Context) error {
 			return nil
 		}
 		logx.Must(util.MkdirIfNotExist(dir))
-		logx.Must(genEtc(dir, api))
-		logx.Must(genConfig(dir))
-		logx.Must(genMain(dir, api))
-		logx.Must(genServiceContext(dir, api))
+		logx.Must(genEtc(dir, observability, api))
+		logx.Must(genConfig(dir, observability, api))
+		logx.Must(genMain(dir, observability, api))
+		logx.Must(genMiddlewares(dir, api))
+		logx.Must(genServiceContext(dir, observability, api))
+		logx.Must(genObservability(dir, observability))
 		if len(proto) == 0 {
 			logx.Must(genTypes(dir, api))
 		}
-		logx.Must(genHandlers(dir, proto, api))
-		logx.Must(genRoutes(dir, api))
-		logx.Must(genLogic(dir, proto, api))
+		logx.Must(genErrorx(dir, api))
+		logx.Must(genHandlers(dir, proto, observability, api))
+		logx.Must(genRoutes(dir, observability, api))
+		logx.Must(genLogic(dir, proto, observability, api))
 		if c.Bool("clitest") {
-			logx.Must(genClient(dir, api))
-			logx.Must(genTest(dir, api))
+			logx.Must(genClient(dir, proto, api))
+			logx.Must(genTest(dir, proto, observability, api))
 		}
 	}
 
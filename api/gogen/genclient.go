@@ -1,179 +1,370 @@
 package gogen
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"text/template"
 
-	"github.com/StevenZack/tools/strToolkit"
+	"github.com/gofaith/goctlr/api/gocligen"
+	"github.com/gofaith/goctlr/api/protogen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/iancoleman/strcase"
 )
 
 const (
-	clientTemplate = `package client
-
+	protoApiTemplate = `package client
+{{if .imports}}
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io"
-	"io/ioutil"
-	"net/http"
-	"strings"
-	"time"
-
-	"github.com/gofaith/go-zero/core/logx"
+	{{.imports}}
 )
-
-type Client struct {
-	Server string
-	Header map[string]string
+{{end}}` + gocligen.ErrorsTemplate + `{{range .routes}}
+// {{.Func}} {{.Summary}}{{if .Deprecated}}
+//
+// Deprecated: {{.Deprecated}}{{end}}
+func (c *Client) {{.Func}}(ctx context.Context, {{if .Request}}request *pb.{{.Request}}, {{end}}opts ...CallOption) {{if .Response}}(*pb.{{.Response}}, error){{else}}error{{end}} {
+	{{- if .Response}}
+	res := &pb.{{.Response}}{}
+	if e := c.callMessage(ctx, "{{.Method}}", {{.Path}}, {{if .Request}}request{{else}}nil{{end}}, res, {{.Opts}}); e != nil {
+		return nil, e
+	}
+	return res, nil
+	{{- else}}
+	return c.callMessage(ctx, "{{.Method}}", {{.Path}}, {{if .Request}}request{{else}}nil{{end}}, nil, {{.Opts}})
+	{{- end}}
 }
-
-var (
-	server = "http://localhost:8080"
+{{end}}`
+	apiTemplate = `package client
+{{if .imports}}
+import (
+	{{.imports}}
 )
+{{end}}` + gocligen.ErrorsTemplate + `{{range .routes}}{{$types := .Types}}{{if .Responses}}
+// {{.Func}}Result is the response of {{.Func}}, the field of its Status is set if the response has a body.
+type {{.Func}}Result struct {
+	Status int{{range .Responses}}{{if .Type}}
+	{{.Field}} *{{$types}}.{{.Type}}{{end}}{{end}}
+}
 
-func NewClient() *Client {
-	return &Client{
-		Server: server,
-		Header: make(map[string]string),
+// {{.Func}} {{.Summary}}{{if .Deprecated}}
+//
+// Deprecated: {{.Deprecated}}{{end}}
+func (c *Client) {{.Func}}(ctx context.Context, {{if .Request}}request {{$types}}.{{.Request}}, {{end}}opts ...CallOption) (*{{.Func}}Result, error) {
+	res := &{{.Func}}Result{}
+	status, e := c.callStatus(ctx, "{{.Method}}", {{.Path}}, {{if .Body}}request{{else}}nil{{end}}, func(status int) (interface{}, bool) {
+		switch status { {{- range .Responses}}
+		case {{.Status}}:
+			{{- if .Type}}
+			res.{{.Field}} = &{{$types}}.{{.Type}}{}
+			return res.{{.Field}}, true
+			{{- else}}
+			return nil, true
+			{{- end}}
+		{{- end}}
+		}
+		return nil, false
+	}, {{.Opts}})
+	if e != nil {
+		return nil, e
+	}
+	res.Status = status
+	return res, nil
+}
+{{else}}
+// {{.Func}} {{.Summary}}{{if .Deprecated}}
+//
+// Deprecated: {{.Deprecated}}{{end}}
+func (c *Client) {{.Func}}(ctx context.Context, {{if .Request}}request {{$types}}.{{.Request}}, {{end}}opts ...CallOption) {{if .Response}}(*{{$types}}.{{.Response}}, error){{else}}error{{end}} {
+	{{- if .Response}}
+	res := &{{$types}}.{{.Response}}{}
+	if e := c.call(ctx, "{{.Method}}", {{.Path}}, {{if .Body}}request{{else}}nil{{end}}, res, {{.Opts}}); e != nil {
+		return nil, e
 	}
+	return res, nil
+	{{- else}}
+	return c.call(ctx, "{{.Method}}", {{.Path}}, {{if .Body}}request{{else}}nil{{end}}, nil, {{.Opts}})
+	{{- end}}
 }
+{{end}}{{end}}`
+)
 
-func (c *Client) Ping() bool {
-	_, e := http.Get(c.Server)
-	return e == nil
+type clientRoute struct {
+	Func     string
+	Summary  string
+	Method   string
+	Path     string
+	Request  string
+	Response string
+	// Body tells whether the request has body members, the others are sent by the uri and the headers
+	Body bool
+	// Opts is the expression of the call options, which set the header members, see gocligen.CallOptions
+	Opts string
+	// Types is the name the types package of the route is imported as, e.g. typesv2 for the routes of v2
+	Types string
+	// Deprecated is the message of the deprecated route, empty if it isn't
+	Deprecated string
+	// Responses are the responses of a route with multiple responses
+	Responses []clientResponse
 }
 
-func (c *Client) Request(method, path string, body interface{}) ([]byte, error) {
-	cli := http.Client{
-		Timeout: time.Second,
-	}
+type clientResponse struct {
+	Status int
+	Field  string
+	Type   string
+}
 
-	var reader io.Reader
-	if body != nil {
-		if s, ok := body.(string); ok {
-			reader = strings.NewReader(s)
-		} else {
-			b, e := json.Marshal(body)
-			if e != nil {
-				logx.Error(e)
-				return nil, e
-			}
-			reader = bytes.NewReader(b)
-		}
-	}
-	req, e := http.NewRequest(method, c.Server+path, reader)
+func genClient(dir, proto string, api *spec.ApiSpec) error {
+	dir, e := filepath.Abs(dir)
 	if e != nil {
-		logx.Error(e)
-		return nil, e
+		return e
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	for k, v := range c.Header {
-		req.Header.Set(k, v)
+	clientDir := filepath.Join(dir, "client")
+	e = os.MkdirAll(clientDir, 0755)
+	if e != nil {
+		return e
 	}
 
-	res, e := cli.Do(req)
+	// the Client is the one of gocli, the api functions are its methods
+	e = genClientOnce(clientDir, "client.go", gocligen.ClientTemplate, len(proto) > 0)
 	if e != nil {
-		logx.Error(e)
-		return nil, e
+		return e
+	}
+	if len(proto) > 0 {
+		e = genClientOnce(clientDir, "protobuf.go", gocligen.ProtobufTemplate, true)
+		if e != nil {
+			return e
+		}
 	}
-	defer res.Body.Close()
-	b, e := ioutil.ReadAll(res.Body)
+
+	// gen api.go
+	apiFile, e := os.OpenFile(filepath.Join(clientDir, "api.go"), os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
 	if e != nil {
-		logx.Error(e)
-		return nil, e
+		return e
 	}
+	defer apiFile.Close()
 
-	if res.StatusCode == 200 {
-		return b, nil
+	if len(proto) > 0 {
+		return genProtoClientApi(apiFile, dir, api)
 	}
-	
-	return nil, errors.New(res.Status + ":" + string(b))
-}
-`
-	apiTemplate = `package client
 
-import (
-	"encoding/json"
+	return genJsonClientApi(apiFile, dir, api)
+}
 
-	"{{.Info.Desc}}/internal/types"
+func genClientOnce(clientDir, name, text string, protobuf bool) error {
+	clientFile := filepath.Join(clientDir, name)
+	if _, e := os.Stat(clientFile); e == nil {
+		log.Println(name + " exists. skipped it.")
+		return nil
+	}
 
-	"github.com/gofaith/go-zero/core/logx"
-)
-{{with .Service}}{{range .Routes}}
-// {{camelCase (routeToFuncName .Method .Path)}} {{.Summary}}
-func (c *Client) {{camelCase (routeToFuncName .Method .Path)}}({{with .RequestType}}{{if ne .Name ""}}request types.{{.Name}}{{end}}{{end}}) {{with .ResponseType}}{{if ne .Name ""}}(*types.{{.Name}}, error){{else}}error{{end}}{{end}} {
-	{{with .ResponseType}}{{if ne .Name ""}}res{{else}}_{{end}}{{end}}, e := c.Request("{{upperCase .Method}}", "{{.Path}}", {{with .RequestType}}{{if ne .Name ""}}request{{else}}nil{{end}}{{end}})
+	file, e := os.OpenFile(clientFile, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
 	if e != nil {
-		logx.Error(e)
-		return {{with .ResponseType}}{{if ne .Name ""}}nil, {{end}}{{end}}e
-	}{{with .ResponseType}}{{if ne .Name ""}}
-	v := types.{{.Name}}{}
-	e = json.Unmarshal(res, &v)
+		return e
+	}
+	defer file.Close()
+	t, e := template.New(name).Parse(text)
 	if e != nil {
-		logx.Error(e)
-		return nil, e
+		return e
 	}
-	return &v, nil{{else}}
-	return nil{{end}}{{end}}
-}{{end}}{{end}}
-`
-)
-
-func genClient(dir string, api *spec.ApiSpec) error {
-	dir, e := filepath.Abs(dir)
+	buffer := new(bytes.Buffer)
+	e = t.Execute(buffer, map[string]interface{}{
+		"pkg":      "client",
+		"protobuf": protobuf,
+	})
 	if e != nil {
 		return e
 	}
+	_, e = file.WriteString(formatCode(buffer.String()))
+	return e
+}
 
-	clientDir := filepath.Join(dir, "client")
-	e = os.MkdirAll(clientDir, 0755)
+// genJsonClientApi writes the client functions of the json mode, the path and form members are put into the uri.
+func genJsonClientApi(w io.Writer, dir string, api *spec.ApiSpec) error {
+	pkg, e := getParentPackage(dir)
 	if e != nil {
 		return e
 	}
 
-	clientFile := filepath.Join(clientDir, "client.go")
-	_, e = os.Stat(clientFile)
-	if os.IsNotExist(e) {
-		// gen client.go
-		file, e := os.OpenFile(clientFile, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
-		if e != nil {
-			return e
+	var routes []clientRoute
+	var usePath, useFmt bool
+	// the versions whose types are used, the routes without version use the types package
+	var typesVersions []string
+	for _, route := range api.Service.Routes {
+		// the files are parsed into *multipart.FileHeader, which the client can't send
+		if len(route.Stream) > 0 || util.IsFileRoute(api, route) {
+			continue
 		}
-		defer file.Close()
-		_, e = file.WriteString(clientTemplate)
-		if e != nil {
-			return e
+		item := clientRoute{
+			Func:       strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)),
+			Summary:    route.Summary,
+			Method:     strings.ToUpper(route.Method),
+			Path:       strconv.Quote(route.Path),
+			Request:    ctlutil.Title(route.RequestType.Name),
+			Response:   ctlutil.Title(route.ResponseType.Name),
+			Types:      typesPacket + route.Version,
+			Opts:       "opts",
+			Deprecated: route.Deprecation.Message(),
+		}
+		useTypes := len(item.Request) > 0 || len(item.Response) > 0
+		for _, response := range route.Responses {
+			item.Responses = append(item.Responses, clientResponse{
+				Status: response.Status,
+				Field:  util.StatusName(response.Status),
+				Type:   ctlutil.Title(response.Type.Name),
+			})
+			useTypes = useTypes || len(response.Type.Name) > 0
 		}
-	} else if e == nil {
-		log.Println("client.go exists. skipped it.")
+		if len(item.Request) > 0 {
+			members := util.GetRequestMembers(api, route)
+			item.Path = clientPath(members, route)
+			item.Body = len(members.Body) > 0
+			item.Opts = gocligen.CallOptions(members.Header, func(member spec.Member) string {
+				return "request." + strings.Title(member.Name)
+			})
+			usePath = usePath || len(route.GetPathParams()) > 0
+			useFmt = useFmt || len(members.Header) > 0
+		}
+		found := false
+		for _, version := range typesVersions {
+			found = found || version == route.Version
+		}
+		if useTypes && !found {
+			typesVersions = append(typesVersions, route.Version)
+		}
+		routes = append(routes, item)
 	}
 
-	// gen api.go
-	apiFile, e := os.OpenFile(filepath.Join(clientDir, "api.go"), os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
-	if e != nil {
-		return e
+	var imports []string
+	if len(routes) > 0 {
+		imports = append(imports, `"context"`)
+	}
+	if usePath || useFmt {
+		imports = append(imports, `"fmt"`)
+	}
+	if usePath {
+		imports = append(imports, `"net/url"`)
+	}
+	if len(imports) > 0 {
+		imports = append(imports, "")
+	}
+	for _, version := range typesVersions {
+		if len(version) == 0 {
+			imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(pkg, typesDir)))
+		} else {
+			imports = append(imports, fmt.Sprintf("%s \"%s\"", typesPacket+version, ctlutil.JoinPackages(pkg, typesDir, version)))
+		}
 	}
-	defer apiFile.Close()
 
-	api.Info.Desc = getImport(dir)
-	t, e := template.New("api.go").Funcs(util.FuncsMap).Parse(apiTemplate)
+	t, e := template.New("api.go").Parse(apiTemplate)
 	if e != nil {
 		return e
 	}
-	e = t.Execute(apiFile, api)
+	buffer := new(bytes.Buffer)
+	e = t.Execute(buffer, map[string]interface{}{
+		"imports": strings.Join(imports, "\n\t"),
+		"routes":  routes,
+		"Errors":  api.Errors,
+	})
 	if e != nil {
 		return e
 	}
+	_, e = io.WriteString(w, formatCode(buffer.String()))
+	return e
+}
+
+// clientPath returns a go expression building the uri of the route from the path and form members of the request,
+// e.g. "/api/user/" + url.PathEscape(fmt.Sprint(request.Name)) + apiQuery("age", request.Age)
+func clientPath(members util.RequestMembers, route spec.Route) string {
+	path := util.ConvertPath(route.Path, func(name string) string {
+		field := strcase.ToCamel(name)
+		if member, ok := members.GetPathMember(name); ok {
+			field = strings.Title(member.Name)
+		}
+		return fmt.Sprintf(`" + url.PathEscape(fmt.Sprint(request.%s)) + "`, field)
+	})
+	uri := strings.TrimSuffix(`"`+path+`"`, ` + ""`)
 
-	return nil
+	var params []string
+	for _, member := range members.Query {
+		params = append(params, fmt.Sprintf("%q, request.%s", member.GetTagName(), strings.Title(member.Name)))
+	}
+	if len(params) > 0 {
+		uri += " + apiQuery(" + strings.Join(params, ", ") + ")"
+	}
+	return uri
 }
 
-func getImport(dir string) string {
-	return strToolkit.TrimStart(strToolkit.SubAfter(dir, filepath.Join(os.Getenv("GOPATH"), "src"), dir), "/")
+// genProtoClientApi writes the client functions of the protobuf mode, which take and return the compiled messages.
+func genProtoClientApi(w io.Writer, dir string, api *spec.ApiSpec) error {
+	pkg, e := getParentPackage(dir)
+	if e != nil {
+		return e
+	}
+
+	var routes []clientRoute
+	var usePb, usePath, useFmt bool
+	for _, route := range api.Service.Routes {
+		item := clientRoute{
+			Func:       strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)),
+			Summary:    route.Summary,
+			Method:     strings.ToUpper(route.Method),
+			Path:       strconv.Quote(route.Path),
+			Request:    protogen.MessageName(route.RequestType.Name),
+			Response:   protogen.MessageName(route.ResponseType.Name),
+			Opts:       "opts",
+			Deprecated: route.Deprecation.Message(),
+		}
+		if len(item.Request) > 0 {
+			item.Path = protogen.GoPath(api, route, "request")
+			headers := util.GetRequestMembers(api, route).Header
+			item.Opts = gocligen.CallOptions(headers, func(member spec.Member) string {
+				return "request.Get" + protogen.GoFieldName(member) + "()"
+			})
+			usePath = usePath || len(route.GetPathParams()) > 0
+			useFmt = useFmt || len(headers) > 0
+		}
+		usePb = usePb || len(item.Request) > 0 || len(item.Response) > 0
+		routes = append(routes, item)
+	}
+
+	var imports []string
+	if len(routes) > 0 {
+		imports = append(imports, `"context"`)
+	}
+	if usePath || useFmt {
+		imports = append(imports, `"fmt"`)
+	}
+	if usePath {
+		imports = append(imports, `"net/url"`)
+	}
+	if len(imports) > 0 {
+		imports = append(imports, "")
+	}
+	if usePb {
+		imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(pkg, pbDir)))
+	}
+
+	t, e := template.New("api.go").Parse(protoApiTemplate)
+	if e != nil {
+		return e
+	}
+	buffer := new(bytes.Buffer)
+	e = t.Execute(buffer, map[string]interface{}{
+		"imports": strings.Join(imports, "\n\t"),
+		"routes":  routes,
+		"Errors":  api.Errors,
+	})
+	if e != nil {
+		return e
+	}
+	_, e = io.WriteString(w, formatCode(buffer.String()))
+	return e
 }
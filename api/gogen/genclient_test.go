@@ -0,0 +1,47 @@
+package gogen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientHeaderMembers(t *testing.T) {
+	p, err := parser.NewParserFromStr(`type getRequest struct {
+	name  string ` + "`path:\"name\"`" + `
+	token string ` + "`header:\"X-Token\"`" + `
+}
+
+type setRequest struct {
+	name  string ` + "`path:\"name\"`" + `
+	token string ` + "`header:\"X-Token\"`" + `
+	age   int    ` + "`json:\"age\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(getRequest)
+
+	@server(
+		handler: SetUserHandler
+	)
+	post /api/user/:name(setRequest)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, genJsonClientApi(&buffer, t.TempDir(), api))
+	code := buffer.String()
+	// the header members are set by CallHeader, and a GET without body members sends no body
+	assert.Contains(t, code, `c.call(ctx, "GET", "/api/user/"+url.PathEscape(fmt.Sprint(request.Name)), nil, nil, `+
+		`append([]CallOption{CallHeader("X-Token", fmt.Sprint(request.Token))}, opts...))`)
+	assert.Contains(t, code, `c.call(ctx, "POST", "/api/user/"+url.PathEscape(fmt.Sprint(request.Name)), request, nil, `+
+		`append([]CallOption{CallHeader("X-Token", fmt.Sprint(request.Token))}, opts...))`)
+}
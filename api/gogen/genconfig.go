@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
 	"github.com/gofaith/goctlr/vars"
 )
 
@@ -13,15 +15,34 @@ const (
 	configFile     = "config.go"
 	configTemplate = `package config
 
-import {{.authImport}}
+import (
+	{{- if .observability}}
+	"{{.observabilityPkg}}"
+{{end}}
+	{{.authImport}}
+)
 
 type Config struct {
 	rest.RestConf
+	{{- range .auths}}
+	{{.Name}} struct {
+		{{- if .Jwt}}
+		AccessSecret string
+		AccessExpire int64
+		{{- end}}
+		{{- if .Signature}}
+		Signature rest.SignatureConf
+		{{- end}}
+	}
+	{{- end}}
+	{{- if .observability}}
+	Observability observability.Config
+	{{- end}}
 }
 `
 )
 
-func genConfig(dir string) error {
+func genConfig(dir string, observability bool, api *spec.ApiSpec) error {
 	fp, created, err := util.MaybeCreateFile(dir, configDir, configFile)
 	if err != nil {
 		return err
@@ -31,11 +52,18 @@ func genConfig(dir string) error {
 	}
 	defer fp.Close()
 
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
 	var authImportStr = fmt.Sprintf("\"%s/rest\"", vars.ProjectOpenSourceUrl)
 	t := template.Must(template.New("configTemplate").Parse(configTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(buffer, map[string]string{
-		"authImport": authImportStr,
+	err = t.Execute(buffer, map[string]interface{}{
+		"authImport":       authImportStr,
+		"auths":            getAuths(api),
+		"observability":    observability,
+		"observabilityPkg": ctlutil.JoinPackages(parentPkg, observabilityDir),
 	})
 	if err != nil {
 		return nil
@@ -0,0 +1,113 @@
+package gogen
+
+import (
+	"bytes"
+	"path"
+	"strconv"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+)
+
+const (
+	errorxFile     = "errorx.go"
+	errorxTemplate = `// DO NOT EDIT, generated by goctl
+package errorx
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is the code of an error declared in the errors block of the api file.
+type Code int
+
+const (
+	{{- range .errors}}
+	// {{.Name}} {{.Desc}}
+	{{.Name}} Code = {{.Code}}
+	{{- end}}
+)
+
+// CodeError is written by the handlers as {"code":1001,"desc":"user not found"} with its http Status.
+type CodeError struct {
+	Status int    ` + "`" + `json:"-"` + "`" + `
+	Code   Code   ` + "`" + `json:"code"` + "`" + `
+	Desc   string ` + "`" + `json:"desc"` + "`" + `
+}
+{{range .errors}}
+// New{{.Name}} returns the error {{.Name}}, {{.Desc}}
+func New{{.Name}}() *CodeError {
+	return &CodeError{Status: {{.Status}}, Code: {{.Name}}, Desc: {{.Quoted}}}
+}
+{{end}}
+func (e *CodeError) Error() string {
+	return e.Desc
+}
+
+// Is tells whether target is a CodeError of the same code, e.g. errors.Is(e, errorx.NewUserNotFound())
+func (e *CodeError) Is(target error) bool {
+	t, ok := target.(*CodeError)
+	return ok && t.Code == e.Code
+}
+
+// WithDesc returns a copy of e described by desc, e.g. errorx.NewUserNotFound().WithDesc("no user " + name)
+func (e *CodeError) WithDesc(desc string) *CodeError {
+	err := *e
+	err.Desc = desc
+	return &err
+}
+
+// Handle is the error handler of httpx set by RegisterHandlers, the CodeErrors are written with their Status
+// and the other errors are written as 400 with the code 0.
+func Handle(err error) (int, interface{}) {
+	var codeErr *CodeError
+	if !errors.As(err, &codeErr) {
+		codeErr = &CodeError{Status: http.StatusBadRequest, Desc: err.Error()}
+	}
+	// the body is written as json since it isn't an error, unlike *CodeError
+	return codeErr.Status, *codeErr
+}
+`
+)
+
+// genErrorx generates the package errorx with the errors declared in the errors block of the api file.
+func genErrorx(dir string, api *spec.ApiSpec) error {
+	if len(api.Errors) == 0 {
+		return nil
+	}
+	filename := path.Join(dir, errorxDir, errorxFile)
+	if err := util.RemoveOrQuit(filename); err != nil {
+		return err
+	}
+
+	fp, created, err := apiutil.MaybeCreateFile(dir, errorxDir, errorxFile)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	type item struct {
+		spec.Error
+		Quoted string
+	}
+	var errs []item
+	for _, e := range api.Errors {
+		errs = append(errs, item{Error: e, Quoted: strconv.Quote(e.Desc)})
+	}
+	t := template.Must(template.New("errorxTemplate").Parse(errorxTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]interface{}{
+		"errors": errs,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
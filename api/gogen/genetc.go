@@ -3,23 +3,63 @@ package gogen
 import (
 	"bytes"
 	"fmt"
+	"log"
 	"strconv"
 	"text/template"
+	"time"
 
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
 )
 
 const (
 	defaultPort = 8888
-	etcDir      = "etc"
-	etcTemplate = `Name: {{.serviceName}}
+	// the default MaxBytes and Timeout of rest.RestConf, and the largest MaxBytes it accepts
+	defaultMaxBytes = 1 << 20
+	maxMaxBytes     = 8 << 20
+	defaultTimeout  = 3 * time.Second
+	etcDir          = "etc"
+	etcTemplate     = `Name: {{.serviceName}}
 Host: {{.host}}
-Port: {{.port}}
+Port: {{.port}}{{if .stream}}
+# the streams last longer than any timeout
+Timeout: 0{{else if .timeout}}
+# the longest timeout of the groups, which limit their routes in handler/routes.go
+Timeout: {{.timeout}}{{end}}{{if .maxBytes}}
+# the largest body of the groups and the file uploads, which limit their routes
+MaxBytes: {{.maxBytes}}{{end}}
+{{- range .auths}}
+{{.Name}}:
+  {{- if .Jwt}}
+  # the secret signing the jwt tokens
+  AccessSecret: change-me
+  AccessExpire: 86400
+  {{- end}}
+  {{- if .Signature}}
+  Signature:
+    PrivateKeys:
+      - Fingerprint: change-me
+        KeyFile: change-me.pem
+  {{- end}}
+{{- end}}
+{{- if .observability}}
+# the metrics of the handlers are served by the Prometheus agent
+Prometheus:
+  Host: 0.0.0.0
+  Port: 9101
+  Path: /metrics
+Observability:
+  # the OTLP/HTTP endpoint the spans are exported to, e.g. localhost:4318
+  TracingEndpoint: ""
+  TracingSampler: 1
+  HealthPath: /healthz
+  ReadyPath: /readyz
+{{- end}}
 `
 )
 
-func genEtc(dir string, api *spec.ApiSpec) error {
+func genEtc(dir string, observability bool, api *spec.ApiSpec) error {
 	fp, created, err := util.MaybeCreateFile(dir, etcDir, fmt.Sprintf("%s.yaml", api.Service.Name))
 	if err != nil {
 		return err
@@ -39,12 +79,22 @@ func genEtc(dir string, api *spec.ApiSpec) error {
 		port = strconv.Itoa(defaultPort)
 	}
 
+	maxBytes, timeout, err := getConfigLimits(api)
+	if err != nil {
+		return err
+	}
+
 	t := template.Must(template.New("etcTemplate").Parse(etcTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(buffer, map[string]string{
-		"serviceName": service.Name,
-		"host":        host,
-		"port":        port,
+	err = t.Execute(buffer, map[string]interface{}{
+		"serviceName":   service.Name,
+		"host":          host,
+		"port":          port,
+		"stream":        hasStream(api),
+		"timeout":       timeout.Milliseconds(),
+		"maxBytes":      maxBytes,
+		"auths":         getAuths(api),
+		"observability": observability,
 	})
 	if err != nil {
 		return err
@@ -53,3 +103,50 @@ func genEtc(dir string, api *spec.ApiSpec) error {
 	_, err = fp.WriteString(formatCode)
 	return err
 }
+
+func hasStream(api *spec.ApiSpec) bool {
+	for _, route := range api.Service.Routes {
+		if len(route.Stream) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getConfigLimits returns the MaxBytes and Timeout of the config, which every route runs within before the limits
+// of its group and its uploads, they are zero if the defaults are large enough.
+func getConfigLimits(api *spec.ApiSpec) (int64, time.Duration, error) {
+	var maxBytes int64
+	var timeout time.Duration
+	for _, g := range api.Service.Groups {
+		if g.Timeout > timeout {
+			timeout = g.Timeout
+		}
+		if g.MaxBytes > maxBytes {
+			maxBytes = g.MaxBytes
+		}
+		for _, route := range g.Routes {
+			if !util.IsMultipart(api, route) {
+				continue
+			}
+			size, err := servergen.GetMultipartMaxBytes(api, route)
+			if err != nil {
+				return 0, 0, err
+			}
+			if size > maxBytes {
+				maxBytes = size
+			}
+		}
+	}
+	if maxBytes > maxMaxBytes {
+		log.Printf("the bodies are limited to %d bytes, the largest MaxBytes of the config, instead of %d", maxMaxBytes, maxBytes)
+		maxBytes = maxMaxBytes
+	}
+	if maxBytes <= defaultMaxBytes {
+		maxBytes = 0
+	}
+	if timeout <= defaultTimeout {
+		timeout = 0
+	}
+	return maxBytes, timeout, nil
+}
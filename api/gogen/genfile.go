@@ -0,0 +1,315 @@
+package gogen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
+)
+
+const (
+	fileHandlerTemplate = `package handler
+
+import (
+	"net/http"
+
+	logic "{{.logicPkg}}"{{if .observability}}
+	"{{.pkg}}/internal/observability"{{end}}
+	"{{.pkg}}/internal/svc"{{if .request}}
+	{{.typesImport}}{{end}}
+
+	"{{.rest}}/httpx"
+)
+
+func {{.handler}}(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		{{- if .files}}
+		// the files are limited by their maxSize, the form fields by the rest
+		r.Body = http.MaxBytesReader(w, r.Body, {{.maxBytes}})
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		{{- if .params}}
+		var params struct {
+			{{- range .params}}
+			{{.Name}} {{.Type}} {{.Tag}}
+			{{- end}}
+		}
+		if err := parseFormParams(r, &params); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		{{- end}}
+		var req types.{{.request}}
+		{{- range .params}}
+		req.{{.Name}} = params.{{.Name}}
+		{{- end}}
+		{{- range .files}}
+		if err := {{if .List}}formFiles{{else}}formFile{{end}}(r, "{{.Field}}", {{.MaxSize}}, {{.Optional}}, &req.{{.Name}}); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		{{- end}}
+		{{- else if .request}}
+		var req types.{{.request}}
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		{{- end}}
+
+		{{- if .observability}}
+		logicCtx, span := observability.StartSpan(r.Context(), "{{.name}}Logic.{{.name}}")
+		l := logic.New{{.name}}Logic(logicCtx, ctx)
+		{{- else}}
+		l := logic.New{{.name}}Logic(r.Context(), ctx)
+		{{- end}}
+		{{- if .binary}}
+		name, content, err := l.{{.name}}({{if .request}}req{{end}}){{template "endSpan" .}}
+		if err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		writeBinary(w, r, name, content)
+		{{- else if .response}}
+		resp, err := l.{{.name}}({{if .request}}req{{end}}){{template "endSpan" .}}
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			httpx.WriteJson(w, http.StatusOK, resp)
+		}
+		{{- else}}
+		err := l.{{.name}}({{if .request}}req{{end}}){{template "endSpan" .}}
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			httpx.Ok(w)
+		}
+		{{- end}}
+	}
+}
+{{- define "endSpan"}}{{if .observability}}
+		observability.EndSpan(span, err){{end}}{{end}}
+`
+	multipartTemplate = `package handler
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"{{.rest}}/httpx"
+)
+
+// the size of the files kept in memory while parsing a multipart request, the rest is written to temporary files
+const multipartMemory = 32 << 20
+
+// parseFormParams parses the path, form and header parameters of a parsed multipart request into v.
+func parseFormParams(r *http.Request, v interface{}) error {
+	r2 := r.Clone(r.Context())
+	r2.Body = http.NoBody
+	r2.ContentLength = 0
+	return httpx.Parse(r2, v)
+}
+
+// formFile sets dst to the file uploaded as the form field name, which can't be larger than maxSize bytes.
+func formFile(r *http.Request, name string, maxSize int64, optional bool, dst **multipart.FileHeader) error {
+	files := r.MultipartForm.File[name]
+	if len(files) == 0 {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("file %s is not set", name)
+	}
+	if files[0].Size > maxSize {
+		return fmt.Errorf("file %s is larger than %d bytes", name, maxSize)
+	}
+	*dst = files[0]
+	return nil
+}
+
+// formFiles sets dst to the files uploaded as the form field name, their total size can't be larger than maxSize bytes.
+func formFiles(r *http.Request, name string, maxSize int64, optional bool, dst *[]*multipart.FileHeader) error {
+	files := r.MultipartForm.File[name]
+	if len(files) == 0 && !optional {
+		return fmt.Errorf("file %s is not set", name)
+	}
+	var size int64
+	for _, file := range files {
+		size += file.Size
+	}
+	if size > maxSize {
+		return fmt.Errorf("files %s are larger than %d bytes", name, maxSize)
+	}
+	*dst = files
+	return nil
+}
+`
+	binaryTemplate = `package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// writeBinary sends content as the download name, the content type is guessed from the extension of name.
+// A seekable content is served with range requests, and the content is closed if it's an io.Closer.
+func writeBinary(w http.ResponseWriter, r *http.Request, name string, content io.Reader) {
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		// lets the browsers of other origins read the file name
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	}
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, time.Time{}, seeker)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if content != nil {
+		io.Copy(w, content)
+	}
+}
+`
+)
+
+type (
+	fileParam struct {
+		Name     string
+		Field    string
+		List     bool
+		MaxSize  int64
+		Optional bool
+	}
+	formParam struct {
+		Name string
+		Type string
+		Tag  string
+	}
+)
+
+// genFileHandler generates the handler of a route uploading files as multipart/form-data or returning binary.
+func genFileHandler(dir string, observability bool, api *spec.ApiSpec, group spec.Group, route spec.Route) error {
+	handler, ok := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		return fmt.Errorf("missing handler annotation for %q", route.Path)
+	}
+	handler = getHandlerName(handler)
+	if servergen.GetHandlerFolderPath(group, route) != handlerDir {
+		handler = strings.Title(handler)
+	}
+	pkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	var files []fileParam
+	var params []formParam
+	var maxBytes int64
+	if apiutil.IsMultipart(api, route) {
+		for _, member := range apiutil.FlattenMembers(api.Types, route.RequestType) {
+			if !member.IsFile() {
+				params = append(params, formParam{
+					Name: util.Title(member.Name),
+					Type: member.Type,
+					Tag:  member.Tag,
+				})
+				continue
+			}
+			maxSize, err := member.GetMaxSize()
+			if err != nil {
+				return err
+			}
+			files = append(files, fileParam{
+				Name:     util.Title(member.Name),
+				Field:    member.GetTagName(),
+				List:     member.IsFileList(),
+				MaxSize:  maxSize,
+				Optional: member.IsOptional(),
+			})
+		}
+		if maxBytes, err = servergen.GetMultipartMaxBytes(api, route); err != nil {
+			return err
+		}
+		if err := genHandlerHelper(dir, group, route, "multipart.go", multipartTemplate); err != nil {
+			return err
+		}
+	}
+	if route.Binary {
+		if err := genHandlerHelper(dir, group, route, "binary.go", binaryTemplate); err != nil {
+			return err
+		}
+	}
+
+	fp, created, err := apiutil.MaybeCreateFile(dir, servergen.GetHandlerFolderPath(group, route), strings.ToLower(handler)+".go")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New("fileHandlerTemplate").Parse(fileHandlerTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]interface{}{
+		"pkg":           pkg,
+		"typesImport":   getTypesImport(pkg, route),
+		"logicPkg":      util.JoinPackages(pkg, servergen.GetLogicFolderPath(group, route)),
+		"rest":          vars.ProjectOpenSourceUrl + "/rest",
+		"handler":       handler,
+		"name":          strings.Title(servergen.GetHandlerBaseName(handler)),
+		"request":       util.Title(route.RequestType.Name),
+		"response":      len(route.ResponseType.Name) > 0,
+		"binary":        route.Binary,
+		"files":         files,
+		"params":        params,
+		"maxBytes":      maxBytes,
+		"observability": observability,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
+
+// genHandlerHelper generates a file of functions shared by the handlers of a handler folder.
+func genHandlerHelper(dir string, group spec.Group, route spec.Route, file, text string) error {
+	fp, created, err := apiutil.MaybeCreateFile(dir, servergen.GetHandlerFolderPath(group, route), file)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New(file).Parse(text))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"rest": vars.ProjectOpenSourceUrl + "/rest",
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
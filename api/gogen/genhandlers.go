@@ -3,18 +3,19 @@ package gogen
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/protogen"
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	apiutil "github.com/gofaith/goctlr/api/util"
 	"github.com/gofaith/goctlr/util"
 	"github.com/gofaith/goctlr/vars"
-	"github.com/iancoleman/strcase"
 )
 
 const (
@@ -41,129 +42,184 @@ func {{.handlerName}}(ctx *svc.ServiceContext) http.HandlerFunc {
 			return
 		}
 `
-	hasRespTemplate = `
-		l := logic.{{.logic}}(r.Context(), ctx)
-		{{.logicResponse}} l.{{.callee}}({{.req}})
+	hasRespTemplate = `{{template "callLogic" .}}
 		if err != nil {
 			httpx.Error(w, err)
 		} else {
 			{{.respWriter}}
 		}
 	`
-	hasRespTemplate_HtmlMode = `
-		l := logic.{{.logic}}(r.Context(), ctx)
-		err := l.{{.callee}}({{.req}})
+	hasRespTemplate_HtmlMode = `{{template "callLogic" .}}
 		if err != nil {
 			httpx.Error(w, err)
 		}
 	`
+	// callLogic calls the logic in a span with -observability
+	callLogicTemplate = `{{define "callLogic"}}
+		{{- if .observability}}
+		logicCtx, span := observability.StartSpan(r.Context(), "{{.span}}")
+		l := logic.{{.logic}}(logicCtx, ctx)
+		{{.logicResponse}} l.{{.callee}}({{.req}})
+		observability.EndSpan(span, err)
+		{{- else}}
+		l := logic.{{.logic}}(r.Context(), ctx)
+		{{.logicResponse}} l.{{.callee}}({{.req}})
+		{{- end}}
+{{- end}}`
 	protoTemplate = `package handler
 
 import (
-	"io"
 	"net/http"
 
-	"github.com/gofaith/rest/httpx"
-	logic "{{.pkg}}/internal/logic/user"
-	"{{.pkg}}/internal/pb"
-	"{{.pkg}}/internal/svc"{{if and (ne .route.RequestType.Name "") (ne .route.ResponseType.Name "")}}
-	"google.golang.org/protobuf/proto"{{end}}
+	"{{.pkg}}/internal/codec"
+	logic "{{.logicPkg}}"{{if .observability}}
+	"{{.pkg}}/internal/observability"{{end}}{{if .request}}
+	"{{.pkg}}/internal/pb"{{end}}
+	"{{.pkg}}/internal/svc"
+
+	"{{.rest}}/httpx"
 )
 
-func {{.name}}Handler(ctx *svc.ServiceContext) http.HandlerFunc {
+func {{.handler}}(ctx *svc.ServiceContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		l := logic.New{{.name}}Logic(r.Context(), ctx){{if ne .route.RequestType.Name ""}}
-		b, e := io.ReadAll(r.Body)
-		if e != nil {
+		{{- if .request}}
+		var req pb.{{.request}}
+		if e := codec.Read(r, &req); e != nil {
 			httpx.Error(w, e)
 			return
 		}
-		req := pb.User{{.name}}Request{}
-		e = proto.Unmarshal(b, &req)
-		if e != nil {
-			httpx.Error(w, e)
-			return
-		}{{if ne .route.ResponseType.Name ""}}
-		res, e := l.{{.name}}(&req)
-		if e != nil {
-			httpx.Error(w, e)
-			return
+		{{- if .params}}
+		// the path, form and header parameters take precedence over the body
+		var params struct {
+			{{- range .params}}
+			{{.Name}} {{.Type}} {{.Tag}}
+			{{- end}}
 		}
-		b2, e := proto.Marshal(res)
-		if e != nil {
+		if e := codec.ParseParams(r, &params); e != nil {
 			httpx.Error(w, e)
 			return
 		}
-		w.Write(b){{else}}
-		e := l.{{.name}}(&req)
+		{{- range .params}}
+		{{.Assign}}
+		{{- end}}
+		{{- end}}
+{{end}}
+		{{- if .observability}}
+		logicCtx, span := observability.StartSpan(r.Context(), "{{.name}}Logic.{{.name}}")
+		l := logic.New{{.name}}Logic(logicCtx, ctx)
+		{{- else}}
+		l := logic.New{{.name}}Logic(r.Context(), ctx)
+		{{- end}}
+		{{if .response}}res, e := l.{{.name}}({{if .request}}&req{{end}}){{if .observability}}
+		observability.EndSpan(span, e){{end}}
 		if e != nil {
 			httpx.Error(w, e)
 			return
 		}
-		httpx.Ok(w){{end}}{{else}}{{if ne .route.ResponseType.Name ""}}
-		res, e := l.{{.name}}()
+		codec.Write(w, r, res){{else}}e := l.{{.name}}({{if .request}}&req{{end}}){{if .observability}}
+		observability.EndSpan(span, e){{end}}
 		if e != nil {
 			httpx.Error(w, e)
 			return
 		}
-		b2, e := proto.Marshal(res)
-		if e != nil {
-			httpx.Error(w, e)
-			return
-		}
-		w.Write(b){{else}}
-		e := l.{{.name}}()
-		if e != nil {
-			httpx.Error(w, e)
-			return
-		}
-		httpx.Ok(w){{end}}{{end}}
+		httpx.Ok(w){{end}}
 	}
 }
 `
 )
 
-func genHandlerProto(dir string, group spec.Group, route spec.Route) error {
+// protoParam is a path, form or header member of a request in the protobuf mode, it's parsed by httpx and copied into the message.
+type protoParam struct {
+	Name   string
+	Type   string
+	Tag    string
+	Assign string
+}
+
+func genHandlerProto(dir string, observability bool, api *spec.ApiSpec, group spec.Group, route spec.Route) error {
 	handler, ok := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
 	if !ok {
 		return fmt.Errorf("missing handler annotation for %q", route.Path)
 	}
 	handler = getHandlerName(handler)
+	if servergen.GetHandlerFolderPath(group, route) != handlerDir {
+		handler = strings.Title(handler)
+	}
 	pkg, e := getParentPackage(dir)
 	if e != nil {
 		log.Println(e)
 		return e
 	}
 
+	var params []protoParam
+	if len(route.RequestType.Name) > 0 {
+		params, e = getProtoParams(api, route)
+		if e != nil {
+			log.Println(e)
+			return e
+		}
+	}
+
 	t, e := template.New("protoTemplate").Parse(protoTemplate)
 	if e != nil {
 		log.Println(e)
 		return e
 	}
 
-	base := filepath.Join(dir, getHandlerFolderPath(group, route))
+	base := filepath.Join(dir, servergen.GetHandlerFolderPath(group, route))
 	os.MkdirAll(base, 0755)
 	path := filepath.Join(base, strings.ToLower(handler)+".go")
-	fo, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	buffer := new(bytes.Buffer)
+	e = t.Execute(buffer, map[string]interface{}{
+		"pkg":           pkg,
+		"logicPkg":      util.JoinPackages(pkg, servergen.GetLogicFolderPath(group, route)),
+		"rest":          vars.ProjectOpenSourceUrl + "/rest",
+		"handler":       handler,
+		"name":          strings.Title(servergen.GetHandlerBaseName(handler)),
+		"request":       protogen.MessageName(route.RequestType.Name),
+		"response":      protogen.MessageName(route.ResponseType.Name),
+		"params":        params,
+		"observability": observability,
+	})
 	if e != nil {
 		log.Println(e)
 		return e
 	}
-	defer fo.Close()
+	return ioutil.WriteFile(path, []byte(formatCode(buffer.String())), 0644)
+}
 
-	e = t.Execute(fo, map[string]interface{}{
-		"pkg":   pkg,
-		"route": route,
-		"name":  strcase.ToCamel(strings.TrimSuffix(handler, "Handler")),
-	})
-	if e != nil {
-		log.Println(e)
-		return e
+func getProtoParams(api *spec.ApiSpec, route spec.Route) ([]protoParam, error) {
+	members := apiutil.GetRequestMembers(api, route)
+	var params []protoParam
+	for _, list := range [][]spec.Member{members.Path, members.Query, members.Header} {
+		for _, member := range list {
+			name := protogen.GoFieldName(member)
+			typ := strings.TrimPrefix(member.Type, "*")
+			elem := strings.TrimPrefix(typ, "[]")
+			pbType, ok := protogen.GoType(elem)
+			if !ok {
+				return nil, fmt.Errorf("route %s: the %s member %s must be of a basic type or a slice of it", route.Path, member.GetTagKey(), member.Name)
+			}
+			param := protoParam{
+				Name: name,
+				Type: typ,
+				Tag:  member.Tag,
+			}
+			switch {
+			case pbType == elem:
+				param.Assign = fmt.Sprintf("req.%s = params.%s", name, name)
+			case typ == elem:
+				param.Assign = fmt.Sprintf("req.%s = %s(params.%s)", name, pbType, name)
+			default:
+				param.Assign = fmt.Sprintf("for _, v := range params.%s {\n\t\t\treq.%s = append(req.%s, %s(v))\n\t\t}", name, name, name, pbType)
+			}
+			params = append(params, param)
+		}
 	}
-	return nil
+	return params, nil
 }
 
-func genHandler(dir string, group spec.Group, route spec.Route) error {
+func genHandler(dir string, observability bool, group spec.Group, route spec.Route) error {
 	handler, ok := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
 	if !ok {
 		return fmt.Errorf("missing handler annotation for %q", route.Path)
@@ -201,9 +257,19 @@ func genHandler(dir string, group spec.Group, route spec.Route) error {
 	var logicResponse string
 	var writeResponse string
 	var respWriter = `httpx.WriteJson(w, http.StatusOK, resp)`
-	if len(route.ResponseType.Name) > 0 {
+	if len(route.ResponseType.Name) > 0 || len(route.Responses) > 0 {
 		logicResponse = "resp, err :="
 		writeResponse = "resp, err"
+		if len(route.Responses) > 0 {
+			// a logic returning neither a response nor an error is a bug, and a nil body is written without body
+			respWriter = `if resp == nil {
+				http.Error(w, "no response", http.StatusInternalServerError)
+			} else if !resp.HasBody() {
+				w.WriteHeader(resp.Status)
+			} else {
+				httpx.WriteJson(w, resp.Status, resp.Body)
+			}`
+		}
 	} else {
 		logicResponse = "err :="
 		writeResponse = "nil, err"
@@ -212,26 +278,30 @@ func genHandler(dir string, group spec.Group, route spec.Route) error {
 	var logicBodyBuilder strings.Builder
 	switch typ {
 	case SERVER_TYPE_HTML:
-		t := template.Must(template.New("hasRespTemplate").Parse(hasRespTemplate_HtmlMode))
-		if err := t.Execute(&logicBodyBuilder, map[string]string{
+		t := template.Must(template.Must(template.New("hasRespTemplate").Parse(hasRespTemplate_HtmlMode)).Parse(callLogicTemplate))
+		if err := t.Execute(&logicBodyBuilder, map[string]interface{}{
 			"logic":         "New" + strings.TrimSuffix(strings.Title(handler), "Handler") + "Logic",
 			"callee":        strings.Title(strings.TrimSuffix(handler, "Handler")),
+			"span":          strings.TrimSuffix(strings.Title(handler), "Handler") + "Logic." + strings.Title(strings.TrimSuffix(handler, "Handler")),
 			"req":           req,
-			"logicResponse": logicResponse,
+			"logicResponse": "err :=",
 			"writeResponse": writeResponse,
 			"respWriter":    respWriter,
+			"observability": observability,
 		}); err != nil {
 			return err
 		}
 	default:
-		t := template.Must(template.New("hasRespTemplate").Parse(hasRespTemplate))
-		if err := t.Execute(&logicBodyBuilder, map[string]string{
+		t := template.Must(template.Must(template.New("hasRespTemplate").Parse(hasRespTemplate)).Parse(callLogicTemplate))
+		if err := t.Execute(&logicBodyBuilder, map[string]interface{}{
 			"logic":         "New" + strings.TrimSuffix(strings.Title(handler), "Handler") + "Logic",
 			"callee":        strings.Title(strings.TrimSuffix(handler, "Handler")),
+			"span":          strings.TrimSuffix(strings.Title(handler), "Handler") + "Logic." + strings.Title(strings.TrimSuffix(handler, "Handler")),
 			"req":           req,
 			"logicResponse": logicResponse,
 			"writeResponse": writeResponse,
 			"respWriter":    respWriter,
+			"observability": observability,
 		}); err != nil {
 			return err
 		}
@@ -250,11 +320,11 @@ func genHandler(dir string, group spec.Group, route spec.Route) error {
 	}); err != nil {
 		return err
 	}
-	return doGenToFile(dir, handler, group, route, bodyBuilder)
+	return doGenToFile(dir, handler, observability, group, route, bodyBuilder)
 }
 
-func doGenToFile(dir, handler string, group spec.Group, route spec.Route, bodyBuilder strings.Builder) error {
-	if getHandlerFolderPath(group, route) != handlerDir {
+func doGenToFile(dir, handler string, observability bool, group spec.Group, route spec.Route, bodyBuilder strings.Builder) error {
+	if servergen.GetHandlerFolderPath(group, route) != handlerDir {
 		handler = strings.Title(handler)
 	}
 	parentPkg, err := getParentPackage(dir)
@@ -267,7 +337,7 @@ func doGenToFile(dir, handler string, group spec.Group, route spec.Route, bodyBu
 	} else {
 		filename = filename + "handler.go"
 	}
-	fp, created, err := apiutil.MaybeCreateFile(dir, getHandlerFolderPath(group, route), filename)
+	fp, created, err := apiutil.MaybeCreateFile(dir, servergen.GetHandlerFolderPath(group, route), filename)
 	if err != nil {
 		return err
 	}
@@ -278,7 +348,7 @@ func doGenToFile(dir, handler string, group spec.Group, route spec.Route, bodyBu
 	t := template.Must(template.New("handlerTemplate").Parse(handlerTemplate))
 	buffer := new(bytes.Buffer)
 	err = t.Execute(buffer, map[string]string{
-		"importPackages": genHandlerImports(group, route, parentPkg),
+		"importPackages": genHandlerImports(group, route, parentPkg, observability),
 		"handlerName":    handler,
 		"handlerBody":    strings.TrimSpace(bodyBuilder.String()),
 	})
@@ -290,18 +360,42 @@ func doGenToFile(dir, handler string, group spec.Group, route spec.Route, bodyBu
 	return err
 }
 
-func genHandlers(dir, proto string, api *spec.ApiSpec) error {
+func genHandlers(dir, proto string, observability bool, api *spec.ApiSpec) error {
 	for _, group := range api.Service.Groups {
 		for _, route := range group.Routes {
+			if len(route.Stream) > 0 {
+				if proto != "" {
+					return fmt.Errorf("the stream %s can't be generated with -proto", route.Path)
+				}
+				if err := genStreamHandler(dir, group, route); err != nil {
+					return err
+				}
+				continue
+			}
+			if len(route.Responses) > 0 {
+				typ, _ := apiutil.GetAnnotationValue(route.Annotations, "server", "type")
+				if proto != "" || apiutil.IsMultipart(api, route) || typ == SERVER_TYPE_HTML {
+					return fmt.Errorf("the responses of %s can only be generated for the json routes", route.Path)
+				}
+			}
+			if route.Binary || apiutil.IsMultipart(api, route) {
+				if proto != "" {
+					return fmt.Errorf("the files of %s can't be generated with -proto", route.Path)
+				}
+				if err := genFileHandler(dir, observability, api, group, route); err != nil {
+					return err
+				}
+				continue
+			}
 			if proto != "" {
-				e := genHandlerProto(dir, group, route)
+				e := genHandlerProto(dir, observability, api, group, route)
 				if e != nil {
 					log.Println(e)
 					return e
 				}
 				continue
 			}
-			if err := genHandler(dir, group, route); err != nil {
+			if err := genHandler(dir, observability, group, route); err != nil {
 				return err
 			}
 		}
@@ -310,42 +404,22 @@ func genHandlers(dir, proto string, api *spec.ApiSpec) error {
 	return nil
 }
 
-func genHandlerImports(group spec.Group, route spec.Route, parentPkg string) string {
+func genHandlerImports(group spec.Group, route spec.Route, parentPkg string, observability bool) string {
 	var imports []string
 	imports = append(imports, fmt.Sprintf("\"%s\"",
-		util.JoinPackages(parentPkg, getLogicFolderPath(group, route))))
+		util.JoinPackages(parentPkg, servergen.GetLogicFolderPath(group, route))))
+	if observability {
+		imports = append(imports, fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, observabilityDir)))
+	}
 	imports = append(imports, fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, contextDir)))
 	if len(route.RequestType.Name) > 0 {
-		imports = append(imports, fmt.Sprintf("\"%s\"\n", util.JoinPackages(parentPkg, typesDir)))
+		imports = append(imports, getTypesImport(parentPkg, route)+"\n")
 	}
 	imports = append(imports, fmt.Sprintf("\"%s/rest/httpx\"", vars.ProjectOpenSourceUrl))
 
 	return strings.Join(imports, "\n\t")
 }
 
-func getHandlerBaseName(handler string) string {
-	handlerName := util.Untitle(handler)
-	if strings.HasSuffix(handlerName, "handler") {
-		handlerName = strings.ReplaceAll(handlerName, "handler", "")
-	} else if strings.HasSuffix(handlerName, "Handler") {
-		handlerName = strings.ReplaceAll(handlerName, "Handler", "")
-	}
-	return handlerName
-}
-
-func getHandlerFolderPath(group spec.Group, route spec.Route) string {
-	folder, ok := apiutil.GetAnnotationValue(route.Annotations, "server", folderProperty)
-	if !ok {
-		folder, ok = apiutil.GetAnnotationValue(group.Annotations, "server", folderProperty)
-		if !ok {
-			return handlerDir
-		}
-	}
-	folder = strings.TrimPrefix(folder, "/")
-	folder = strings.TrimSuffix(folder, "/")
-	return path.Join(handlerDir, folder)
-}
-
 func getHandlerName(handler string) string {
-	return getHandlerBaseName(handler) + "Handler"
+	return servergen.GetHandlerBaseName(handler) + "Handler"
 }
@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
-	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
 
 	"github.com/StevenZack/tools/strToolkit"
+	"github.com/gofaith/goctlr/api/protogen"
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
 	apiutil "github.com/gofaith/goctlr/api/util"
@@ -32,7 +33,7 @@ type {{.logic}} struct {
 
 func New{{.logic}}(ctx context.Context, svcCtx *svc.ServiceContext) {{.logic}} {
 	return {{.logic}}{
-		Logger: logx.WithContext(ctx),
+		Logger: {{if .observability}}observability.Logger(ctx){{else}}logx.WithContext(ctx){{end}},
 		ctx:    ctx,
 		s:      svcCtx,
 	}
@@ -55,10 +56,10 @@ func (l *{{.logic}}) {{.function}}({{.request}}) {{.responseType}} {
 func (l *`
 )
 
-func genLogic(dir, proto string, api *spec.ApiSpec) error {
+func genLogic(dir, proto string, observability bool, api *spec.ApiSpec) error {
 	for _, g := range api.Service.Groups {
 		for _, r := range g.Routes {
-			err := genLogicByRoute(dir, g, r)
+			err := genLogicByRoute(dir, proto, observability, g, r)
 			if err != nil {
 				return err
 			}
@@ -67,7 +68,7 @@ func genLogic(dir, proto string, api *spec.ApiSpec) error {
 	return nil
 }
 
-func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
+func genLogicByRoute(dir, proto string, observability bool, group spec.Group, route spec.Route) error {
 	handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
 	if !ok {
 		return fmt.Errorf("missing handler annotation for %q", route.Path)
@@ -80,14 +81,14 @@ func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
 	filename := strings.ToLower(handler)
 	goFile := filename + "logic.go"
 	logic := strings.Title(handler) + "Logic"
-	fp, created, err := util.MaybeCreateFile(dir, getLogicFolderPath(group, route), goFile)
+	fp, created, err := util.MaybeCreateFile(dir, servergen.GetLogicFolderPath(group, route), goFile)
 	if err != nil {
 		return err
 	}
 
 	if !created {
 		//update doc
-		path := filepath.Join(dir, getLogicFolderPath(group, route), goFile)
+		path := filepath.Join(dir, servergen.GetLogicFolderPath(group, route), goFile)
 		b, e := ioutil.ReadFile(path)
 		if e != nil {
 			return e
@@ -130,12 +131,38 @@ func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
 		return err
 	}
 
-	imports := genLogicImports(route, parentPkg, typ)
+	imports := genLogicImports(route, parentPkg, typ, proto, observability)
 	var responseString string
 	var returnString string
 	var requestString string
-	switch typ {
-	case SERVER_TYPE_HTML:
+	switch {
+	case len(proto) > 0:
+		// the messages compiled from the proto file, the handler decodes them from json or protobuf
+		if len(route.RequestType.Name) > 0 {
+			requestString = "req *pb." + protogen.MessageName(route.RequestType.Name)
+		}
+
+		if len(route.ResponseType.Name) > 0 {
+			resp := protogen.MessageName(route.ResponseType.Name)
+			responseString = "(*pb." + resp + ", error)"
+			returnString = fmt.Sprintf("return &pb.%s{}, nil", resp)
+		} else {
+			responseString = "error"
+			returnString = "return nil"
+		}
+	case len(route.Stream) > 0:
+		// the handler writes the events passed to send until the method returns
+		requestString = getStreamLogicSignature(route)
+		responseString = "error"
+		returnString = "return nil"
+	case route.Binary:
+		// the handler sends the content as a download of the file name
+		if len(route.RequestType.Name) > 0 {
+			requestString = "req " + "types." + strings.Title(route.RequestType.Name)
+		}
+		responseString = "(name string, content io.Reader, err error)"
+		returnString = `return "", nil, nil`
+	case typ == SERVER_TYPE_HTML:
 		if len(route.RequestType.Name) > 0 {
 			requestString = "w http.ResponseWriter, r *http.Request, req " + "types." + strings.Title(route.RequestType.Name)
 		} else {
@@ -149,7 +176,10 @@ func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
 			requestString = "req " + "types." + strings.Title(route.RequestType.Name)
 		}
 
-		if len(route.ResponseType.Name) > 0 {
+		if len(route.Responses) > 0 {
+			// the handler writes the response chosen by the logic with its status
+			responseString, returnString = servergen.GetResultReturn(route)
+		} else if len(route.ResponseType.Name) > 0 {
 			resp := strings.Title(route.ResponseType.Name)
 			responseString = "(*types." + resp + ", error)"
 			returnString = fmt.Sprintf("return &types.%s{}, nil", resp)
@@ -161,15 +191,16 @@ func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
 
 	t := template.Must(template.New("logicTemplate").Parse(logicTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(fp, map[string]string{
-		"imports":      imports,
-		"logic":        logic,
-		"summary":      summary,
-		"desc":         desc,
-		"function":     strings.Title(strings.TrimSuffix(handler, "Handler")),
-		"responseType": responseString,
-		"returnString": returnString,
-		"request":      requestString,
+	err = t.Execute(fp, map[string]interface{}{
+		"imports":       imports,
+		"logic":         logic,
+		"summary":       summary,
+		"desc":          desc,
+		"function":      strings.Title(strings.TrimSuffix(handler, "Handler")),
+		"responseType":  responseString,
+		"returnString":  returnString,
+		"request":       requestString,
+		"observability": observability,
 	})
 	if err != nil {
 		return err
@@ -180,34 +211,35 @@ func genLogicByRoute(dir string, group spec.Group, route spec.Route) error {
 	return err
 }
 
-func getLogicFolderPath(group spec.Group, route spec.Route) string {
-	folder, ok := util.GetAnnotationValue(route.Annotations, "server", folderProperty)
-	if !ok {
-		folder, ok = util.GetAnnotationValue(group.Annotations, "server", folderProperty)
-		if !ok {
-			return logicDir
-		}
-	}
-	folder = strings.TrimPrefix(folder, "/")
-	folder = strings.TrimSuffix(folder, "/")
-	return path.Join(logicDir, folder)
-}
-
-func genLogicImports(route spec.Route, parentPkg, typ string) string {
+func genLogicImports(route spec.Route, parentPkg, typ, proto string, observability bool) string {
 	var imports []string
 	imports = append(imports, `"context"`)
-	switch typ {
-	case SERVER_TYPE_HTML:
+	switch {
+	case len(proto) > 0:
+		if len(route.ResponseType.Name) > 0 || len(route.RequestType.Name) > 0 {
+			imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, pbDir)))
+		}
+	case len(route.Stream) > 0:
+		imports = append(imports, getTypesImport(parentPkg, route))
+	case route.Binary:
+		imports = append(imports, `"io"`)
+		if len(route.RequestType.Name) > 0 {
+			imports = append(imports, getTypesImport(parentPkg, route))
+		}
+	case typ == SERVER_TYPE_HTML:
 		imports = append(imports, `"net/http"`)
 		if len(route.RequestType.Name) > 0 {
-			imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, typesDir)))
+			imports = append(imports, getTypesImport(parentPkg, route))
 		}
 	default:
-		if len(route.ResponseType.Name) > 0 || len(route.RequestType.Name) > 0 {
-			imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, typesDir)))
+		if len(route.ResponseType.Name) > 0 || len(route.RequestType.Name) > 0 || len(route.Responses) > 0 {
+			imports = append(imports, getTypesImport(parentPkg, route))
 		}
 	}
 	imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, contextDir)))
+	if observability {
+		imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, observabilityDir)))
+	}
 
 	imports = append(imports, fmt.Sprintf("\"%s/go-zero/core/logx\"", vars.ProjectOpenSourceUrl))
 	return strings.Join(imports, "\n\t")
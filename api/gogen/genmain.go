@@ -31,13 +31,17 @@ func main() {
 	ctx := svc.NewServiceContext(c)
 	server := rest.MustNewServer(c.RestConf)
 	defer server.Stop()
+	{{- if .observability}}
+	stopTracing := observability.Start(c.Name, c.Observability)
+	defer stopTracing()
+	{{- end}}
 
 	handler.RegisterHandlers(server, ctx)
 	server.Start()
 }
 `
 
-func genMain(dir string, api *spec.ApiSpec) error {
+func genMain(dir string, observability bool, api *spec.ApiSpec) error {
 	name := strings.ToLower(api.Service.Name)
 	if strings.HasSuffix(name, "-api") {
 		name = strings.ReplaceAll(name, "-api", "")
@@ -59,9 +63,10 @@ func genMain(dir string, api *spec.ApiSpec) error {
 
 	t := template.Must(template.New("mainTemplate").Parse(mainTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(buffer, map[string]string{
-		"importPackages": genMainImports(parentPkg),
+	err = t.Execute(buffer, map[string]interface{}{
+		"importPackages": genMainImports(parentPkg, observability),
 		"serviceName":    api.Service.Name,
+		"observability":  observability,
 	})
 	if err != nil {
 		return nil
@@ -71,10 +76,13 @@ func genMain(dir string, api *spec.ApiSpec) error {
 	return err
 }
 
-func genMainImports(parentPkg string) string {
+func genMainImports(parentPkg string, observability bool) string {
 	var imports []string
 	imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, configDir)))
 	imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, handlerDir)))
+	if observability {
+		imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, observabilityDir)))
+	}
 	imports = append(imports, fmt.Sprintf("\"%s\"\n", ctlutil.JoinPackages(parentPkg, contextDir)))
 	imports = append(imports, fmt.Sprintf("\"%s/go-zero/core/conf\"", vars.ProjectOpenSourceUrl))
 	imports = append(imports, fmt.Sprintf("\"%s/rest\"", vars.ProjectOpenSourceUrl))
@@ -0,0 +1,142 @@
+package gogen
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	middlewareTemplate = `package middleware
+
+import "net/http"
+
+type {{.name}}Middleware struct {
+}
+
+func New{{.name}}Middleware() *{{.name}}Middleware {
+	return &{{.name}}Middleware{}
+}
+
+func (m *{{.name}}Middleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// todo: add your logic here, return without calling next to reject the request
+
+		next(w, r)
+	}
+}
+`
+	// limitTemplate is the timeout and maxBytes of the groups, which run within the Timeout and MaxBytes of the config
+	limitTemplate = `// DO NOT EDIT, generated by goctl
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"{{.rest}}"
+)
+
+// Timeout responds 503 if the handler doesn't finish in d.
+func Timeout(d time.Duration) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable)).ServeHTTP
+	}
+}
+
+// MaxBytes limits the request bodies to n bytes.
+func MaxBytes(n int64) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next(w, r)
+		}
+	}
+}
+`
+)
+
+func genMiddlewares(dir string, api *spec.ApiSpec) error {
+	for _, name := range servergen.GetMiddlewares(api) {
+		fp, created, err := util.MaybeCreateFile(dir, middlewareDir, strings.ToLower(name)+"middleware.go")
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+
+		t := template.Must(template.New("middlewareTemplate").Parse(middlewareTemplate))
+		buffer := new(bytes.Buffer)
+		err = t.Execute(buffer, map[string]string{
+			"name": strcase.ToCamel(name),
+		})
+		if err != nil {
+			fp.Close()
+			return err
+		}
+		_, err = fp.WriteString(formatCode(buffer.String()))
+		fp.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if hasLimit(api) {
+		return genLimitMiddlewares(dir)
+	}
+	return nil
+}
+
+func genLimitMiddlewares(dir string) error {
+	filename := path.Join(dir, middlewareDir, "limit.go")
+	if err := ctlutil.RemoveOrQuit(filename); err != nil {
+		return err
+	}
+	fp, created, err := util.MaybeCreateFile(dir, middlewareDir, "limit.go")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New("limitTemplate").Parse(limitTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"rest": vars.ProjectOpenSourceUrl + "/rest",
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
+
+// hasLimit tells whether a group has a timeout or maxBytes.
+func hasLimit(api *spec.ApiSpec) bool {
+	for _, g := range api.Service.Groups {
+		if g.Timeout > 0 || g.MaxBytes > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTimeout tells whether a group has a timeout.
+func hasTimeout(api *spec.ApiSpec) bool {
+	for _, g := range api.Service.Groups {
+		if g.Timeout > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,383 @@
+package gogen
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/vars"
+)
+
+const (
+	observabilityTemplate = `package observability
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"{{.rest}}/go-zero/core/stringx"
+	"{{.rest}}/go-zero/core/timex"
+)
+
+// RequestIdHeader carries the id of a request, it's generated if the client doesn't send one.
+const RequestIdHeader = "X-Request-ID"
+
+type (
+	Config struct {
+		// the OTLP/HTTP endpoint the spans are exported to, e.g. localhost:4318, the spans aren't exported if empty
+		TracingEndpoint string  ` + "`" + `json:",optional"` + "`" + `
+		TracingSampler  float64 ` + "`" + `json:",default=1"` + "`" + `
+		HealthPath      string  ` + "`" + `json:",default=/healthz"` + "`" + `
+		ReadyPath       string  ` + "`" + `json:",default=/readyz"` + "`" + `
+	}
+
+	requestIdKey struct{}
+
+	// statusWriter records the status written by a handler.
+	statusWriter struct {
+		http.ResponseWriter
+		status int
+	}
+)
+
+// Start starts exporting the spans of the service name, the returned func flushes them on shutdown.
+func Start(name string, c Config) func() {
+	return startTracing(name, c)
+}
+
+// Handler wraps the handler named by the handler annotation of a route, it propagates the request id,
+// traces the request with OpenTelemetry and records its latency and status.
+func Handler(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if len(id) == 0 {
+			id = stringx.RandId()
+		}
+		w.Header().Set(RequestIdHeader, id)
+
+		// Logger writes the request id along with the logs of the request
+		ctx := context.WithValue(r.Context(), requestIdKey{}, id)
+		ctx = ContextWithField(ctx, "request_id", id)
+		ctx, span := startServerSpan(ctx, r, name, id)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		start := timex.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				sw.status = http.StatusInternalServerError
+				observe(name, sw.status, timex.Since(start))
+				endServerSpan(span, sw.status)
+				panic(p)
+			}
+			observe(name, sw.status, timex.Since(start))
+			endServerSpan(span, sw.status)
+		}()
+		next(sw, r.WithContext(ctx))
+	}
+}
+
+// RequestId returns the id of the request handled with ctx, empty if ctx isn't of a request.
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush flushes the events of the streams.
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the websocket streams.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+`
+	metricsTemplate = `package observability
+
+import (
+	"strconv"
+	"time"
+
+	"{{.rest}}/go-zero/core/metric"
+)
+
+// the metrics are exported by the Prometheus agent of go-zero, see the Prometheus config
+var (
+	handlerDuration = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "api",
+		Subsystem: "handler",
+		Name:      "duration_ms",
+		Help:      "the latency of the handlers in milliseconds.",
+		Labels:    []string{"handler"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+	})
+	handlerCode = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "api",
+		Subsystem: "handler",
+		Name:      "code_total",
+		Help:      "the status codes written by the handlers.",
+		Labels:    []string{"handler", "code"},
+	})
+)
+
+func observe(handler string, status int, duration time.Duration) {
+	handlerDuration.Observe(durationMs(duration), handler)
+	handlerCode.Inc(handler, strconv.Itoa(status))
+}
+
+func durationMs(duration time.Duration) int64 {
+	return int64(duration / time.Millisecond)
+}
+`
+	tracingTemplate = `package observability
+
+import (
+	"context"
+	"net/http"
+
+	"{{.rest}}/go-zero/core/logx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the spans are dropped until Start sets the provider exporting them
+var tracer = otel.Tracer("{{.pkg}}")
+
+func startTracing(name string, c Config) func() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if len(c.TracingEndpoint) == 0 {
+		return func() {}
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpoint(c.TracingEndpoint), otlptracehttp.WithInsecure())
+	if err != nil {
+		logx.Error(err)
+		return func() {}
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.TracingSampler))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", name))),
+	)
+	otel.SetTracerProvider(provider)
+	return func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logx.Error(err)
+		}
+	}
+}
+
+// StartSpan starts a child span of ctx, e.g. around a logic call, it's ended by EndSpan.
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// EndSpan ends the span, which fails with err if it isn't nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startServerSpan starts the span of a request, which continues the trace of the traceparent header if any.
+func startServerSpan(ctx context.Context, r *http.Request, name, requestId string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("http.target", r.URL.Path),
+		attribute.String("request.id", requestId),
+	))
+}
+
+func endServerSpan(span trace.Span, status int) {
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+`
+	loggerTemplate = `package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"{{.rest}}/go-zero/core/logx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	fieldsKey struct{}
+
+	// fieldLogger writes the fields of a context before the content of the logs.
+	fieldLogger struct {
+		logger logx.Logger
+		fields string
+	}
+)
+
+// ContextWithField returns a copy of ctx whose Logger writes the field along with the logs, e.g. the id of a user.
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]string)
+	fields = append(fields[:len(fields):len(fields)], fmt.Sprintf("%s=%v", key, value))
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Logger returns the logger of the logic of a request, which writes the fields of ctx and the ids of its
+// OpenTelemetry span before the content of the logs, e.g. request_id=... trace_id=... span_id=... content.
+func Logger(ctx context.Context) logx.Logger {
+	fields, _ := ctx.Value(fieldsKey{}).([]string)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields[:len(fields):len(fields)], "trace_id="+sc.TraceID().String(), "span_id="+sc.SpanID().String())
+	}
+	// the trace of go-zero isn't written, the trace ids are of OpenTelemetry
+	logger := &fieldLogger{logger: logx.WithContext(context.Background())}
+	if len(fields) > 0 {
+		logger.fields = strings.Join(fields, " ") + " "
+	}
+	return logger
+}
+
+func (l *fieldLogger) Error(v ...interface{}) {
+	l.logger.Error(l.fields + fmt.Sprint(v...))
+}
+
+func (l *fieldLogger) Errorf(format string, v ...interface{}) {
+	l.logger.Error(l.fields + fmt.Sprintf(format, v...))
+}
+
+func (l *fieldLogger) Info(v ...interface{}) {
+	l.logger.Info(l.fields + fmt.Sprint(v...))
+}
+
+func (l *fieldLogger) Infof(format string, v ...interface{}) {
+	l.logger.Info(l.fields + fmt.Sprintf(format, v...))
+}
+
+func (l *fieldLogger) Slow(v ...interface{}) {
+	l.logger.Slow(l.fields + fmt.Sprint(v...))
+}
+
+func (l *fieldLogger) Slowf(format string, v ...interface{}) {
+	l.logger.Slow(l.fields + fmt.Sprintf(format, v...))
+}
+
+func (l *fieldLogger) WithDuration(duration time.Duration) logx.Logger {
+	return &fieldLogger{logger: l.logger.WithDuration(duration), fields: l.fields}
+}
+`
+	healthTemplate = `package observability
+
+import (
+	"context"
+	"net/http"
+
+	"{{.rest}}/rest"
+	"{{.rest}}/rest/httpx"
+)
+
+// RegisterHealth registers the liveness and readiness endpoints, the service is ready if ready returns nil.
+func RegisterHealth(engine *rest.Server, c Config, ready func(ctx context.Context) error) {
+	engine.AddRoutes([]rest.Route{
+		{
+			Method: http.MethodGet,
+			Path:   c.HealthPath,
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				httpx.OkJson(w, map[string]string{"status": "ok"})
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   c.ReadyPath,
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if err := ready(r.Context()); err != nil {
+					httpx.WriteJson(w, http.StatusServiceUnavailable, map[string]string{
+						"status": "unavailable",
+						"error":  err.Error(),
+					})
+					return
+				}
+				httpx.OkJson(w, map[string]string{"status": "ok"})
+			},
+		},
+	})
+}
+`
+)
+
+// genObservability generates the metrics, tracing, logger and health endpoints wrapping the handlers with -observability,
+// the files are generated once to be customized, e.g. to export the spans elsewhere.
+func genObservability(dir string, observability bool) error {
+	if !observability {
+		return nil
+	}
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range []struct {
+		file, text string
+	}{
+		{"observability.go", observabilityTemplate},
+		{"metrics.go", metricsTemplate},
+		{"tracing.go", tracingTemplate},
+		{"logger.go", loggerTemplate},
+		{"health.go", healthTemplate},
+	} {
+		if err := genObservabilityFile(dir, parentPkg, item.file, item.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genObservabilityFile(dir, parentPkg, file, text string) error {
+	fp, created, err := util.MaybeCreateFile(dir, observabilityDir, file)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New(file).Parse(text))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"pkg":  parentPkg,
+		"rest": vars.ProjectOpenSourceUrl,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
@@ -0,0 +1,45 @@
+package gogen
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func genObservabilityModule(t *testing.T) string {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.16\n"), 0644))
+	assert.Nil(t, genObservability(dir, true))
+	return dir
+}
+
+func TestObservabilityTracing(t *testing.T) {
+	dir := genObservabilityModule(t)
+	b, err := ioutil.ReadFile(filepath.Join(dir, observabilityDir, "observability.go"))
+	assert.Nil(t, err)
+	// the requests are traced by OpenTelemetry only, the request id is a field of the logs
+	assert.NotContains(t, string(b), "go-zero/core/trace")
+	assert.NotContains(t, string(b), "X-Trace-ID")
+	assert.Contains(t, string(b), `ctx = ContextWithField(ctx, "request_id", id)`)
+}
+
+// TestObservabilityBuild compiles the generated package, it's skipped if its dependencies can't be downloaded.
+func TestObservabilityBuild(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := genObservabilityModule(t)
+	tidy := exec.Command("go", "mod", "tidy")
+	tidy.Dir = dir
+	if out, err := tidy.CombinedOutput(); err != nil {
+		t.Skipf("the dependencies aren't available: %s", out)
+	}
+
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+}
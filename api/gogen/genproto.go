@@ -1,10 +1,107 @@
 package gogen
 
 import (
+	"bytes"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"text/template"
+
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
+)
+
+const (
+	codecFile     = "codec.go"
+	codecTemplate = `package codec
+
+import (
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"{{.rest}}/httpx"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	JsonContentType     = "application/json"
+	ProtobufContentType = "application/x-protobuf"
+)
+
+var (
+	jsonMarshal   = protojson.MarshalOptions{EmitUnpopulated: true}
+	jsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// IsProtobuf tells whether the media type is protobuf, application/protobuf is accepted as well.
+func IsProtobuf(mediaType string) bool {
+	t, _, _ := mime.ParseMediaType(mediaType)
+	return t == ProtobufContentType || t == "application/protobuf"
+}
+
+// ParseParams parses the path, form and header parameters into v, the body is left to Read.
+func ParseParams(r *http.Request, v interface{}) error {
+	r2 := r.Clone(r.Context())
+	r2.Body = http.NoBody
+	r2.ContentLength = 0
+	return httpx.Parse(r2, v)
+}
+
+// Read decodes the body into m as protobuf or json according to the Content-Type header, an empty body is allowed.
+func Read(r *http.Request, m proto.Message) error {
+	b, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return e
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if IsProtobuf(r.Header.Get("Content-Type")) {
+		return proto.Unmarshal(b, m)
+	}
+	return jsonUnmarshal.Unmarshal(b, m)
+}
+
+// Write encodes m as protobuf if the Accept header asks for it, or if there is no Accept header and the request was protobuf.
+// Otherwise m is written as json.
+func Write(w http.ResponseWriter, r *http.Request, m proto.Message) {
+	contentType := JsonContentType
+	marshal := jsonMarshal.Marshal
+	if acceptsProtobuf(r) {
+		contentType = ProtobufContentType
+		marshal = proto.Marshal
+	}
+	b, e := marshal(m)
+	if e != nil {
+		httpx.Error(w, e)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+func acceptsProtobuf(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if len(accept) == 0 {
+		return IsProtobuf(r.Header.Get("Content-Type"))
+	}
+	for _, item := range strings.Split(accept, ",") {
+		if IsProtobuf(strings.TrimSpace(item)) {
+			return true
+		}
+	}
+	return false
+}
+`
 )
 
 func genProto(dir, proto string) error {
@@ -14,18 +111,52 @@ func genProto(dir, proto string) error {
 		return e
 	}
 
-	dst := filepath.Join(dir, "internal", "pb")
+	dst := filepath.Join(dir, pbDir)
 	e = os.MkdirAll(dst, 0755)
 	if e != nil {
 		log.Println(e)
 		return e
 	}
+	pkg, e := getParentPackage(dir)
+	if e != nil {
+		log.Println(e)
+		return e
+	}
 
-	e = exec.Command("protoc", "--go_out="+dst, proto).Run()
+	// the proto file doesn't need a go_package option, the pb package of the project is used
+	out, e := exec.Command("protoc",
+		"-I", filepath.Dir(proto),
+		"--go_out="+dst,
+		"--go_opt=paths=source_relative",
+		fmt.Sprintf("--go_opt=M%s=%s;pb", filepath.Base(proto), util.JoinPackages(pkg, pbDir)),
+		proto).CombinedOutput()
 	if e != nil {
+		e = fmt.Errorf("protoc: %s %s", e.Error(), strings.TrimSpace(string(out)))
 		log.Println(e)
 		return e
 	}
 
-	return nil
+	return genCodec(dir)
+}
+
+func genCodec(dir string) error {
+	fp, created, err := apiutil.MaybeCreateFile(dir, codecDir, codecFile)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New("codecTemplate").Parse(codecTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"rest": vars.ProjectOpenSourceUrl + "/rest",
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
 }
@@ -0,0 +1,89 @@
+package gogen
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+const resultApi = `type getRequest struct {
+	name string ` + "`path:\"name\"`" + `
+}
+
+type user struct {
+	name string ` + "`json:\"name\"`" + `
+}
+
+type notFound struct {
+	reason string ` + "`json:\"reason\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:name(getRequest) returns(200: user, 201: user, 202, 404: notFound)
+}
+`
+
+func parseResultApi(t *testing.T) *spec.ApiSpec {
+	p, err := parser.NewParserFromStr(resultApi)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	return api
+}
+
+func TestResultHandler(t *testing.T) {
+	api := parseResultApi(t)
+	dir := t.TempDir()
+	assert.Nil(t, genHandler(dir, false, api.Service.Groups[0], api.Service.Routes[0]))
+	b, err := ioutil.ReadFile(filepath.Join(dir, handlerDir, "getuserhandler.go"))
+	assert.Nil(t, err)
+	// a logic returning (nil, nil) doesn't panic, and a nil *User isn't written as null
+	assert.Contains(t, string(b), "if resp == nil {")
+	assert.Contains(t, string(b), `http.Error(w, "no response", http.StatusInternalServerError)`)
+	assert.Contains(t, string(b), "} else if !resp.HasBody() {")
+}
+
+func TestResultHasBody(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/user\n\ngo 1.16\n"), 0644))
+	assert.Nil(t, genTypes(dir, parseResultApi(t)))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, typesDir, "result_test.go"), []byte(`package types
+
+import "testing"
+
+func TestHasBody(t *testing.T) {
+	for _, c := range []struct {
+		result *GetUserResult
+		want   bool
+	}{
+		{GetUserOK(&User{}), true},
+		{GetUserOK(nil), false},
+		{GetUserNotFound(nil), false},
+		{GetUserAccepted(), false},
+		{&GetUserResult{Status: 200}, false},
+	} {
+		if got := c.result.HasBody(); got != c.want {
+			t.Errorf("%d %v: got %v, want %v", c.result.Status, c.result.Body, got, c.want)
+		}
+	}
+}
+`), 0644))
+
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+}
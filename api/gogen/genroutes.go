@@ -9,10 +9,12 @@ import (
 	"text/template"
 
 	"github.com/gofaith/go-zero/core/collection"
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	apiutil "github.com/gofaith/goctlr/api/util"
 	"github.com/gofaith/goctlr/util"
 	"github.com/gofaith/goctlr/vars"
+	"github.com/iancoleman/strcase"
 )
 
 const (
@@ -21,19 +23,55 @@ const (
 package handler
 
 import (
-	"net/http"
+	"net/http"{{if .time}}
+	"time"{{end}}
 
 	{{.importPackages}}
 )
 
 func RegisterHandlers(engine *rest.Server, serverCtx *svc.ServiceContext) {
+	{{- if .errorx}}
+	httpx.SetErrorHandler(errorx.Handle)
+	{{end}}
 	{{.routesAdditions}}
+	{{- if .observability}}
+
+	observability.RegisterHealth(engine, serverCtx.Config.Observability, serverCtx.Ready)
+	{{- end}}
+}
+{{- if .deprecated}}
+
+// deprecated sets the Deprecation, Sunset and Link headers of the responses of a deprecated route, the empty ones aren't set.
+func deprecated(next http.HandlerFunc, deprecation, sunset, link string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		if len(sunset) > 0 {
+			w.Header().Set("Sunset", sunset)
+		}
+		if len(link) > 0 {
+			w.Header().Add("Link", link)
+		}
+		next(w, r)
+	}
 }
+{{- end}}
 `
 	routesAdditionTemplate = `
-	engine.AddRoutes([]rest.Route{
-		{{.routes}}
-	}{{.jwt}}{{.signature}})
+	engine.AddRoutes(
+		{{- if .middlewares}}
+		rest.WithMiddlewares(
+			[]rest.Middleware{ {{.middlewares}} },
+			[]rest.Route{
+				{{.routes}}
+			}...,
+		),
+		{{- else}}
+		[]rest.Route{
+			{{.routes}}
+		},
+		{{- end}}
+		{{- .jwt}}{{.signature}}
+	)
 `
 )
 
@@ -53,25 +91,40 @@ type (
 		jwtEnabled       bool
 		signatureEnabled bool
 		authName         string
+		signatureName    string
+		// the limits of the group followed by the middlewares of the group and its routes,
+		// e.g. middleware.Timeout(30 * time.Second), serverCtx.Auth
+		middlewares []string
 	}
 	route struct {
 		method  string
 		path    string
 		handler string
+		// the value of the handler annotation, which labels the metrics of the route along with its version
+		name        string
+		deprecation *spec.Deprecation
 	}
 )
 
-func genRoutes(dir string, api *spec.ApiSpec) error {
+func genRoutes(dir string, observability bool, api *spec.ApiSpec) error {
 	var builder strings.Builder
 	groups, err := getRoutes(api)
 	if err != nil {
 		return err
 	}
 
+	var hasDeprecated bool
 	gt := template.Must(template.New("groupTemplate").Parse(routesAdditionTemplate))
 	for _, g := range groups {
 		var gbuilder strings.Builder
 		for _, r := range g.routes {
+			if d := r.deprecation; d != nil {
+				hasDeprecated = true
+				r.handler = fmt.Sprintf("deprecated(%s, %q, %q, %q)", r.handler, d.DeprecationHeader(), d.SunsetHeader(), d.LinkHeader())
+			}
+			if observability {
+				r.handler = fmt.Sprintf("observability.Handler(%q, %s)", r.name, r.handler)
+			}
 			fmt.Fprintf(&gbuilder, `
 		{
 			Method:  %s,
@@ -82,16 +135,17 @@ func genRoutes(dir string, api *spec.ApiSpec) error {
 		}
 		var jwt string
 		if g.jwtEnabled {
-			jwt = fmt.Sprintf(", rest.WithJwt(serverCtx.Config.%s.AccessSecret)", g.authName)
+			jwt = fmt.Sprintf("\nrest.WithJwt(serverCtx.Config.%s.AccessSecret),", g.authName)
 		}
 		var signature string
 		if g.signatureEnabled {
-			signature = fmt.Sprintf(", rest.WithSignature(serverCtx.Config.%s.Signature)", g.authName)
+			signature = fmt.Sprintf("\nrest.WithSignature(serverCtx.Config.%s.Signature),", g.signatureName)
 		}
 		if err := gt.Execute(&builder, map[string]string{
-			"routes":    strings.TrimSpace(gbuilder.String()),
-			"jwt":       jwt,
-			"signature": signature,
+			"routes":      strings.TrimSpace(gbuilder.String()),
+			"middlewares": strings.Join(g.middlewares, ", "),
+			"jwt":         jwt,
+			"signature":   signature,
 		}); err != nil {
 			return err
 		}
@@ -118,9 +172,13 @@ func genRoutes(dir string, api *spec.ApiSpec) error {
 
 	t := template.Must(template.New("routesTemplate").Parse(routesTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(buffer, map[string]string{
-		"importPackages":  genRouteImports(parentPkg, api),
+	err = t.Execute(buffer, map[string]interface{}{
+		"time":            hasTimeout(api),
+		"errorx":          len(api.Errors) > 0,
+		"importPackages":  genRouteImports(parentPkg, observability, api),
 		"routesAdditions": strings.TrimSpace(builder.String()),
+		"observability":   observability,
+		"deprecated":      hasDeprecated,
 	})
 	if err != nil {
 		return nil
@@ -130,19 +188,25 @@ func genRoutes(dir string, api *spec.ApiSpec) error {
 	return err
 }
 
-func genRouteImports(parentPkg string, api *spec.ApiSpec) string {
+func genRouteImports(parentPkg string, observability bool, api *spec.ApiSpec) string {
 	var importSet = collection.NewSet()
 	importSet.AddStr(fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, contextDir)))
+	if len(api.Errors) > 0 {
+		importSet.AddStr(fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, errorxDir)))
+	}
+	if observability {
+		importSet.AddStr(fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, observabilityDir)))
+	}
+	if hasLimit(api) {
+		importSet.AddStr(fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, middlewareDir)))
+	}
 	for _, group := range api.Service.Groups {
 		for _, route := range group.Routes {
-			folder, ok := apiutil.GetAnnotationValue(route.Annotations, "server", folderProperty)
-			if !ok {
-				folder, ok = apiutil.GetAnnotationValue(group.Annotations, "server", folderProperty)
-				if !ok {
-					continue
-				}
+			folder := apiutil.GetRouteFolder(group, route)
+			if len(folder) == 0 {
+				continue
 			}
-			importSet.AddStr(fmt.Sprintf("%s \"%s\"", folder,
+			importSet.AddStr(fmt.Sprintf("%s \"%s\"", apiutil.GetRoutePackage(group, route),
 				util.JoinPackages(parentPkg, handlerDir, folder)))
 		}
 	}
@@ -150,45 +214,74 @@ func genRouteImports(parentPkg string, api *spec.ApiSpec) string {
 	sort.Strings(imports)
 	projectSection := strings.Join(imports, "\n\t")
 	depSection := fmt.Sprintf("\"%s/rest\"", vars.ProjectOpenSourceUrl)
+	if len(api.Errors) > 0 {
+		depSection += fmt.Sprintf("\n\t\"%s/rest/httpx\"", vars.ProjectOpenSourceUrl)
+	}
 	return fmt.Sprintf("%s\n\n\t%s", projectSection, depSection)
 }
 
+// getRoutes returns the routes to register, the routes of a group are split by their middlewares
+// since rest.WithMiddlewares wraps all the routes it is given.
 func getRoutes(api *spec.ApiSpec) ([]group, error) {
 	var routes []group
 
 	for _, g := range api.Service.Groups {
-		var groupedRoutes group
+		var base group
+		var limits []string
+		if g.Timeout > 0 {
+			limits = append(limits, fmt.Sprintf("middleware.Timeout(%s)", servergen.FormatDuration(g.Timeout)))
+		}
+		if g.MaxBytes > 0 {
+			limits = append(limits, fmt.Sprintf("middleware.MaxBytes(%d)", g.MaxBytes))
+		}
+		for _, annotation := range g.Annotations {
+			for k, v := range annotation.Properties {
+				switch k {
+				case "jwt":
+					base.jwtEnabled = true
+					base.authName = v
+				case "signature":
+					base.signatureEnabled = true
+					base.signatureName = v
+				}
+			}
+		}
+
+		var groups []group
 		for _, r := range g.Routes {
 			handler, ok := apiutil.GetAnnotationValue(r.Annotations, "server", "handler")
 			if !ok {
 				return nil, fmt.Errorf("missing handler annotation for route %q", r.Path)
 			}
-			handler = getHandlerBaseName(handler) + "Handler(serverCtx)"
-			folder, ok := apiutil.GetAnnotationValue(r.Annotations, "server", folderProperty)
-			if ok {
-				handler = folder + "." + strings.ToUpper(handler[:1]) + handler[1:]
-			} else {
-				folder, ok = apiutil.GetAnnotationValue(g.Annotations, "server", folderProperty)
-				if ok {
-					handler = folder + "." + strings.ToUpper(handler[:1]) + handler[1:]
-				}
+			name := path.Join(r.Version, handler)
+			handler = servergen.GetHandlerBaseName(handler) + "Handler(serverCtx)"
+			if len(apiutil.GetRouteFolder(g, r)) > 0 {
+				handler = apiutil.GetRoutePackage(g, r) + "." + strings.ToUpper(handler[:1]) + handler[1:]
+			}
+			middlewares := append([]string{}, limits...)
+			for _, name := range apiutil.GetMiddlewares(g, r) {
+				middlewares = append(middlewares, "serverCtx."+strcase.ToCamel(name))
 			}
-			groupedRoutes.routes = append(groupedRoutes.routes, route{
-				method:  mapping[r.Method],
-				path:    r.Path,
-				handler: handler,
-			})
-		}
 
-		for _, annotation := range g.Annotations {
-			for k, v := range annotation.Properties {
-				if k == "jwt" {
-					groupedRoutes.jwtEnabled = true
-					groupedRoutes.authName = v
-				}
+			i := 0
+			for i < len(groups) && strings.Join(groups[i].middlewares, ",") != strings.Join(middlewares, ",") {
+				i++
+			}
+			if i == len(groups) {
+				item := base
+				item.middlewares = middlewares
+				groups = append(groups, item)
 			}
+			// the path of the route is joined with the prefix of its group already
+			groups[i].routes = append(groups[i].routes, route{
+				method:      mapping[r.Method],
+				path:        r.Path,
+				handler:     handler,
+				name:        name,
+				deprecation: r.Deprecation,
+			})
 		}
-		routes = append(routes, groupedRoutes)
+		routes = append(routes, groups...)
 	}
 
 	return routes, nil
@@ -0,0 +1,52 @@
+package gogen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoutes(t *testing.T) {
+	p, err := parser.NewParserFromStr(`type upload struct {
+	file file ` + "`form:\"file,maxSize=2MB\"`" + `
+}
+
+@server(
+	prefix: /api/v1/admin
+	timeout: 30s
+	maxBytes: 2KB
+	middleware: Cors
+)
+service admin-api {
+	@server(
+		handler: IndexHandler
+	)
+	get /()
+
+	@server(
+		handler: UploadHandler
+		middleware: Trace
+	)
+	post /upload(upload)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	groups, err := getRoutes(api)
+	assert.Nil(t, err)
+	assert.Len(t, groups, 2)
+	// the limits run first, the prefix is a part of the paths
+	assert.Equal(t, []string{"middleware.Timeout(30 * time.Second)", "middleware.MaxBytes(2048)", "serverCtx.Cors"}, groups[0].middlewares)
+	assert.Equal(t, "/api/v1/admin", groups[0].routes[0].path)
+	assert.Equal(t, []string{"middleware.Timeout(30 * time.Second)", "middleware.MaxBytes(2048)", "serverCtx.Cors", "serverCtx.Trace"}, groups[1].middlewares)
+	assert.Equal(t, "/api/v1/admin/upload", groups[1].routes[0].path)
+
+	maxBytes, timeout, err := getConfigLimits(api)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3<<20), maxBytes)
+	assert.Equal(t, 30*time.Second, timeout)
+}
@@ -0,0 +1,279 @@
+package gogen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
+)
+
+const (
+	sseHandlerTemplate = `package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	logic "{{.logicPkg}}"
+	"{{.pkg}}/internal/svc"
+	{{.typesImport}}{{if .request}}
+
+	"{{.rest}}/httpx"{{end}}
+)
+
+func {{.handler}}(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		{{- if .request}}
+		var req types.{{.request}}
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+{{end}}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// the logic sends the events through the channel, the request context is done once the client goes away
+		events := make(chan *types.{{.event}})
+		done := make(chan error, 1)
+		go func() {
+			l := logic.New{{.name}}Logic(r.Context(), ctx)
+			done <- l.{{.name}}({{if .request}}req, {{end}}func(event *types.{{.event}}) error {
+				select {
+				case events <- event:
+					return nil
+				case <-r.Context().Done():
+					return r.Context().Err()
+				}
+			})
+		}()
+
+		for {
+			select {
+			case event := <-events:
+				b, err := json.Marshal(event)
+				if err != nil {
+					writeFail(w, err)
+					flusher.Flush()
+					return
+				}
+				fmt.Fprintf(w, "data: %s\n\n", b)
+				flusher.Flush()
+			case err := <-done:
+				if err != nil {
+					writeFail(w, err)
+					flusher.Flush()
+				}
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+`
+	// the error event can't be named error, EventSource fires error for the connection errors
+	sseFailTemplate = `package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// writeFail ends an event stream with a fail event carrying the error message.
+func writeFail(w io.Writer, err error) {
+	b, _ := json.Marshal(err.Error())
+	fmt.Fprintf(w, "event: fail\ndata: %s\n\n", b)
+}
+`
+	wsHandlerTemplate = `package handler
+
+import (
+	"context"
+	"net/http"
+
+	logic "{{.logicPkg}}"
+	"{{.pkg}}/internal/svc"
+	{{.typesImport}}
+{{if .request}}
+	"{{.rest}}/httpx"{{end}}
+	"github.com/gorilla/websocket"
+)
+
+func {{.handler}}(ctx *svc.ServiceContext) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		// TODO: check the origin if the service is not meant to be called by pages of other sites
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		{{- if .request}}
+		var req types.{{.request}}
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+{{end}}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// the upgrader has replied with an http error
+			return
+		}
+		defer conn.Close()
+
+		// c is done once the client closes the connection
+		c, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		{{- if .request}}
+		messages := make(chan *types.{{.request}})
+		go func() {
+			defer cancel()
+			for {
+				var message types.{{.request}}
+				if err := conn.ReadJSON(&message); err != nil {
+					return
+				}
+				select {
+				case messages <- &message:
+				case <-c.Done():
+					return
+				}
+			}
+		}()
+		{{- else}}
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		{{- end}}
+
+		events := make(chan *types.{{.event}})
+		done := make(chan error, 1)
+		go func() {
+			l := logic.New{{.name}}Logic(c, ctx)
+			done <- l.{{.name}}({{if .request}}req, {{end}}func(event *types.{{.event}}) error {
+				select {
+				case events <- event:
+					return nil
+				case <-c.Done():
+					return c.Err()
+				}
+			}{{if .request}}, func() (*types.{{.request}}, error) {
+				select {
+				case message := <-messages:
+					return message, nil
+				case <-c.Done():
+					return nil, c.Err()
+				}
+			}{{end}})
+		}()
+
+		for {
+			select {
+			case event := <-events:
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			case err := <-done:
+				code, text := websocket.CloseNormalClosure, ""
+				if err != nil {
+					code, text = websocket.CloseInternalServerErr, err.Error()
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+				return
+			case <-c.Done():
+				return
+			}
+		}
+	}
+}
+`
+)
+
+// genStreamHandler generates the handler of a server-sent events or websocket route,
+// the logic pushes the events of the response type through a send function.
+func genStreamHandler(dir string, group spec.Group, route spec.Route) error {
+	handler, ok := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		return fmt.Errorf("missing handler annotation for %q", route.Path)
+	}
+	handler = getHandlerName(handler)
+	if servergen.GetHandlerFolderPath(group, route) != handlerDir {
+		handler = strings.Title(handler)
+	}
+	pkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	text := sseHandlerTemplate
+	if route.Stream == spec.StreamWS {
+		text = wsHandlerTemplate
+	} else if err := genHandlerHelper(dir, group, route, "ssefail.go", sseFailTemplate); err != nil {
+		return err
+	}
+
+	fp, created, err := apiutil.MaybeCreateFile(dir, servergen.GetHandlerFolderPath(group, route), strings.ToLower(handler)+".go")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	t := template.Must(template.New("streamHandlerTemplate").Parse(text))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"pkg":         pkg,
+		"typesImport": getTypesImport(pkg, route),
+		"logicPkg":    util.JoinPackages(pkg, servergen.GetLogicFolderPath(group, route)),
+		"rest":        vars.ProjectOpenSourceUrl + "/rest",
+		"handler":     handler,
+		"name":        strings.Title(servergen.GetHandlerBaseName(handler)),
+		"request":     util.Title(route.RequestType.Name),
+		"event":       util.Title(route.ResponseType.Name),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
+
+// getStreamLogicSignature returns the parameters of the logic method of a streaming route.
+func getStreamLogicSignature(route spec.Route) string {
+	event := "types." + strings.Title(route.ResponseType.Name)
+	var params []string
+	if len(route.RequestType.Name) > 0 {
+		params = append(params, "req types."+strings.Title(route.RequestType.Name))
+	}
+	params = append(params, "send func(*"+event+") error")
+	if route.Stream == spec.StreamWS && len(route.RequestType.Name) > 0 {
+		// the client sends the messages of the request type
+		params = append(params, "receive func() (*types."+strings.Title(route.RequestType.Name)+", error)")
+	}
+	return strings.Join(params, ", ")
+}
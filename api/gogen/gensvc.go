@@ -2,31 +2,57 @@ package gogen
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
 	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
+	"github.com/iancoleman/strcase"
 )
 
 const (
 	contextFilename = "servicecontext.go"
 	contextTemplate = `package svc
 
-import {{.configImport}}
+import (
+	{{- if .observability}}
+	"context"
+{{end}}
+	{{.configImport}}{{if .middlewares}}
+	{{.middlewareImport}}
+
+	"{{.rest}}/rest"{{end}}
+)
 
 type ServiceContext struct {
 	Config {{.config}}
+	{{- range .middlewares}}
+	{{.}} rest.Middleware
+	{{- end}}
 }
 
 func NewServiceContext(c {{.config}}) *ServiceContext {
-	return &ServiceContext{Config: c}
+	return &ServiceContext{
+		Config: c,
+		{{- range .middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+		{{- end}}
+	}
+}
+{{- if .observability}}
+
+// Ready reports whether the service can handle the requests, e.g. its databases are reachable, it backs the ReadyPath of Observability.
+func (s *ServiceContext) Ready(ctx context.Context) error {
+	return nil
 }
+{{- end}}
 `
 )
 
-func genServiceContext(dir string, api *spec.ApiSpec) error {
+func genServiceContext(dir string, observability bool, api *spec.ApiSpec) error {
 	fp, created, err := util.MaybeCreateFile(dir, contextDir, contextFilename)
 	if err != nil {
 		return err
@@ -36,22 +62,30 @@ func genServiceContext(dir string, api *spec.ApiSpec) error {
 	}
 	defer fp.Close()
 
-	var authNames = getAuths(api)
-	var auths []string
-	for _, item := range authNames {
-		auths = append(auths, fmt.Sprintf("%s config.AuthConfig", item))
-	}
-
 	parentPkg, err := getParentPackage(dir)
 	if err != nil {
 		return err
 	}
+	var middlewares []string
+	for _, name := range servergen.GetMiddlewares(api) {
+		name = strcase.ToCamel(name)
+		if name == "Config" {
+			return errors.New("the middleware Config conflicts with the Config of ServiceContext")
+		}
+		middlewares = append(middlewares, name)
+	}
+
 	var configImport = "\"" + ctlutil.JoinPackages(parentPkg, configDir) + "\""
+	var middlewareImport = "\"" + ctlutil.JoinPackages(parentPkg, middlewareDir) + "\""
 	t := template.Must(template.New("contextTemplate").Parse(contextTemplate))
 	buffer := new(bytes.Buffer)
-	err = t.Execute(buffer, map[string]string{
-		"configImport": configImport,
-		"config":       "config.Config",
+	err = t.Execute(buffer, map[string]interface{}{
+		"configImport":     configImport,
+		"middlewareImport": middlewareImport,
+		"rest":             vars.ProjectOpenSourceUrl,
+		"config":           "config.Config",
+		"middlewares":      middlewares,
+		"observability":    observability,
 	})
 	if err != nil {
 		return nil
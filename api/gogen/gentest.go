@@ -1,128 +1,429 @@
 package gogen
 
 import (
+	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/protogen"
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/gofaith/goctlr/vars"
 	"github.com/iancoleman/strcase"
 )
 
 const (
-	testTemplate = `package test
+	testDir            = "test"
+	testServerFile     = "server_test.go"
+	testServerTemplate = `package test
 
 import (
-	"{{.baseDir}}/client"
-	{{if ne .requestType ""}}"{{.baseDir}}/internal/types"
-	{{end}}"testing"
+	{{- if .jwt}}
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	{{- end}}
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"{{.pkg}}/client"
+	"{{.pkg}}/internal/config"
+	"{{.pkg}}/internal/handler"
+	"{{.pkg}}/internal/svc"
+
+	"{{.core}}/service"
+	"{{.rest}}"
+	"{{.rest}}/httpx"
+	"{{.rest}}/router"
 )
 
-func {{camelCase .funcName}}(t *testing.T) {
-	cli := client.NewClient()
-	if !cli.Ping() {
-		return
-	}{{if ne .requestType ""}}
-	
-	req := types.{{.requestType}}{}{{end}}
-	{{if ne .responseType ""}}_, {{end}}e := cli.{{.apiFuncName}}({{if ne .requestType ""}}req{{end}})
-	if e != nil {
-		t.Error(e)
-		return
+{{- if .jwt}}
+
+// testSecret signs the jwt tokens of the test client
+const testSecret = "test-secret"
+{{- end}}
+
+// testConfig is the config of the servers of the tests.
+func testConfig() config.Config {
+	var c config.Config
+	c.Name = "{{.name}}-test"
+	c.Mode = service.TestMode
+	c.Log.Mode = "console"
+	{{- range .jwt}}
+	c.{{.}}.AccessSecret = testSecret
+	c.{{.}}.AccessExpire = 3600
+	{{- end}}
+	{{- if .observability}}
+	// the defaults of the config file, which the tests don't load
+	c.Observability.HealthPath = "/healthz"
+	c.Observability.ReadyPath = "/readyz"
+	{{- end}}
+	return c
+}
+
+// newClient serves the routes of the server by an in-process httptest.Server, and returns a client calling it{{if .jwt}} with a jwt token{{end}}.
+func newClient(t *testing.T) *client.Client {
+	c := testConfig()
+	c.Host = "127.0.0.1"
+	c.Port = freePort(t)
+	rt := &testRouter{Router: router.NewRouter()}
+	server := rest.MustNewServer(c.RestConf, rest.WithRouter(rt))
+	handler.RegisterHandlers(server, svc.NewServiceContext(c))
+	// Start binds the routes to rt before it listens on the port, which the tests don't call
+	go server.Start()
+	waitListening(t, c.Host, c.Port)
+
+	ts := httptest.NewServer(rt)
+	t.Cleanup(ts.Close)
+	return client.NewClient(ts.URL{{if .jwt}}, client.WithHeader("Authorization", "Bearer "+token(testSecret)){{end}})
+}
+
+// testRouter is the router of the server shared with httptest, the routes are bound by server.Start in its goroutine.
+type testRouter struct {
+	sync.RWMutex
+	httpx.Router
+}
+
+func (r *testRouter) Handle(method, path string, handler http.Handler) error {
+	r.Lock()
+	defer r.Unlock()
+	return r.Router.Handle(method, path, handler)
+}
+
+func (r *testRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.RLock()
+	defer r.RUnlock()
+	r.Router.ServeHTTP(w, req)
+}
+
+// freePort returns a free port of the loopback.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
 	}
-	// TODO test code
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
 }
+
+// waitListening waits for the server to listen on the port, its routes are bound by then.
+func waitListening(t *testing.T, host string, port int) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	t.Fatalf("the server doesn't listen on %s", addr)
+}
+{{- if .jwt}}
+
+// token signs a HS256 jwt token expiring in an hour by secret.
+func token(secret string) string {
+	now := time.Now()
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString([]byte(` + "`" + `{"alg":"HS256","typ":"JWT"}` + "`" + `)) + "." +
+		encoding.EncodeToString([]byte(fmt.Sprintf(` + "`" + `{"iat":%d,"exp":%d}` + "`" + `, now.Unix(), now.Add(time.Hour).Unix())))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encoding.EncodeToString(mac.Sum(nil))
+}
+{{- end}}
 `
-	test_testTemplate = `package test
+	testTemplate = `package test
 
 import (
+	"context"
+	{{- if not .result}}
+	"net/http"
+	{{- end}}
 	"testing"
+
+	"{{.pkg}}/client"{{if .typesPkg}}
+	{{.typesPkg}}{{end}}
 )
-{{range .}}
-func Test_{{.funcName}}(t *testing.T) {
-	{{camelCase .funcName}}(t)
-}{{end}}
+
+func Test{{.function}}(t *testing.T) {
+	cli := newClient(t)
+	for _, c := range []struct {
+		name string
+		{{- if .request}}
+		req {{.request}}
+		{{- end}}
+		status int
+		{{- if .response}}
+		// check asserts the response decoded from a {{.statusText}}
+		check func(t *testing.T, res {{.response}})
+		{{- end}}
+	}{
+		{
+			name: "ok",
+			{{- if .request}}
+			req: {{.example}},
+			{{- end}}
+			status: {{.status}},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			{{if .response}}res, {{end}}e := cli.{{.function}}(context.Background(){{if .request}}, c.req{{end}})
+			{{- if .result}}
+			status := client.StatusCode(e)
+			if e == nil {
+				status = res.Status
+			}
+			if status != c.status {
+			{{- else}}
+			if status := client.StatusCode(e); status != c.status {
+			{{- end}}
+				t.Fatalf("got status %d, want %d: %v", status, c.status, e)
+			}
+			{{- if .response}}
+			if e == nil && c.check != nil {
+				c.check(t, res)
+			}
+			{{- end}}
+		})
+	}
+}
 `
 )
 
-func genTest(dir string, api *spec.ApiSpec) error {
-	dir, e := filepath.Abs(dir)
+var (
+	goIdentifier = regexp.MustCompile(`[A-Za-z_]\w*`)
+	// the first of the options of a member, e.g. male of options=male|female
+	optionsRe = regexp.MustCompile(`options=([^|",\s]+)`)
+)
+
+// genTest generates the tests calling every route by the client, which are served in-process without the network.
+// The tests are generated once, the cases of a route can be added to its table.
+func genTest(dir, proto string, observability bool, api *spec.ApiSpec) error {
+	pkg, e := getParentPackage(dir)
 	if e != nil {
 		return e
 	}
-
-	testDir := filepath.Join(dir, "test")
-	e = os.MkdirAll(testDir, 0755)
-	if e != nil {
+	if e := genTestServer(dir, pkg, observability, api); e != nil {
 		return e
 	}
 
-	baseDir := getImport(dir)
-
-	testFiles := make(map[string][]map[string]interface{})
-	// range routes
-	for _, route := range api.Service.Routes {
-		handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
-		if !ok {
-			return fmt.Errorf("missing handler annotation for %q", route.Path)
-		}
-		group, ok := util.GetAnnotationValue(route.Annotations, "server", "folder")
-
-		// basic file
-		filename := strings.ToLower(getHandlerBaseName(handler)) + ".go"
-		filePath := filepath.Join(testDir, filename)
-		if ok {
-			os.MkdirAll(filepath.Join(testDir, group), 0755)
-			filePath = filepath.Join(testDir, group, filename)
+	for _, group := range api.Service.Groups {
+		// the client doesn't sign the requests
+		if _, ok := util.GetAnnotationValue(group.Annotations, "server", "signature"); ok {
+			continue
 		}
+		for _, route := range group.Routes {
+			handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
+			if !ok {
+				return fmt.Errorf("missing handler annotation for %q", route.Path)
+			}
+			// the client doesn't call the streams and the file routes, and the html pages aren't json
+			typ, _ := util.GetAnnotationValue(route.Annotations, "server", "type")
+			if len(route.Stream) > 0 || util.IsFileRoute(api, route) || typ == SERVER_TYPE_HTML {
+				continue
+			}
 
-		_, e = os.Stat(filePath)
-		if os.IsNotExist(e) {
-			file, e := os.OpenFile(filePath, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0644)
-			if e != nil {
+			name := strings.ToLower(servergen.GetHandlerBaseName(handler))
+			if folder := util.GetRouteFolder(group, route); len(folder) > 0 {
+				name = strings.ReplaceAll(folder, "/", "_") + "_" + name
+			}
+			if e := genRouteTest(dir, pkg, name+"_test.go", proto, api, route); e != nil {
 				return e
 			}
-			defer file.Close()
+		}
+	}
+	return nil
+}
 
-			t, e := template.New(filename).Funcs(util.FuncsMap).Parse(testTemplate)
-			if e != nil {
-				return e
+func genTestServer(dir, pkg string, observability bool, api *spec.ApiSpec) error {
+	fp, created, err := util.MaybeCreateFile(dir, testDir, testServerFile)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	var jwt []string
+	for _, auth := range getAuths(api) {
+		if auth.Jwt {
+			jwt = append(jwt, auth.Name)
+		}
+	}
+	t := template.Must(template.New(testServerFile).Parse(testServerTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]interface{}{
+		"pkg":           pkg,
+		"core":          vars.ProjectOpenSourceUrl + "/go-zero/core",
+		"rest":          vars.ProjectOpenSourceUrl + "/rest",
+		"name":          api.Service.Name,
+		"jwt":           jwt,
+		"observability": observability,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
+
+func genRouteTest(dir, pkg, file, proto string, api *spec.ApiSpec, route spec.Route) error {
+	fp, created, err := util.MaybeCreateFile(dir, testDir, file)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	var request, response, example, typesPkg string
+	status, code := "http.StatusOK", http.StatusOK
+	if len(proto) > 0 {
+		// the fields of the messages are optional, but the path variables can't be empty
+		if len(route.RequestType.Name) > 0 {
+			request = "*pb." + protogen.MessageName(route.RequestType.Name)
+			var fields string
+			for _, member := range util.GetRequestMembers(api, route).Path {
+				if strings.TrimPrefix(member.Type, "*") == "string" {
+					fields += "\n" + protogen.GoFieldName(member) + ": " + strconv.Quote("string") + ","
+				}
 			}
-			e = t.Execute(file, map[string]interface{}{
-				"baseDir":      baseDir,
-				"funcName":     getHandlerBaseName(handler),
-				"requestType":  route.RequestType.Name,
-				"apiFuncName":  strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)),
-				"responseType": route.ResponseType.Name,
-			})
-			if e != nil {
-				return e
+			if len(fields) > 0 {
+				fields += "\n"
+			}
+			example = "&pb." + protogen.MessageName(route.RequestType.Name) + "{" + fields + "}"
+		}
+		if len(route.ResponseType.Name) > 0 {
+			response = "*pb." + protogen.MessageName(route.ResponseType.Name)
+		}
+		typesPkg = fmt.Sprintf("%q", ctlutil.JoinPackages(pkg, pbDir))
+	} else {
+		if len(route.RequestType.Name) > 0 {
+			request = goTestType(api, route.RequestType.Name)
+			example = goExample(api, route.RequestType.Name, map[string]bool{})
+		}
+		if len(route.Responses) > 0 {
+			// the logic returns the first 2xx response
+			response = "*client." + strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)) + "Result"
+			for _, r := range route.Responses {
+				if r.Status/100 == 2 {
+					status, code = strconv.Itoa(r.Status), r.Status
+					break
+				}
 			}
+		} else if len(route.ResponseType.Name) > 0 {
+			response = "*" + goTestType(api, route.ResponseType.Name)
 		}
+		typesPkg = getTypesImport(pkg, route)
+	}
+	if len(request) == 0 && (len(response) == 0 || strings.HasPrefix(response, "*client.")) {
+		typesPkg = ""
+	}
 
-		// test file
-		testFiles[group] = append(testFiles[group], map[string]interface{}{
-			"funcName": getHandlerBaseName(handler),
-		})
+	t := template.Must(template.New(file).Parse(testTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]interface{}{
+		"pkg":        pkg,
+		"typesPkg":   typesPkg,
+		"function":   strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)),
+		"request":    request,
+		"response":   response,
+		"result":     len(route.Responses) > 0 && len(proto) == 0,
+		"status":     status,
+		"statusText": fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		"example":    example,
+	})
+	if err != nil {
+		return err
 	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
 
-	for group, vs := range testFiles {
-		file, e := os.OpenFile(filepath.Join(testDir, group, group+"_test.go"), os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
-		if e != nil {
-			return e
+// goTestType returns the go type t outside of the package types, e.g. []types.User for []User.
+func goTestType(api *spec.ApiSpec, t string) string {
+	return goIdentifier.ReplaceAllStringFunc(t, func(name string) string {
+		for _, ty := range api.Types {
+			if ty.Name == name {
+				return typesPacket + "." + ctlutil.Title(name)
+			}
 		}
-		defer file.Close()
-		t, e := template.New(group + "_test.go").Funcs(util.FuncsMap).Parse(test_testTemplate)
-		if e != nil {
-			return e
+		return name
+	})
+}
+
+// goExample returns a go expression of an example of the type t, e.g. types.User{Name: "string"}.
+// The slices and the maps are empty instead of nil, which would be sent as null and rejected unless optional.
+// It's empty for the types left zero, such as time.Time.
+func goExample(api *spec.ApiSpec, t string, seen map[string]bool) string {
+	if strings.Contains(t, "time.Time") {
+		return ""
+	}
+	if strings.HasPrefix(t, "*") {
+		value := goExample(api, t[1:], seen)
+		if strings.HasPrefix(value, typesPacket+".") {
+			return "&" + value
 		}
-		e = t.Execute(file, vs)
-		if e != nil {
-			return e
+		return "new(" + goTestType(api, t[1:]) + ")"
+	}
+	if strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") {
+		return goTestType(api, t) + "{}"
+	}
+
+	switch t {
+	case "string", "interface{}":
+		return strconv.Quote("string")
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return "0"
+	case "bool":
+		return "false"
+	}
+	for _, ty := range api.Types {
+		if ty.Name != t {
+			continue
+		}
+		value := goTestType(api, t) + "{"
+		// recursive types end with the zero value
+		if seen[t] {
+			return value + "}"
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		for _, member := range ty.Members {
+			name := strings.Title(member.Name)
+			if member.IsInline {
+				name = strings.Title(strings.TrimPrefix(member.Type, "*"))
+			}
+			if v := goMemberExample(api, member, seen); len(v) > 0 {
+				value += "\n" + name + ": " + v + ","
+			}
 		}
+		return value + "\n}"
 	}
-	return nil
+	return ""
+}
+
+// goMemberExample returns the example of the member, which is the first of its options if it has any.
+func goMemberExample(api *spec.ApiSpec, member spec.Member, seen map[string]bool) string {
+	if match := optionsRe.FindStringSubmatch(member.Tag); match != nil && !strings.ContainsAny(member.Type, "*[") {
+		if member.Type == "string" {
+			return strconv.Quote(match[1])
+		}
+		return match[1]
+	}
+	return goExample(api, member.Type, seen)
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/servergen"
 	"github.com/gofaith/goctlr/api/spec"
 	apiutil "github.com/gofaith/goctlr/api/util"
 	"github.com/gofaith/goctlr/util"
@@ -17,15 +18,38 @@ import (
 const (
 	typesFile     = "types.go"
 	typesTemplate = `// DO NOT EDIT, generated by goctl
-package types{{if .containsTime}}
+package {{.pkg}}{{if or .containsTime .containsFile .aliases}}
 import (
+	{{- if .containsFile}}
+	"mime/multipart"
+	{{- end}}
+	{{- if .containsTime}}
 	"time"
+	{{- end}}
+	{{- if .aliases}}
+
+	"{{.sharedPkg}}"
+	{{- end}}
 ){{end}}
+{{- if .aliases}}
+
+// the types shared with the other versions
+type (
+	{{- range .aliases}}
+	{{.}} = types.{{.}}
+	{{- end}}
+)
+{{end}}
 {{.types}}
 `
 )
 
 func BuildTypes(types []spec.Type) (string, error) {
+	return buildTypes(types, types)
+}
+
+// buildTypes builds the types, whose members may refer to all the types.
+func buildTypes(types, all []spec.Type) (string, error) {
 	var builder strings.Builder
 	first := true
 	for _, tp := range types {
@@ -34,7 +58,7 @@ func BuildTypes(types []spec.Type) (string, error) {
 		} else {
 			builder.WriteString("\n\n")
 		}
-		if err := writeType(&builder, tp, types); err != nil {
+		if err := writeType(&builder, tp, all); err != nil {
 			return "", apiutil.WrapErr(err, "Type "+tp.Name+" generate error")
 		}
 	}
@@ -42,18 +66,73 @@ func BuildTypes(types []spec.Type) (string, error) {
 	return builder.String(), nil
 }
 
+// genTypes generates the types shared by the versions in the types package, and the types only used by
+// the routes of a version in its own package, e.g. types/v2, which aliases the shared types.
 func genTypes(dir string, api *spec.ApiSpec) error {
-	val, err := BuildTypes(api.Types)
+	typeVersions := apiutil.GetTypeVersions(api)
+	var shared []spec.Type
+	for _, tp := range api.Types {
+		if len(typeVersions[tp.Name]) == 0 {
+			shared = append(shared, tp)
+		}
+	}
+	if err := genVersionTypes(dir, "", shared, nil, api); err != nil {
+		return err
+	}
+
+	var aliases []string
+	for _, tp := range shared {
+		aliases = append(aliases, util.Title(tp.Name))
+	}
+	for _, version := range apiutil.GetVersions(api) {
+		var types []spec.Type
+		for _, tp := range api.Types {
+			if typeVersions[tp.Name] == version {
+				types = append(types, tp)
+			}
+		}
+		if err := genVersionTypes(dir, version, types, aliases, api); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genVersionTypes generates the types and the results of the routes of the version, the routes without version
+// if it's empty.
+func genVersionTypes(dir, version string, types []spec.Type, aliases []string, api *spec.ApiSpec) error {
+	val, err := buildTypes(types, api.Types)
+	if err != nil {
+		return err
+	}
+	var routes []spec.Route
+	for _, route := range api.Service.Routes {
+		if route.Version == version {
+			routes = append(routes, route)
+		}
+	}
+	results, err := servergen.BuildResults(routes)
 	if err != nil {
 		return err
 	}
+	val += "\n" + results
 
-	filename := path.Join(dir, typesDir, strings.ToLower(strings.TrimSuffix(api.Service.Name, "-api"))+typesFile)
+	pkg, folder, sharedPkg := typesPacket, typesDir, ""
+	if len(version) > 0 {
+		pkg, folder = version, path.Join(typesDir, version)
+		parentPkg, err := getParentPackage(dir)
+		if err != nil {
+			return err
+		}
+		sharedPkg = util.JoinPackages(parentPkg, typesDir)
+	}
+	file := strings.ToLower(strings.TrimSuffix(api.Service.Name, "-api")) + typesFile
+	filename := path.Join(dir, folder, file)
 	if err := util.RemoveOrQuit(filename); err != nil {
 		return err
 	}
 
-	fp, created, err := apiutil.MaybeCreateFile(dir, typesDir, strings.ToLower(strings.TrimSuffix(api.Service.Name, "-api"))+typesFile)
+	fp, created, err := apiutil.MaybeCreateFile(dir, folder, file)
 	if err != nil {
 		return err
 	}
@@ -62,11 +141,16 @@ func genTypes(dir string, api *spec.ApiSpec) error {
 	}
 	defer fp.Close()
 
+	subset := &spec.ApiSpec{Types: types}
 	t := template.Must(template.New("typesTemplate").Parse(typesTemplate))
 	buffer := new(bytes.Buffer)
 	err = t.Execute(buffer, map[string]interface{}{
+		"pkg":          pkg,
 		"types":        val,
-		"containsTime": api.ContainsTime(),
+		"containsTime": subset.ContainsTime(),
+		"containsFile": subset.ContainsFile(),
+		"aliases":      aliases,
+		"sharedPkg":    sharedPkg,
 	})
 	if err != nil {
 		return nil
@@ -76,6 +160,14 @@ func genTypes(dir string, api *spec.ApiSpec) error {
 	return err
 }
 
+// getTypesImport returns the import of the types package of the route, the package of its version is imported as types.
+func getTypesImport(parentPkg string, route spec.Route) string {
+	if len(route.Version) == 0 {
+		return fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, typesDir))
+	}
+	return fmt.Sprintf("%s \"%s\"", typesPacket, util.JoinPackages(parentPkg, typesDir, route.Version))
+}
+
 func convertTypeCase(types []spec.Type, t string) (string, error) {
 	ts, err := apiutil.DecomposeType(t)
 	if err != nil {
@@ -127,6 +219,10 @@ func writeType(writer io.Writer, tp spec.Type, types []spec.Type) error {
 		if err != nil {
 			return err
 		}
+		if member.IsFile() {
+			// the uploaded files are kept by the multipart form of the request
+			tpString = strings.Replace(strings.TrimPrefix(tpString, "*"), spec.FileTypeName, "*multipart.FileHeader", 1)
+		}
 		// pm, err := member.GetPropertyName()
 		// if err != nil {
 		// 	return err
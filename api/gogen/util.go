@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/gofaith/go-zero/core/collection"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
 	goctlutil "github.com/gofaith/goctlr/util"
@@ -63,17 +62,34 @@ func writeProperty(writer io.Writer, name, tp, tag, comment string, indent int)
 	return err
 }
 
-func getAuths(api *spec.ApiSpec) []string {
-	authNames := collection.NewSet()
+// authConfig is a config field of the jwt and signature annotations, they can share one name.
+type authConfig struct {
+	Name      string
+	Jwt       bool
+	Signature bool
+}
+
+func getAuths(api *spec.ApiSpec) []authConfig {
+	var result []authConfig
+	add := func(name string, fn func(item *authConfig)) {
+		for i := range result {
+			if result[i].Name == name {
+				fn(&result[i])
+				return
+			}
+		}
+		result = append(result, authConfig{Name: name})
+		fn(&result[len(result)-1])
+	}
 	for _, g := range api.Service.Groups {
 		if value, ok := util.GetAnnotationValue(g.Annotations, "server", "jwt"); ok {
-			authNames.Add(value)
+			add(value, func(item *authConfig) { item.Jwt = true })
 		}
 		if value, ok := util.GetAnnotationValue(g.Annotations, "server", "signature"); ok {
-			authNames.Add(value)
+			add(value, func(item *authConfig) { item.Signature = true })
 		}
 	}
-	return authNames.KeysStr()
+	return result
 }
 
 func formatCode(code string) string {
@@ -1,12 +1,16 @@
 package gogen
 
 const (
-	interval       = "internal/"
-	typesPacket    = "types"
-	configDir      = interval + "config"
-	contextDir     = interval + "svc"
-	handlerDir     = interval + "handler"
-	logicDir       = interval + "logic"
-	typesDir       = interval + typesPacket
-	folderProperty = "folder"
+	interval         = "internal/"
+	typesPacket      = "types"
+	configDir        = interval + "config"
+	contextDir       = interval + "svc"
+	handlerDir       = interval + "handler"
+	logicDir         = interval + "logic"
+	typesDir         = interval + typesPacket
+	pbDir            = interval + "pb"
+	codecDir         = interval + "codec"
+	middlewareDir    = interval + "middleware"
+	errorxDir        = interval + "errorx"
+	observabilityDir = interval + "observability"
 )
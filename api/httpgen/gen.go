@@ -0,0 +1,193 @@
+package httpgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/urfave/cli"
+)
+
+// boundary separates the parts of the multipart/form-data bodies
+const boundary = "goctlr"
+
+const httpTemplate = `# {{.title}}{{if ne .desc ""}} - {{.desc}}{{end}}
+# Works with the VS Code REST Client and the JetBrains HTTP Client.
+@baseUrl = {{.baseUrl}}
+# the Authorization header of the jwt routes
+@token =
+{{range .variables}}@{{.}}
+{{end}}{{range .requests}}
+### {{.Title}}
+{{range .Comments}}# {{.}}
+{{end}}{{.Method}} {{.Url}}
+{{range .Headers}}{{.}}
+{{end}}{{if ne .Body ""}}
+{{.Body}}
+{{end}}{{end}}`
+
+type request struct {
+	Title    string
+	Comments []string
+	Method   string
+	Url      string
+	Headers  []string
+	Body     string
+}
+
+func HttpCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+	return genHttp(dir, c.String("baseurl"), api)
+}
+
+func genHttp(dir, baseUrl string, api *spec.ApiSpec) error {
+	if len(baseUrl) == 0 {
+		baseUrl = util.GetBaseUrl(api)
+	}
+
+	var (
+		requests  []request
+		variables []string
+		// the folders are written one after another
+		folders []string
+		grouped = make(map[string][]request)
+		seen    = make(map[string]bool)
+	)
+	for _, group := range api.Service.Groups {
+		for _, route := range group.Routes {
+			req := buildRequest(api, group, route, func(name, value string) {
+				if !seen[name] {
+					seen[name] = true
+					variables = append(variables, name+" = "+value)
+				}
+			})
+			folder := util.GetRouteFolder(group, route)
+			if _, ok := grouped[folder]; !ok {
+				folders = append(folders, folder)
+			}
+			if len(folder) > 0 {
+				req.Title = folder + " / " + req.Title
+			}
+			grouped[folder] = append(grouped[folder], req)
+		}
+	}
+	for _, folder := range folders {
+		requests = append(requests, grouped[folder]...)
+	}
+
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, api.Info.Title+".http"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("http").Parse(httpTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"title":     api.Info.Title,
+		"desc":      api.Info.Desc,
+		"baseUrl":   baseUrl,
+		"variables": variables,
+		"requests":  requests,
+	})
+}
+
+func buildRequest(api *spec.ApiSpec, group spec.Group, route spec.Route, addVariable func(name, value string)) request {
+	members := util.GetRequestMembers(api, route)
+	result := request{
+		Title:  util.GetRouteName(route),
+		Method: strings.ToUpper(route.Method),
+	}
+	if handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler"); ok {
+		result.Comments = append(result.Comments, "@name "+handler)
+	}
+	if len(route.Desc) > 0 {
+		result.Comments = append(result.Comments, route.Desc)
+	}
+
+	path := util.ConvertPath(route.Path, func(name string) string {
+		value := name
+		if member, ok := members.GetPathMember(name); ok {
+			value = exampleString(api, member)
+		}
+		addVariable(name, value)
+		return "{{" + name + "}}"
+	})
+	var query []string
+	if util.IsMultipart(api, route) {
+		// the form members are sent as the fields along with the files
+		result.Headers = append(result.Headers, "Content-Type: multipart/form-data; boundary="+boundary)
+		result.Body = multipartBody(api, members.Query)
+		members.Query = nil
+	}
+	for _, member := range members.Query {
+		query = append(query, member.GetTagName()+"="+exampleString(api, member))
+	}
+	result.Url = "{{baseUrl}}" + path
+	if len(query) > 0 {
+		result.Url += "?" + strings.Join(query, "&")
+	}
+
+	if group.Jwt {
+		result.Headers = append(result.Headers, "Authorization: {{token}}")
+	}
+	for _, member := range members.Header {
+		result.Headers = append(result.Headers, member.GetTagName()+": "+exampleString(api, member))
+	}
+	if len(members.Body) > 0 {
+		result.Headers = append(result.Headers, "Content-Type: application/json")
+		result.Body = util.GetExampleJSON(api.Types, members.Body)
+	}
+	return result
+}
+
+// multipartBody returns the example parts of the members, the files are read from the paths next to the .http file.
+func multipartBody(api *spec.ApiSpec, members []spec.Member) string {
+	var parts []string
+	for _, member := range members {
+		name := member.GetTagName()
+		if member.IsFile() {
+			parts = append(parts, fmt.Sprintf("--%s\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s.bin\"\n\n< ./%s.bin", boundary, name, name, name))
+			continue
+		}
+		value := util.GetExampleValue(api.Types, strings.TrimPrefix(strings.TrimPrefix(member.Type, "*"), "[]"))
+		parts = append(parts, fmt.Sprintf("--%s\nContent-Disposition: form-data; name=\"%s\"\n\n%v", boundary, name, value))
+	}
+	return strings.Join(append(parts, "--"+boundary+"--"), "\n")
+}
+
+func exampleString(api *spec.ApiSpec, member spec.Member) string {
+	return fmt.Sprint(util.GetExampleValue(api.Types, member.Type))
+}
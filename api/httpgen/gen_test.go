@@ -0,0 +1,111 @@
+package httpgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const userApi = `info(
+	title: user
+)
+
+type setRequest struct {
+	id    int    ` + "`path:\"id\"`" + `
+	token string ` + "`header:\"X-Token\"`" + `
+	name  string ` + "`json:\"name\"`" + `
+}
+
+type listRequest struct {
+	page int    ` + "`form:\"page\"`" + `
+	sort string ` + "`form:\"sort,optional\"`" + `
+}
+
+type avatarRequest struct {
+	name   string ` + "`form:\"name\"`" + `
+	avatar file   ` + "`form:\"avatar\"`" + `
+}
+
+@server(
+	jwt: Auth
+)
+service user-api {
+	@doc(
+		summary: set user
+	)
+	@server(
+		handler: SetUserHandler
+	)
+	put /api/user/:id(setRequest)
+
+	@server(
+		handler: ListUserHandler
+	)
+	get /api/users(listRequest)
+
+	@server(
+		handler: SetAvatarHandler
+	)
+	post /api/avatar(avatarRequest)
+}
+`
+
+func genTestHttp(t *testing.T) string {
+	p, e := parser.NewParserFromStr(userApi)
+	assert.Nil(t, e)
+	api, e := p.Parse()
+	assert.Nil(t, e)
+
+	dir := t.TempDir()
+	assert.Nil(t, genHttp(dir, "http://localhost:8080", api))
+	b, e := ioutil.ReadFile(filepath.Join(dir, "user.http"))
+	assert.Nil(t, e)
+	return string(b)
+}
+
+func TestVariables(t *testing.T) {
+	text := genTestHttp(t)
+	assert.Contains(t, text, "@baseUrl = http://localhost:8080\n")
+	assert.Contains(t, text, "@token =\n")
+	assert.Contains(t, text, "@id = 0\n")
+}
+
+func TestRequestLines(t *testing.T) {
+	text := genTestHttp(t)
+	assert.Contains(t, text, `### set user
+# @name SetUserHandler
+PUT {{baseUrl}}/api/user/{{id}}
+Authorization: {{token}}
+X-Token: string
+Content-Type: application/json
+
+{
+  "name": "string"
+}
+`)
+	assert.Contains(t, text, `### ListUserHandler
+# @name ListUserHandler
+GET {{baseUrl}}/api/users?page=0&sort=string
+Authorization: {{token}}
+`)
+}
+
+func TestMultipart(t *testing.T) {
+	assert.Contains(t, genTestHttp(t), `POST {{baseUrl}}/api/avatar
+Content-Type: multipart/form-data; boundary=goctlr
+Authorization: {{token}}
+
+--goctlr
+Content-Disposition: form-data; name="name"
+
+string
+--goctlr
+Content-Disposition: form-data; name="avatar"; filename="avatar.bin"
+
+< ./avatar.bin
+--goctlr--
+`)
+}
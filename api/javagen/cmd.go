@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
 	"github.com/urfave/cli"
 )
 
@@ -29,6 +30,25 @@ func JavaCommand(c *cli.Context) error {
 	if e != nil {
 		return e
 	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+
+	if c.Bool("retrofit") {
+		e = genRetrofitBase(dir, pkg)
+		if e != nil {
+			return e
+		}
+		e = genRetrofitFile(dir, pkg, api)
+		if e != nil {
+			return e
+		}
+		return genRetrofitApi(dir, pkg, api)
+	}
+	if util.HasFileRoute(api) {
+		return errors.New("file uploads and binary downloads need -retrofit")
+	}
 
 	e = genBase(dir, pkg, api)
 	if e != nil {
@@ -101,11 +101,18 @@ public class {{with .Info}}{{.Title}}{{end}} {
 			return v;
 		}
 	}{{end}}
-	{{with .Service}}{{range .Routes}}
+	{{with .Service}}{{range .Routes}}{{if .Deprecation}}
+	/** @deprecated {{.Deprecation.Message}} */
+	@Deprecated{{end}}
 	public static {{with .ResponseType}}{{if eq .Name ""}}void{{else}}{{.Name}}{{end}}{{end}} {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}{{.Name}} request{{else}}{{end}}{{end}}) throws Exception {
 		{{with .ResponseType}}{{if ne .Name ""}}String res = {{end}}{{end}}Base.request("{{upperCase .Method}}", "{{.Path}}", {{with .RequestType}}{{if ne .Name ""}}request.toString(){{else}}null{{end}}{{end}});{{with .ResponseType}}{{if ne .Name ""}}
 		return {{.Name}}.fromJson((JSONObject) new JSONTokener(res).nextValue());{{end}}{{end}}
-	} {{end}}{{end}}
+	} {{end}}{{end}}{{if .Errors}}
+	/** the codes of the errors declared in the api file */
+	public static final class ErrorCodes { {{range .Errors}}
+		/** {{.Desc}} */
+		public static final int {{.Name}} = {{.Code}};{{end}}
+	}{{end}}
 }
 `
 )
@@ -140,6 +147,7 @@ func genBase(dir, pkg string, api *spec.ApiSpec) error {
 }
 
 func genApi(dir, pkg string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "java")
 	name := strcase.ToCamel(api.Info.Title + "Api")
 	path := filepath.Join(dir, name+".java")
 	api.Info.Title = name
@@ -0,0 +1,462 @@
+package javagen
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	retrofitBaseTemplate = `package {{.}};
+
+// Dependencies:
+//   com.squareup.retrofit2:retrofit:2.11.0
+//   com.squareup.retrofit2:converter-gson:2.11.0
+//   com.squareup.okhttp3:okhttp:4.12.0
+
+import com.google.gson.Gson;
+import com.google.gson.annotations.SerializedName;
+
+import java.io.IOException;
+import java.util.Collections;
+import java.util.List;
+
+import okhttp3.Interceptor;
+import okhttp3.OkHttpClient;
+import okhttp3.ResponseBody;
+import retrofit2.Response;
+import retrofit2.Retrofit;
+import retrofit2.converter.gson.GsonConverterFactory;
+
+public final class ApiClient {
+	public static final Gson GSON = new Gson();
+
+	public static class ErrorCode {
+		@SerializedName("code")
+		public int code;
+		@SerializedName("desc")
+		public String desc;
+	}
+
+	public static class ApiException extends IOException {
+		public final int status;
+		public final ErrorCode error;
+
+		public ApiException(int status, ErrorCode error) {
+			super(status + ": " + error.code + " " + error.desc);
+			this.status = status;
+			this.error = error;
+		}
+	}
+
+	private ApiClient() {
+	}
+
+	public static Retrofit retrofit(String baseUrl) {
+		return retrofit(baseUrl, new OkHttpClient(), Collections.<Interceptor>emptyList());
+	}
+
+	/**
+	 * Builds a Retrofit instance for the generated api services,
+	 * timeouts and connection pools are configured on the given client.
+	 */
+	public static Retrofit retrofit(String baseUrl, OkHttpClient client, List<Interceptor> interceptors) {
+		OkHttpClient.Builder builder = client.newBuilder();
+		for (Interceptor interceptor : interceptors) {
+			builder.addInterceptor(interceptor);
+		}
+		return new Retrofit.Builder()
+				.baseUrl(baseUrl)
+				.client(builder.build())
+				.addConverterFactory(GsonConverterFactory.create(GSON))
+				.build();
+	}
+
+	/**
+	 * Returns the body of a successful response, or throws ApiException with the decoded ErrorCode.
+	 */
+	public static <T> T unwrap(Response<T> response) throws ApiException {
+		if (response.isSuccessful()) {
+			return response.body();
+		}
+		ErrorCode error = new ErrorCode();
+		error.code = response.code();
+		try (ResponseBody body = response.errorBody()) {
+			String str = body == null ? "" : body.string();
+			error.desc = str;
+			ErrorCode decoded = GSON.fromJson(str, ErrorCode.class);
+			if (decoded != null) {
+				error = decoded;
+			}
+		} catch (Exception ignored) {
+		}
+		throw new ApiException(response.code(), error);
+	}
+}
+`
+	retrofitFileTemplate = `package {{.}};
+
+import java.io.File;
+import java.io.UnsupportedEncodingException;
+import java.net.URLDecoder;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+import okhttp3.MediaType;
+import okhttp3.MultipartBody;
+import okhttp3.RequestBody;
+import okhttp3.ResponseBody;
+import retrofit2.Response;
+
+/**
+ * A binary response, the body must be closed once read.
+ */
+public final class ApiFile {
+	private static final Pattern ENCODED_NAME = Pattern.compile("filename\\*=[^']*'[^']*'([^;]+)", Pattern.CASE_INSENSITIVE);
+	private static final Pattern PLAIN_NAME = Pattern.compile("filename=\"?([^\";]+)\"?", Pattern.CASE_INSENSITIVE);
+
+	public final String name;
+	public final String contentType;
+	public final ResponseBody body;
+
+	private ApiFile(String name, String contentType, ResponseBody body) {
+		this.name = name;
+		this.contentType = contentType;
+		this.body = body;
+	}
+
+	/**
+	 * Returns the file of a binary response named by its Content-Disposition header,
+	 * or throws ApiException with the decoded ErrorCode.
+	 */
+	public static ApiFile of(Response<ResponseBody> response) throws ApiClient.ApiException {
+		ResponseBody body = ApiClient.unwrap(response);
+		return new ApiFile(fileName(response.headers().get("Content-Disposition")), response.headers().get("Content-Type"), body);
+	}
+
+	/**
+	 * Returns the multipart part of the upload field, e.g. ApiFile.part("avatar", "me.png", bytes, "image/png")
+	 */
+	public static MultipartBody.Part part(String field, String name, byte[] bytes, String contentType) {
+		return MultipartBody.Part.createFormData(field, name, RequestBody.create(bytes, MediaType.parse(contentType)));
+	}
+
+	/**
+	 * Returns the multipart part of the upload field, the content is streamed from the file.
+	 */
+	public static MultipartBody.Part part(String field, File file, String contentType) {
+		return MultipartBody.Part.createFormData(field, file.getName(), RequestBody.create(file, MediaType.parse(contentType)));
+	}
+
+	private static String fileName(String disposition) {
+		if (disposition == null) {
+			return "";
+		}
+		Matcher encoded = ENCODED_NAME.matcher(disposition);
+		if (encoded.find()) {
+			try {
+				return URLDecoder.decode(encoded.group(1), "UTF-8");
+			} catch (UnsupportedEncodingException ignored) {
+			}
+		}
+		Matcher plain = PLAIN_NAME.matcher(disposition);
+		return plain.find() ? plain.group(1) : "";
+	}
+}
+`
+	retrofitApiTemplate = `package {{.pkg}};
+
+import com.google.gson.JsonElement;
+import com.google.gson.annotations.SerializedName;
+
+import java.util.List;
+import java.util.Map;
+{{if .file}}
+import okhttp3.MultipartBody;
+import okhttp3.ResponseBody;{{end}}
+import retrofit2.Call;
+import retrofit2.Retrofit;
+import retrofit2.http.*;
+
+public final class {{.name}} {
+	private {{.name}}() {
+	}
+{{range .types}}
+	public static class {{.Name}} { {{range .Members}}{{if ne .Comment ""}}
+		/** {{.Comment}} */{{end}}
+		{{.Annotation}}public {{.Type}} {{.Name}};{{end}}
+	}
+{{end}}
+	public interface Service { {{range .routes}}{{if or (ne .Doc "") (ne .Deprecated "")}}
+		/**{{if ne .Doc ""}} {{.Doc}}{{end}}{{if ne .Deprecated ""}}
+		 * @deprecated {{.Deprecated}}{{end}} */{{end}}{{if ne .Deprecated ""}}
+		@Deprecated{{end}}{{if .Multipart}}
+		@Multipart{{end}}{{if .Binary}}
+		@Streaming{{end}}
+		{{if .HasBody}}@HTTP(method = "{{.Method}}", path = "{{.Path}}", hasBody = true){{else}}@{{.Method}}("{{.Path}}"){{end}}
+		Call<{{if eq .Response ""}}Void{{else}}{{.Response}}{{end}}> {{.Func}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Annotation}} {{$p.Type}} {{$p.Name}}{{end}});
+{{end}}	}
+
+	public static Service create(Retrofit retrofit) {
+		return retrofit.create(Service.class);
+	}
+{{if .errors}}
+	/** the codes of the errors declared in the api file */
+	public static final class ErrorCodes { {{range .errors}}
+		/** {{.Desc}} */
+		public static final int {{.Name}} = {{.Code}};{{end}}
+
+		private ErrorCodes() {
+		}
+	}
+{{end}}}
+`
+)
+
+type (
+	javaMember struct {
+		Annotation string
+		Name       string
+		Type       string
+		Comment    string
+	}
+	javaType struct {
+		Name    string
+		Members []javaMember
+	}
+	javaParam struct {
+		Annotation string
+		Name       string
+		Type       string
+	}
+	javaRoute struct {
+		Doc       string
+		Method    string
+		Path      string
+		Func      string
+		Params    []javaParam
+		Response  string
+		Multipart bool
+		Binary    bool
+		// HasBody is set if the body is sent by a method retrofit sends without body, e.g. DELETE
+		HasBody bool
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+)
+
+func genRetrofitBase(dir, pkg string) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "ApiClient.java")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("ApiClient.java already exists. Skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("ApiClient.java").Parse(retrofitBaseTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, pkg)
+}
+
+func genRetrofitApi(dir, pkg string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "retrofit")
+	name := strcase.ToCamel(api.Info.Title + "Api")
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+
+	file, e := os.OpenFile(filepath.Join(dir, name+".java"), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	var types []javaType
+	for _, tp := range api.Types {
+		types = append(types, buildJavaType(api, tp))
+	}
+	var routes []javaRoute
+	for _, route := range api.Service.Routes {
+		item, e := buildJavaRoute(api, route)
+		if e != nil {
+			return e
+		}
+		routes = append(routes, item)
+	}
+
+	t, e := template.New(name).Parse(retrofitApiTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"pkg":    pkg,
+		"name":   name,
+		"types":  types,
+		"routes": routes,
+		"file":   util.HasFileRoute(api),
+		"errors": api.Errors,
+	})
+}
+
+// genRetrofitFile writes ApiFile.java with the multipart and download helpers if the api uploads files or downloads binary.
+func genRetrofitFile(dir, pkg string, api *spec.ApiSpec) error {
+	if !util.HasFileRoute(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "ApiFile.java")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("ApiFile.java already exists. Skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("ApiFile.java").Parse(retrofitFileTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, pkg)
+}
+
+func buildJavaType(api *spec.ApiSpec, tp spec.Type) javaType {
+	result := javaType{Name: strcase.ToCamel(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		m := javaMember{
+			Name:    strcase.ToLowerCamel(member.Name),
+			Type:    javaFieldType(member),
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+		}
+		if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+			// sent as path, query or header, gson skips transient fields
+			m.Type = "transient " + m.Type
+		} else {
+			m.Annotation = "@SerializedName(\"" + member.GetTagName() + "\")\n\t\t"
+		}
+		result.Members = append(result.Members, m)
+	}
+	return result
+}
+
+func buildJavaRoute(api *spec.ApiSpec, route spec.Route) (javaRoute, error) {
+	members := util.GetRequestMembers(api, route)
+	result := javaRoute{
+		Doc:        strings.TrimSpace(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strings.ToUpper(route.Method),
+		Path: util.ConvertPath(route.Path, func(name string) string {
+			return "{" + name + "}"
+		}),
+		Func: util.RouteToFuncName(route.Method, route.Path),
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		// ApiFile.of reads the file name from the headers of the response
+		result.Response = "ResponseBody"
+		result.Binary = true
+	}
+	result.Multipart = util.IsMultipart(api, route)
+
+	addParams := func(annotation string, items []spec.Member) {
+		for _, member := range items {
+			param := javaParam{
+				Annotation: "@" + annotation + "(\"" + member.GetTagName() + "\")",
+				Name:       strcase.ToLowerCamel(member.Name),
+				// boxed types, so that a null query or header is omitted
+				Type: javaType2(member.Type, true),
+			}
+			if member.IsFile() {
+				// the part carries the field name, see ApiFile.part
+				param.Annotation = "@Part"
+			}
+			result.Params = append(result.Params, param)
+		}
+	}
+	addParams("Path", members.Path)
+	addParams("Query", members.Query)
+	addParams("Header", members.Header)
+	if len(members.Body) > 0 {
+		hasBody, e := util.RetrofitBody(route)
+		if e != nil {
+			return result, e
+		}
+		result.HasBody = hasBody
+		result.Params = append(result.Params, javaParam{
+			Annotation: "@Body",
+			Name:       "req",
+			Type:       strcase.ToCamel(route.RequestType.Name),
+		})
+	}
+	return result, nil
+}
+
+func javaFieldType(member spec.Member) string {
+	boxed := member.IsOptional() || strings.HasPrefix(member.Type, "*")
+	return javaType2(member.Type, boxed)
+}
+
+func javaType2(t string, boxed bool) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "List<" + javaType2(t[2:], true) + ">"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "Map<" + javaType2(tys[0], true) + ", " + javaType2(tys[1], true) + ">"
+	}
+
+	var primitive, box string
+	switch t {
+	case "string":
+		return "String"
+	case "int8", "int16", "int32", "uint8", "uint16":
+		primitive, box = "int", "Integer"
+	case "int", "int64", "uint", "uint32", "uint64":
+		primitive, box = "long", "Long"
+	case "float32":
+		primitive, box = "float", "Float"
+	case "float64":
+		primitive, box = "double", "Double"
+	case "bool":
+		primitive, box = "boolean", "Boolean"
+	case "interface{}":
+		return "JsonElement"
+	case "time.Time":
+		// RFC 3339 string written by encoding/json
+		return "String"
+	case spec.FileTypeName:
+		return "MultipartBody.Part"
+	default:
+		return strcase.ToCamel(t)
+	}
+	if boxed {
+		return box
+	}
+	return primitive
+}
@@ -0,0 +1,58 @@
+package javagen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseApi(t *testing.T, route string) *spec.ApiSpec {
+	text := `info(
+	title: user
+)
+
+type request struct {
+	id   int    ` + "`path:\"id\"`" + `
+	name string ` + "`json:\"name\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: UserHandler
+	)
+	` + route + `
+}
+`
+	p, err := parser.NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	return api
+}
+
+func TestRetrofitDeleteBody(t *testing.T) {
+	api := parseApi(t, "delete /api/user/:id(request)")
+	dir := t.TempDir()
+	assert.Nil(t, genRetrofitApi(dir, "com.example", api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "UserApi.java"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `@HTTP(method = "DELETE", path = "/api/user/{id}", hasBody = true)`)
+	assert.NotContains(t, string(b), "@DELETE(")
+}
+
+func TestRetrofitGetBody(t *testing.T) {
+	api := parseApi(t, "get /api/user/:id(request)")
+	// retrofit throws "Non-body HTTP method cannot contain @Body"
+	assert.Error(t, genRetrofitApi(t.TempDir(), "com.example", api))
+
+	api = parseApi(t, "post /api/user/:id(request)")
+	dir := t.TempDir()
+	assert.Nil(t, genRetrofitApi(dir, "com.example", api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "UserApi.java"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `@POST("/api/user/{id}")`)
+}
@@ -1,6 +1,7 @@
 package jsgen
 
 import (
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -66,7 +67,7 @@ function apiRequest(method,uri,body,onOk,onFail,eventually,headers,onProgress){
 			xhr.setRequestHeader('Content-Type',body.type)
 			xhr.send(body)
 		}else if(body instanceof FormData){
-			xhr.setRequestHeader('Content-Type','multipart/form-data')
+			// the browser sets the multipart boundary
 			xhr.send(body)
         }else{
 			xhr.setRequestHeader('Content-Type','application/json')
@@ -77,10 +78,124 @@ function apiRequest(method,uri,body,onOk,onFail,eventually,headers,onProgress){
     }
 }`
 	apiTemplate = `{{with .Service}}{{range .Routes}}
-//{{.Summary}}
+//{{.Summary}}{{if .Deprecation}}
+/** @deprecated {{.Deprecation.Message}} */{{end}}
 function {{routeToFuncName .Method .Path}}(req,onOk,onFail,eventually,headers,onProgress){
+    {{- if isMultipart .}}
+    apiRequest('{{upperCase .Method}}',{{fileUri .}},apiForm({{formFields .}}),onOk,onFail,eventually,headers,onProgress)
+    {{- else if .Binary}}
+    apiDownload('{{upperCase .Method}}',{{fileUri .}},{{fileBody .}},onOk,onFail,eventually,headers,onProgress)
+    {{- else}}
     apiRequest('{{upperCase .Method}}','{{.Path}}',req,onOk,onFail,eventually,headers,onProgress)
-}{{end}}{{end}}`
+    {{- end}}
+}{{end}}{{end}}{{if .Errors}}
+
+//the codes of the errors declared in the api file, e.g. e.code==ErrorCodes.{{with index .Errors 0}}{{.Name}}{{end}}
+var ErrorCodes=Object.freeze({ {{- range .Errors}}
+    {{.Name}}:{{.Code}}, //{{.Desc}}{{end}}
+}){{end}}`
+	// onOk of a download receives the blob and the file name of its Content-Disposition header
+	fileTemplate = `function apiFileName(disposition){
+    if(!disposition){
+        return ''
+    }
+    var encoded=/filename\*=[^']*'[^']*'([^;]+)/i.exec(disposition)
+    if(encoded){
+        return decodeURIComponent(encoded[1])
+    }
+    var plain=/filename="?([^";]+)"?/i.exec(disposition)
+    return plain?plain[1]:''
+}
+
+function apiForm(fields){
+    var form=new FormData()
+    for(var key in fields){
+        var values=Array.isArray(fields[key])?fields[key]:[fields[key]]
+        for(var i=0;i<values.length;i++){
+            var value=values[i]
+            if(value===undefined||value===null){
+                continue
+            }
+            if(value instanceof Blob){
+                form.append(key,value,value instanceof File?value.name:key)
+            }else{
+                form.append(key,String(value))
+            }
+        }
+    }
+    return form
+}
+
+function apiQuery(params){
+    var items=[]
+    for(var key in params){
+        var values=Array.isArray(params[key])?params[key]:[params[key]]
+        for(var i=0;i<values.length;i++){
+            if(values[i]!==undefined&&values[i]!==null){
+                items.push(encodeURIComponent(key)+'='+encodeURIComponent(String(values[i])))
+            }
+        }
+    }
+    return items.length>0?'?'+items.join('&'):''
+}
+
+function apiDownload(method,uri,body,onOk,onFail,eventually,headers,onProgress){
+    var xhr=new XMLHttpRequest()
+    xhr.onreadystatechange=function(e){
+        if(xhr.readyState!=4){
+            return
+        }
+        if(xhr.status==200||xhr.status==206){
+            if(onOk){
+                onOk(xhr.response,apiFileName(xhr.getResponseHeader('Content-Disposition')))
+            }
+            if(eventually){
+                eventually()
+            }
+            return
+        }
+        var fail=function(text){
+            if(onFail){
+                try{
+                    onFail(JSON.parse(text))
+                }catch(e){
+                    onFail(text)
+                }
+            }
+            if(eventually){
+                eventually()
+            }
+        }
+        if(xhr.response){
+            xhr.response.text().then(fail,function(){
+                fail('')
+            })
+        }else{
+            fail('')
+        }
+    }
+    xhr.open(method,server+uri,true)
+    xhr.responseType='blob'
+    if(headers){
+        for(var key in headers){
+            xhr.setRequestHeader(key,headers[key])
+        }
+    }
+    if(onProgress){
+        xhr.addEventListener('progress',function(ev){
+            if(ev.lengthComputable){
+                onProgress(Math.round(ev.loaded*100/ev.total),ev.loaded,ev.total)
+            }
+        })
+    }
+    if(body){
+        xhr.setRequestHeader('Content-Type','application/json')
+        xhr.send(JSON.stringify(body))
+    }else{
+        xhr.send()
+    }
+}
+`
 )
 
 func genBase(dir string, api *spec.ApiSpec) error {
@@ -105,6 +220,7 @@ func genBase(dir string, api *spec.ApiSpec) error {
 }
 
 func genApi(dir string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "javascript")
 	name := strcase.ToSnake(api.Info.Title + "_api")
 	path := filepath.Join(dir, name+".js")
 	api.Info.Title = name
@@ -120,9 +236,45 @@ func genApi(dir string, api *spec.ApiSpec) error {
 	}
 	defer file.Close()
 
-	t, e := template.New("api").Funcs(util.FuncsMap).Parse(apiTemplate)
+	t, e := template.New("api").Funcs(util.FuncsMap).Funcs(template.FuncMap{
+		"hasFile": func() bool {
+			return util.HasFileRoute(api)
+		},
+		"isMultipart": func(route spec.Route) bool {
+			return util.IsMultipart(api, route)
+		},
+		"fileUri": func(route spec.Route) string {
+			if util.IsMultipart(api, route) {
+				// the form members are sent in the multipart body
+				return util.JsRouteUri(api, route, "")
+			}
+			return util.JsRouteUri(api, route, "apiQuery")
+		},
+		"formFields": func(route spec.Route) string {
+			return util.JsFormFields(api, route)
+		},
+		"fileBody": func(route spec.Route) string {
+			if len(util.GetRequestMembers(api, route).Body) > 0 {
+				return "req"
+			}
+			return "null"
+		},
+	}).Parse(apiTemplate)
 	if e != nil {
 		return e
 	}
 	return t.Execute(file, api)
 }
+
+// genFile writes file.js with the multipart and download helpers if the api uploads files or downloads binary.
+func genFile(dir string, api *spec.ApiSpec) error {
+	if !util.HasFileRoute(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "file.js")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("file.js already exists , skipped it.")
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(fileTemplate), 0644)
+}
@@ -115,7 +115,8 @@ object {{with .Info}}{{.Title}}{{end}}{
 		val {{with $item}}{{lowCamelCase .Name}}: {{toKtType .Type}} = {{ktDefaultValue .Type}}{{end}}{{if ne $i (add $length -1)}},{{end}}{{end}}
 	){{end}}{{end}}
 	{{with .Service}}
-	{{range .Routes}}suspend fun {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
+	{{range .Routes}}{{if .Deprecation}}@Deprecated("{{.Deprecation.Message}}")
+	{{end}}suspend fun {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
 		req:{{.Name}},{{end}}{{end}}
 		onOk: (({{with .ResponseType}}{{.Name}}{{end}}) -> Unit)? = null,
         onFail: ((ErrorCode) -> Unit)? = null,
@@ -125,7 +126,12 @@ object {{with .Info}}{{.Title}}{{end}}{
             onOk?.invoke({{if ne .Name ""}}Json{ignoreUnknownKeys=true}.decodeFromString(it){{end}}){{end}}
         }, onFail = onFail, eventually =eventually)
     }
-	{{end}}{{end}}
+	{{end}}{{end}}{{if .Errors}}
+	/** the codes of the errors declared in the api file, e.g. it.code == ErrorCodes.{{with index .Errors 0}}{{.Name}}{{end}} */
+	object ErrorCodes { {{range .Errors}}
+		/** {{.Desc}} */
+		const val {{.Name}} = {{.Code}}{{end}}
+	}{{end}}
 }`
 )
 
@@ -158,6 +164,7 @@ func genBase(dir, pkg string, api *spec.ApiSpec) error {
 }
 
 func genApi(dir, pkg string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "kotlin")
 	name := strcase.ToCamel(api.Info.Title + "Api")
 	path := filepath.Join(dir, name+".kt")
 	api.Info.Title = name
@@ -0,0 +1,49 @@
+package ktgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+	p, err := parser.NewParserFromStr(`info(
+	title: user
+)
+
+type event struct {
+	text string ` + "`json:\"text\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: EventsHandler
+	)
+	get /api/events() stream(event)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	for _, gen := range []func(dir string) error{
+		func(dir string) error { return genApi(dir, "com.example", api) },
+		func(dir string) error { return genRetrofitApi(dir, "com.example", api) },
+	} {
+		dir := t.TempDir()
+		assert.Nil(t, gen(dir))
+		b, err := ioutil.ReadFile(filepath.Join(dir, "UserApi.kt"))
+		assert.Nil(t, err)
+		assert.NotContains(t, string(b), "/api/events")
+	}
+	assert.Contains(t, buffer.String(), "the sse stream GET /api/events is skipped")
+}
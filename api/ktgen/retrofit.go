@@ -0,0 +1,502 @@
+package ktgen
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	retrofitBaseTemplate = `package {{.}}
+
+// Dependencies:
+//   com.squareup.retrofit2:retrofit:2.11.0
+//   com.squareup.retrofit2:converter-kotlinx-serialization:2.11.0
+//   com.squareup.okhttp3:okhttp:4.12.0
+//   org.jetbrains.kotlinx:kotlinx-serialization-json:1.6.3
+
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.json.Json
+import okhttp3.Interceptor
+import okhttp3.MediaType.Companion.toMediaType
+import okhttp3.OkHttpClient
+import retrofit2.HttpException
+import retrofit2.Retrofit
+import retrofit2.converter.kotlinx.serialization.asConverterFactory
+import java.io.IOException
+
+@Serializable
+data class ErrorCode(
+	val code: Int = 0,
+	val desc: String = "",
+)
+
+sealed class ApiResult<out T> {
+	data class Ok<out T>(val value: T) : ApiResult<T>()
+	data class Fail(val status: Int, val error: ErrorCode) : ApiResult<Nothing>()
+	data class NetworkError(val cause: IOException) : ApiResult<Nothing>()
+}
+
+val apiJson = Json {
+	ignoreUnknownKeys = true
+	explicitNulls = false
+	coerceInputValues = true
+}
+
+object ApiClient {
+	/**
+	 * Builds a Retrofit instance for the generated api interfaces,
+	 * timeouts and connection pools are configured on the given [client].
+	 */
+	fun retrofit(
+		baseUrl: String,
+		interceptors: List<Interceptor> = emptyList(),
+		client: OkHttpClient = OkHttpClient(),
+	): Retrofit {
+		val builder = client.newBuilder()
+		interceptors.forEach { builder.addInterceptor(it) }
+		return Retrofit.Builder()
+			.baseUrl(baseUrl)
+			.client(builder.build())
+			.addConverterFactory(apiJson.asConverterFactory("application/json".toMediaType()))
+			.build()
+	}
+}
+
+/**
+ * Runs the api call and maps the failures to [ApiResult],
+ * the error body of a non-2xx response is decoded as [ErrorCode].
+ */
+suspend fun <T> apiCall(block: suspend () -> T): ApiResult<T> = try {
+	ApiResult.Ok(block())
+} catch (e: HttpException) {
+	val body = e.response()?.errorBody()?.string().orEmpty()
+	val error = runCatching { apiJson.decodeFromString(ErrorCode.serializer(), body) }
+		.getOrElse { ErrorCode(e.code(), body) }
+	ApiResult.Fail(e.code(), error)
+} catch (e: IOException) {
+	ApiResult.NetworkError(e)
+}
+`
+	retrofitFileTemplate = `package {{.}}
+
+import okhttp3.MediaType.Companion.toMediaTypeOrNull
+import okhttp3.MultipartBody
+import okhttp3.RequestBody.Companion.asRequestBody
+import okhttp3.RequestBody.Companion.toRequestBody
+import okhttp3.ResponseBody
+import retrofit2.HttpException
+import retrofit2.Response
+import java.io.File
+import java.net.URLDecoder
+
+/** Returns the multipart part of the upload field, e.g. apiFilePart("avatar", "me.png", bytes) */
+fun apiFilePart(field: String, name: String, bytes: ByteArray, contentType: String = "application/octet-stream"): MultipartBody.Part =
+	MultipartBody.Part.createFormData(field, name, bytes.toRequestBody(contentType.toMediaTypeOrNull()))
+
+/** Returns the multipart part of the upload field, the content is streamed from the file. */
+fun apiFilePart(field: String, file: File, contentType: String = "application/octet-stream"): MultipartBody.Part =
+	MultipartBody.Part.createFormData(field, file.name, file.asRequestBody(contentType.toMediaTypeOrNull()))
+
+/** A binary response, the body must be closed once read. */
+class ApiFile(val name: String, val contentType: String?, val body: ResponseBody)
+
+/**
+ * Returns the file of a binary response named by its Content-Disposition header,
+ * a non-2xx response is thrown as HttpException so that [apiCall] maps it to [ApiResult.Fail].
+ */
+fun Response<ResponseBody>.toApiFile(): ApiFile {
+	val body = body()
+	if (!isSuccessful || body == null) {
+		throw HttpException(this)
+	}
+	return ApiFile(apiFileName(headers()["Content-Disposition"]), headers()["Content-Type"], body)
+}
+
+private fun apiFileName(disposition: String?): String {
+	if (disposition == null) {
+		return ""
+	}
+	Regex("filename\\*=[^']*'[^']*'([^;]+)", RegexOption.IGNORE_CASE).find(disposition)?.let {
+		return URLDecoder.decode(it.groupValues[1], "UTF-8")
+	}
+	return Regex("filename=\"?([^\";]+)\"?", RegexOption.IGNORE_CASE).find(disposition)?.groupValues?.get(1).orEmpty()
+}
+`
+	retrofitApiTemplate = `package {{.pkg}}
+
+import kotlinx.serialization.SerialName
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.Transient
+import kotlinx.serialization.json.JsonElement{{if .file}}
+import okhttp3.MultipartBody{{end}}{{if or .file .results}}
+import okhttp3.ResponseBody{{end}}{{if .results}}
+import retrofit2.HttpException{{end}}{{if or .file .results}}
+import retrofit2.Response{{end}}
+import retrofit2.Retrofit
+import retrofit2.http.*
+{{range .types}}
+@Serializable
+{{if eq 0 (len .Members)}}class {{.Name}}{{else}}data class {{.Name}}({{range .Members}}{{if ne .Comment ""}}
+	/** {{.Comment}} */{{end}}
+	{{.Annotation}}val {{.Name}}: {{.Type}}{{if ne .Default ""}} = {{.Default}}{{end}},{{end}}
+){{end}}
+{{end}}
+interface {{.name}} {
+{{range .routes}}{{if ne .Doc ""}}	/** {{.Doc}} */
+{{end}}{{if ne .Deprecated ""}}	@Deprecated("{{.Deprecated}}")
+{{end}}{{if .Multipart}}	@Multipart
+{{end}}{{if .Binary}}	@Streaming
+{{end}}	{{if .HasBody}}@HTTP(method = "{{.Method}}", path = "{{.Path}}", hasBody = true){{else}}@{{.Method}}("{{.Path}}"){{end}}
+	suspend fun {{.Func}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Annotation}} {{$p.Name}}: {{$p.Type}}{{end}}){{if ne .Response ""}}: {{.Response}}{{end}}
+
+{{end}}	companion object {
+		fun create(retrofit: Retrofit): {{.name}} = retrofit.create({{.name}}::class.java)
+	}{{if .errors}}
+
+	/** the codes of the errors declared in the api file, e.g. fail.error.code == {{.name}}.ErrorCodes.{{with index .errors 0}}{{.Name}}{{end}} */
+	object ErrorCodes { {{range .errors}}
+		/** {{.Desc}} */
+		const val {{.Name}} = {{.Code}}{{end}}
+	}{{end}}
+}
+{{range .routes}}{{if .Result}}
+/** The responses of {{.Func}} told apart by their status. */
+sealed class {{.Result}}(val status: Int) { {{- $result := .Result}}{{range .Responses}}
+	{{if ne .Type ""}}data class {{.Name}}(val body: {{.Body}}) : {{$result}}({{.Status}}){{else}}object {{.Name}} : {{$result}}({{.Status}}){{end}}{{end}}
+}
+
+/**
+ * Returns the response of {{.Func}} told apart by its status,
+ * the other statuses are thrown as HttpException so that [apiCall] maps them to [ApiResult.Fail].
+ */
+fun Response<ResponseBody>.to{{.Result}}(): {{.Result}} = when (code()) { {{- $result := .Result}}{{range .Responses}}
+	{{.Status}} -> {{$result}}.{{.Name}}{{if ne .Type ""}}(apiJson.decodeFromString({{.Type}}.serializer(), (body() ?: errorBody())?.string().orEmpty())){{end}}{{end}}
+	else -> throw HttpException(this)
+}
+{{end}}{{end}}{{range .routes}}{{if .Extension}}
+suspend fun {{$.name}}.{{.Func}}(req: {{.Request}}){{if ne .Response ""}}: {{.Response}}{{end}} =
+	{{.Func}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Arg}}{{end}})
+{{end}}{{end}}`
+)
+
+type (
+	ktMember struct {
+		Annotation string
+		Name       string
+		Type       string
+		Default    string
+		Comment    string
+	}
+	ktType struct {
+		Name    string
+		Members []ktMember
+	}
+	ktParam struct {
+		Annotation string
+		Name       string
+		Type       string
+		Arg        string
+	}
+	ktRoute struct {
+		Doc       string
+		Method    string
+		Path      string
+		Func      string
+		Params    []ktParam
+		Request   string
+		Response  string
+		Extension bool
+		Multipart bool
+		Binary    bool
+		// HasBody is set if the body is sent by a method retrofit sends without body, e.g. DELETE
+		HasBody bool
+		// Result is the sealed class of the Responses of a route with multiple responses
+		Result    string
+		Responses []ktResponse
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+	ktResponse struct {
+		Status int
+		Name   string
+		Type   string
+		// Body is the Type qualified by the package if the Name shadows it, e.g. com.example.NotFound
+		Body string
+	}
+)
+
+func genRetrofitBase(dir, pkg string) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "ApiClient.kt")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("ApiClient.kt already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("ApiClient.kt").Parse(retrofitBaseTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, pkg)
+}
+
+func genRetrofitApi(dir, pkg string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "retrofit")
+	name := strcase.ToCamel(api.Info.Title + "Api")
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+
+	file, e := os.OpenFile(filepath.Join(dir, name+".kt"), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	var types []ktType
+	for _, tp := range api.Types {
+		types = append(types, buildKtType(api, tp))
+	}
+	var routes []ktRoute
+	for _, route := range api.Service.Routes {
+		item, e := buildKtRoute(api, route)
+		if e != nil {
+			return e
+		}
+		for i, response := range item.Responses {
+			item.Responses[i].Body = response.Type
+			if response.Type == response.Name {
+				item.Responses[i].Body = pkg + "." + response.Type
+			}
+		}
+		routes = append(routes, item)
+	}
+
+	t, e := template.New(name).Parse(retrofitApiTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"pkg":     pkg,
+		"name":    name,
+		"types":   types,
+		"routes":  routes,
+		"file":    util.HasFileRoute(api),
+		"errors":  api.Errors,
+		"results": util.HasResponses(api),
+	})
+}
+
+// genRetrofitFile writes ApiFile.kt with the multipart and download helpers if the api uploads files or downloads binary.
+func genRetrofitFile(dir, pkg string, api *spec.ApiSpec) error {
+	if !util.HasFileRoute(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "ApiFile.kt")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("ApiFile.kt already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("ApiFile.kt").Parse(retrofitFileTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, pkg)
+}
+
+func buildKtType(api *spec.ApiSpec, tp spec.Type) ktType {
+	result := ktType{Name: strcase.ToCamel(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		typ, nullable := toKotlinType(member)
+		m := ktMember{
+			Name:    strcase.ToLowerCamel(member.Name),
+			Type:    typ,
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+		}
+		if nullable {
+			m.Type += "?"
+			m.Default = "null"
+		} else {
+			m.Default = kotlinDefaultValue(typ)
+		}
+		if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+			m.Annotation = "@Transient "
+			if len(m.Default) == 0 {
+				m.Type += "?"
+				m.Default = "null"
+			}
+		} else {
+			m.Annotation = "@SerialName(\"" + member.GetTagName() + "\") "
+		}
+		result.Members = append(result.Members, m)
+	}
+	return result
+}
+
+func buildKtRoute(api *spec.ApiSpec, route spec.Route) (ktRoute, error) {
+	members := util.GetRequestMembers(api, route)
+	result := ktRoute{
+		Doc:        strings.TrimSpace(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strings.ToUpper(route.Method),
+		Path: util.ConvertPath(route.Path, func(name string) string {
+			return "{" + name + "}"
+		}),
+		Func: util.RouteToFuncName(route.Method, route.Path),
+	}
+	if len(route.RequestType.Name) > 0 {
+		result.Request = strcase.ToCamel(route.RequestType.Name)
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		// Response keeps the headers, see toApiFile
+		result.Response = "Response<ResponseBody>"
+		result.Binary = true
+	}
+	result.Multipart = util.IsMultipart(api, route)
+	if len(route.Responses) > 0 && !route.Binary {
+		// the status is told apart from the Response, see to<Result>
+		result.Response = "Response<ResponseBody>"
+		result.Result = strcase.ToCamel(result.Func) + "Result"
+		for _, response := range route.Responses {
+			item := ktResponse{Status: response.Status, Name: util.StatusName(response.Status)}
+			if len(response.Type.Name) > 0 {
+				item.Type = strcase.ToCamel(response.Type.Name)
+			}
+			result.Responses = append(result.Responses, item)
+		}
+	}
+
+	addParams := func(annotation string, items []spec.Member) {
+		for _, member := range items {
+			typ, nullable := toKotlinType(member)
+			// a file is a transient member defaulting to null, and a null part is skipped
+			if nullable || member.IsFile() && !member.IsFileList() {
+				typ += "? = null"
+			}
+			name := strcase.ToLowerCamel(member.Name)
+			param := ktParam{
+				Annotation: "@" + annotation + "(\"" + member.GetTagName() + "\")",
+				Name:       name,
+				Type:       typ,
+				Arg:        "req." + name,
+			}
+			if member.IsFile() {
+				// the part carries the field name, see apiFilePart
+				param.Annotation = "@Part"
+			}
+			result.Params = append(result.Params, param)
+		}
+	}
+	addParams("Path", members.Path)
+	addParams("Query", members.Query)
+	addParams("Header", members.Header)
+	if len(members.Body) > 0 {
+		hasBody, e := util.RetrofitBody(route)
+		if e != nil {
+			return result, e
+		}
+		result.HasBody = hasBody
+		result.Params = append(result.Params, ktParam{
+			Annotation: "@Body",
+			Name:       "req",
+			Type:       result.Request,
+			Arg:        "req",
+		})
+	}
+	result.Extension = len(members.Path)+len(members.Query)+len(members.Header) > 0
+	return result, nil
+}
+
+// toKotlinType returns the kotlin type of the member and whether it is nullable.
+func toKotlinType(member spec.Member) (string, bool) {
+	nullable := member.IsOptional() || strings.HasPrefix(member.Type, "*")
+	return kotlinType(member.Type), nullable
+}
+
+func kotlinType(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "List<" + kotlinType(t[2:]) + ">"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "Map<" + kotlinType(tys[0]) + ", " + kotlinType(tys[1]) + ">"
+	}
+
+	switch t {
+	case "string":
+		return "String"
+	case "int8", "int16", "int32", "uint8", "uint16":
+		return "Int"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "Long"
+	case "float32":
+		return "Float"
+	case "float64":
+		return "Double"
+	case "bool":
+		return "Boolean"
+	case "interface{}":
+		return "JsonElement"
+	case "time.Time":
+		// RFC 3339 string written by encoding/json
+		return "String"
+	case spec.FileTypeName:
+		return "MultipartBody.Part"
+	default:
+		return strcase.ToCamel(t)
+	}
+}
+
+func kotlinDefaultValue(t string) string {
+	switch {
+	case strings.HasPrefix(t, "List<"):
+		return "emptyList()"
+	case strings.HasPrefix(t, "Map<"):
+		return "emptyMap()"
+	}
+	switch t {
+	case "String":
+		return `""`
+	case "Int", "Long":
+		return "0"
+	case "Float":
+		return "0f"
+	case "Double":
+		return "0.0"
+	case "Boolean":
+		return "false"
+	default:
+		return ""
+	}
+}
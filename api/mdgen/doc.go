@@ -0,0 +1,371 @@
+package mdgen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+	"github.com/urfave/cli"
+)
+
+type (
+	docSite struct {
+		Title    string
+		Label    map[string]string
+		Services []*docService
+	}
+	docService struct {
+		Name   string
+		Anchor string
+		Groups []*docGroup
+	}
+	docGroup struct {
+		Name   string
+		Desc   string
+		Jwt    bool
+		Routes []*docRoute
+	}
+	docRoute struct {
+		Service  string
+		Handler  string
+		Anchor   string
+		Page     string
+		Summary  string
+		Desc     string
+		Method   string
+		Path     string
+		File     string
+		Jwt      bool
+		Binary   bool
+		Timeout  string
+		MaxBytes string
+		Request  []docType
+		Response []docType
+		// the responses of a route with multiple responses keyed by status
+		Responses []docResponse
+		// the errors of the doc annotation, see the errors block of the api file
+		Errors []spec.Error
+		// the example json bodies, empty if there is no body
+		RequestExample  string
+		ResponseExample string
+	}
+	docResponse struct {
+		Status int
+		// the type of the body, empty if the response has none
+		Type       string
+		TypeAnchor string
+	}
+	docType struct {
+		Name   string
+		Anchor string
+		Fields []docField
+	}
+	docField struct {
+		Name       string
+		Type       string
+		TypeAnchor string
+		JsonName   string
+		In         string
+		Required   bool
+		Doc        string
+	}
+)
+
+var docLabels = map[string]map[string]string{
+	"en": {
+		"title":      "API Reference",
+		"overview":   "Overview",
+		"search":     "Search...",
+		"request":    "Request",
+		"response":   "Response",
+		"name":       "Name",
+		"type":       "Type",
+		"jsonName":   "JSON name",
+		"in":         "In",
+		"required":   "Required",
+		"optional":   "Optional",
+		"desc":       "Description",
+		"example":    "Example",
+		"jwt":        "JWT required",
+		"noRequest":  "No request parameters.",
+		"noResponse": "No response body.",
+		"binary":     "A binary file, named by the Content-Disposition header.",
+		"timeout":    "Timeout",
+		"maxBytes":   "Max body size",
+		"handler":    "Handler",
+		"source":     "Source",
+		"default":    "default",
+		"errors":     "Errors",
+		"code":       "Code",
+		"status":     "Status",
+	},
+	"zh": {
+		"title":      "API 文档",
+		"overview":   "概览",
+		"search":     "搜索...",
+		"request":    "请求",
+		"response":   "响应",
+		"name":       "名称",
+		"type":       "类型",
+		"jsonName":   "JSON 名称",
+		"in":         "位置",
+		"required":   "必填",
+		"optional":   "可选",
+		"desc":       "说明",
+		"example":    "示例",
+		"jwt":        "需要 JWT 鉴权",
+		"noRequest":  "无请求参数。",
+		"noResponse": "无响应体。",
+		"binary":     "二进制文件，文件名见 Content-Disposition 响应头。",
+		"timeout":    "超时",
+		"maxBytes":   "请求体上限",
+		"handler":    "Handler",
+		"source":     "源文件",
+		"default":    "默认",
+		"errors":     "错误",
+		"code":       "错误码",
+		"status":     "状态码",
+	},
+}
+
+func DocCommand(c *cli.Context) error {
+	dir := c.String("dir")
+	if len(dir) == 0 {
+		return errors.New("missing -dir")
+	}
+	out := c.String("o")
+	if len(out) == 0 {
+		out = "doc"
+	}
+	format := c.String("format")
+	if len(format) == 0 {
+		format = "html"
+	}
+	lang := c.String("lang")
+	if len(lang) == 0 {
+		lang = "en"
+	}
+	if _, ok := docLabels[lang]; !ok {
+		return fmt.Errorf("unsupported -lang %s, expected en or zh", lang)
+	}
+
+	files, err := filePathWalkDir(dir)
+	if err != nil {
+		return errors.New(fmt.Sprintf("dir %s not exist", dir))
+	}
+	site := &docSite{Label: docLabels[lang]}
+	for _, f := range files {
+		p, err := parser.NewParser(f)
+		if err != nil {
+			return errors.New(fmt.Sprintf("parse file: %s, err: %s", f, err.Error()))
+		}
+		api, err := p.Parse()
+		if err != nil {
+			return err
+		}
+		site.add(api, f)
+	}
+	site.Title = site.Label["title"]
+	if len(site.Services) == 1 {
+		site.Title = site.Services[0].Name + " " + site.Title
+	}
+
+	switch format {
+	case "html":
+		return genHtmlSite(out, site)
+	case "md":
+		return genMdSite(out, site)
+	default:
+		return fmt.Errorf("unsupported -format %s, expected html or md", format)
+	}
+}
+
+// add merges the routes of the api file into the site, the services and groups with the same name are merged.
+func (s *docSite) add(api *spec.ApiSpec, file string) {
+	var service *docService
+	for _, item := range s.Services {
+		if item.Name == api.Service.Name {
+			service = item
+		}
+	}
+	if service == nil {
+		service = &docService{Name: api.Service.Name, Anchor: strcase.ToKebab(api.Service.Name)}
+		s.Services = append(s.Services, service)
+	}
+
+	for _, g := range api.Service.Groups {
+		for _, route := range g.Routes {
+			name := util.GetRouteFolder(g, route)
+			if len(name) == 0 {
+				name = s.Label["default"]
+			}
+			var group *docGroup
+			for _, item := range service.Groups {
+				if item.Name == name {
+					group = item
+				}
+			}
+			if group == nil {
+				group = &docGroup{Name: name, Desc: g.Desc, Jwt: g.Jwt}
+				service.Groups = append(service.Groups, group)
+			}
+			r := buildDocRoute(api, g, route, file)
+			r.Anchor = service.uniqueAnchor(r.Anchor)
+			r.Page = service.Anchor + "/" + r.Anchor
+			group.Routes = append(group.Routes, r)
+		}
+	}
+}
+
+func (s *docService) uniqueAnchor(anchor string) string {
+	result := anchor
+	for i := 2; ; i++ {
+		exists := false
+		for _, g := range s.Groups {
+			for _, r := range g.Routes {
+				if r.Anchor == result {
+					exists = true
+				}
+			}
+		}
+		if !exists {
+			return result
+		}
+		result = anchor + "-" + strconv.Itoa(i)
+	}
+}
+
+func buildDocRoute(api *spec.ApiSpec, group spec.Group, route spec.Route, file string) *docRoute {
+	handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		handler = util.RouteToFuncName(route.Method, route.Path)
+	}
+	result := &docRoute{
+		Service: api.Service.Name,
+		Handler: handler,
+		Anchor:  strcase.ToKebab(handler),
+		Summary: route.Summary,
+		Desc:    route.Desc,
+		Method:  strings.ToUpper(route.Method),
+		Path:    route.Path,
+		File:    file,
+		Jwt:     group.Jwt,
+		Binary:  route.Binary,
+		Errors:  api.GetRouteErrors(route),
+	}
+	if len(result.Summary) == 0 {
+		result.Summary = handler
+	}
+	for _, r := range route.Responses {
+		item := docResponse{Status: r.Status, Type: r.Type.Name}
+		if len(item.Type) > 0 {
+			item.TypeAnchor = typeAnchor(item.Type)
+		}
+		result.Responses = append(result.Responses, item)
+	}
+	if group.Timeout > 0 {
+		result.Timeout = group.Timeout.String()
+	}
+	if group.MaxBytes > 0 {
+		result.MaxBytes = formatSize(group.MaxBytes)
+	}
+
+	rts, rpts := util.GetAllTypes(api, route)
+	for i, tp := range rts {
+		// only the members of the request type itself are sent as path, query or header
+		result.Request = append(result.Request, buildDocType(api, tp, i == 0, util.IsMultipart(api, route)))
+	}
+	for _, tp := range rpts {
+		result.Response = append(result.Response, buildDocType(api, tp, false, false))
+	}
+	if members := util.GetRequestMembers(api, route); len(members.Body) > 0 {
+		result.RequestExample = util.GetExampleJSON(api.Types, members.Body)
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.ResponseExample = util.GetExampleJSON(api.Types, util.FlattenMembers(api.Types, route.ResponseType))
+	}
+	return result
+}
+
+// formatSize formats size in the largest unit dividing it, e.g. 8MB.
+func formatSize(size int64) string {
+	for _, unit := range []struct {
+		name string
+		n    int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if size%unit.n == 0 {
+			return strconv.FormatInt(size/unit.n, 10) + unit.name
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+func buildDocType(api *spec.ApiSpec, tp spec.Type, request, multipart bool) docType {
+	result := docType{Name: tp.Name, Anchor: typeAnchor(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		field := docField{
+			Name:     member.Name,
+			Type:     member.Type,
+			JsonName: member.GetTagName(),
+			In:       "body",
+			Required: !member.IsOptional() && !member.IsOmitempty() && !strings.HasPrefix(member.Type, "*"),
+			Doc:      memberDoc(member),
+		}
+		if request {
+			switch {
+			case member.IsPathMember():
+				field.In = "path"
+			case member.IsFormMember() && multipart:
+				// sent as the fields of multipart/form-data along with the files
+				field.In = "form"
+			case member.IsFormMember():
+				field.In = "query"
+			case member.IsHeaderMember():
+				field.In = "header"
+			}
+		}
+		if tys, e := util.DecomposeType(member.Type); e == nil {
+			for _, t := range tys {
+				for _, item := range api.Types {
+					if item.Name == t {
+						field.TypeAnchor = typeAnchor(t)
+					}
+				}
+			}
+		}
+		result.Fields = append(result.Fields, field)
+	}
+	return result
+}
+
+func memberDoc(member spec.Member) string {
+	var lines []string
+	for _, doc := range member.Docs {
+		doc = strings.TrimSpace(strings.TrimPrefix(doc, "//"))
+		if len(doc) > 0 {
+			lines = append(lines, doc)
+		}
+	}
+	if comment := util.GetMemberComment(member); len(comment) > 0 {
+		lines = append(lines, comment)
+	}
+	return strings.Join(lines, " ")
+}
+
+func typeAnchor(name string) string {
+	return "type-" + strcase.ToKebab(name)
+}
+
+// dict builds the argument of a nested template from key value pairs.
+func dict(kv ...interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[kv[i].(string)] = kv[i+1]
+	}
+	return m
+}
@@ -0,0 +1,123 @@
+package mdgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+const userApi = `info(
+	title: user
+)
+
+type getRequest struct {
+	id    int    ` + "`path:\"id\"`" + `
+	token string ` + "`header:\"X-Token,optional\"`" + `
+}
+
+type user struct {
+	// the name of the user
+	name string ` + "`json:\"name\"`" + `
+	tags []tag  ` + "`json:\"tags\"`" + `
+}
+
+type tag struct {
+	label string ` + "`json:\"label,optional\"`" + `
+}
+
+errors {
+	UserNotFound = 1001 "user not found" 404
+	Forbidden = 1002 "a | b"
+}
+
+service user-api {
+	@doc(
+		summary: get user
+		errors: UserNotFound, Forbidden
+	)
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:id(getRequest) returns(user)
+}
+`
+
+func parseTestApi(t *testing.T, text string) *spec.ApiSpec {
+	p, e := parser.NewParserFromStr(text)
+	assert.Nil(t, e)
+	api, e := p.Parse()
+	assert.Nil(t, e)
+	return api
+}
+
+func genTestMd(t *testing.T, files ...string) string {
+	site := &docSite{Label: docLabels["en"]}
+	for _, f := range files {
+		site.add(parseTestApi(t, userApi), f)
+	}
+	dir := t.TempDir()
+	assert.Nil(t, genMdSite(dir, site))
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	b, e := ioutil.ReadFile(path)
+	assert.Nil(t, e)
+	return string(b)
+}
+
+func TestAnchors(t *testing.T) {
+	// the same handler in another api file of the service gets a numbered anchor
+	dir := genTestMd(t, "user.api", "admin.api")
+	index := readFile(t, filepath.Join(dir, "README.md"))
+	assert.Contains(t, index, `<a id="user-api"></a>`)
+	assert.Contains(t, index, "[`/api/user/:id`](user-api/get-user-handler.md#get-user-handler) get user")
+	assert.Contains(t, index, "[`/api/user/:id`](user-api/get-user-handler-2.md#get-user-handler-2) get user")
+
+	route := readFile(t, filepath.Join(dir, "user-api", "get-user-handler-2.md"))
+	assert.Contains(t, route, `<a id="get-user-handler-2"></a>`)
+	assert.Contains(t, route, `<a id="get-user-handler-2-request"></a>`)
+	assert.Contains(t, route, `<a id="get-user-handler-2-response"></a>`)
+	assert.Contains(t, route, `<a id="type-get-request"></a>`)
+}
+
+func TestRequestTable(t *testing.T) {
+	route := readFile(t, filepath.Join(genTestMd(t, "user.api"), "user-api", "get-user-handler.md"))
+	assert.Contains(t, route, "| Name | Type | JSON name | In | Required | Description |\n|---|---|---|---|---|---|\n")
+	assert.Contains(t, route, "| `id` | `int` | `id` | path | Required |  |\n")
+	assert.Contains(t, route, "| `token` | `string` | `X-Token` | header | Optional |  |\n")
+	// a request without json members has no example body
+	assert.Contains(t, route, "| Optional |  |\n\n<a id=\"get-user-handler-response\"></a>")
+}
+
+func TestResponseTable(t *testing.T) {
+	route := readFile(t, filepath.Join(genTestMd(t, "user.api"), "user-api", "get-user-handler.md"))
+	assert.Contains(t, route, "| Name | Type | JSON name | Required | Description |\n|---|---|---|---|---|\n")
+	assert.Contains(t, route, "| `name` | `string` | `name` | Required | the name of the user |\n")
+	assert.Contains(t, route, "| `tags` | [`[]tag`](#type-tag) | `tags` | Required |  |\n")
+	assert.Contains(t, route, "| `label` | `string` | `label` | Optional |  |\n")
+	assert.Contains(t, route, "```json\n{\n  \"name\": \"string\",\n  \"tags\": [\n    {\n      \"label\": \"string\"\n    }\n  ]\n}\n```")
+}
+
+func TestErrorsSection(t *testing.T) {
+	route := readFile(t, filepath.Join(genTestMd(t, "user.api"), "user-api", "get-user-handler.md"))
+	assert.Contains(t, route, `<a id="get-user-handler-errors"></a>
+
+## Errors
+
+| Name | Code | Status | Description |
+|---|---|---|---|
+| `+"`UserNotFound`"+` | 1001 | 404 | user not found |
+| `+"`Forbidden`"+` | 1002 | 400 | a \| b |
+`)
+}
+
+func TestErrorsContent(t *testing.T) {
+	api := parseTestApi(t, userApi)
+	assert.Equal(t, "| 错误 | code | 状态码 | 说明 |\n| --- | --- | --- | --- |\n"+
+		"| UserNotFound | 1001 | 404 | user not found |\n| Forbidden | 1002 | 400 | a \\| b |", errorsContent(api, api.Service.Routes[0]))
+}
@@ -0,0 +1,238 @@
+package mdgen
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	htmlTemplate = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Route}}{{.Route.Summary}} - {{end}}{{.Site.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body data-root="{{.Root}}">
+<nav class="sidebar">
+  <a class="home" href="{{.Root}}index.html">{{.Site.Title}}</a>
+  <input id="search" type="search" placeholder="{{index .Site.Label "search"}}" autocomplete="off">
+  <ul id="search-results"></ul>
+  {{$root := .Root}}{{$current := .Current}}{{range .Site.Services}}
+  <div class="service">
+    <div class="service-name">{{.Name}}</div>
+    {{range .Groups}}<div class="group">
+      <div class="group-name">{{.Name}}{{if .Jwt}} <span class="jwt" title="JWT">&#128274;</span>{{end}}</div>
+      <ul>
+        {{range .Routes}}<li{{if eq .Page $current}} class="active"{{end}}><a href="{{$root}}{{.Page}}.html#{{.Anchor}}"><span class="badge {{lower .Method}}">{{.Method}}</span>{{.Summary}}</a></li>
+        {{end}}
+      </ul>
+    </div>{{end}}
+  </div>{{end}}
+</nav>
+<main>
+{{if .Route}}{{template "route" .}}{{else}}{{template "index" .}}{{end}}
+</main>
+<script src="{{.Root}}search-index.js"></script>
+<script src="{{.Root}}search.js"></script>
+</body>
+</html>
+{{end}}
+
+{{define "index"}}{{$label := .Site.Label}}<h1>{{.Site.Title}}</h1>
+{{range .Site.Services}}<section id="{{.Anchor}}">
+<h2>{{.Name}}</h2>
+{{range .Groups}}<h3>{{.Name}}{{if .Jwt}} <span class="jwt">&#128274; {{index $label "jwt"}}</span>{{end}}</h3>
+{{if .Desc}}<p>{{.Desc}}</p>{{end}}
+<table>
+  <tr><th></th><th>{{index $label "name"}}</th><th>{{index $label "handler"}}</th></tr>
+  {{range .Routes}}<tr>
+    <td><span class="badge {{lower .Method}}">{{.Method}}</span></td>
+    <td><a href="{{.Page}}.html#{{.Anchor}}"><code>{{.Path}}</code></a> {{.Summary}}</td>
+    <td><code>{{.Handler}}</code></td>
+  </tr>{{end}}
+</table>
+{{end}}</section>
+{{end}}{{end}}
+
+{{define "route"}}{{$label := .Site.Label}}{{with .Route}}<section id="{{.Anchor}}">
+<h1>{{.Summary}}</h1>
+<p class="endpoint"><span class="badge {{lower .Method}}">{{.Method}}</span> <code>{{.Path}}</code>{{if .Jwt}} <span class="jwt">&#128274; {{index $label "jwt"}}</span>{{end}}</p>
+{{if .Desc}}<p>{{.Desc}}</p>{{end}}
+<p class="meta">{{index $label "handler"}}: <code>{{.Handler}}</code> &middot; {{index $label "source"}}: <code>{{.File}}</code>{{if .Timeout}} &middot; {{index $label "timeout"}}: {{.Timeout}}{{end}}{{if .MaxBytes}} &middot; {{index $label "maxBytes"}}: {{.MaxBytes}}{{end}}</p>
+
+<h2 id="{{.Anchor}}-request">{{index $label "request"}}</h2>
+{{if .Request}}{{range .Request}}{{template "table" dict "Type" . "Label" $label "Request" true}}{{end}}{{else}}<p>{{index $label "noRequest"}}</p>{{end}}
+{{if .RequestExample}}<h3>{{index $label "example"}}</h3>
+<pre><code>{{.RequestExample}}</code></pre>{{end}}
+
+<h2 id="{{.Anchor}}-response">{{index $label "response"}}</h2>
+{{if .Responses}}<table>
+  <tr><th>{{index $label "status"}}</th><th>{{index $label "type"}}</th></tr>
+  {{range .Responses}}<tr><td>{{.Status}}</td><td>{{if .Type}}<a href="#{{.TypeAnchor}}"><code>{{.Type}}</code></a>{{else}}-{{end}}</td></tr>{{end}}
+</table>
+{{end}}{{if .Binary}}<p>{{index $label "binary"}}</p>{{else if .Response}}{{range .Response}}{{template "table" dict "Type" . "Label" $label "Request" false}}{{end}}{{else}}<p>{{index $label "noResponse"}}</p>{{end}}
+{{if .ResponseExample}}<h3>{{index $label "example"}}</h3>
+<pre><code>{{.ResponseExample}}</code></pre>{{end}}
+{{if .Errors}}<h2 id="{{.Anchor}}-errors">{{index $label "errors"}}</h2>
+<table>
+  <tr><th>{{index $label "name"}}</th><th>{{index $label "code"}}</th><th>{{index $label "status"}}</th><th>{{index $label "desc"}}</th></tr>
+  {{range .Errors}}<tr><td><code>{{.Name}}</code></td><td>{{.Code}}</td><td>{{.Status}}</td><td>{{.Desc}}</td></tr>{{end}}
+</table>{{end}}
+</section>{{end}}{{end}}
+
+{{define "table"}}{{$label := .Label}}{{$request := .Request}}{{with .Type}}<h3 id="{{.Anchor}}"><code>{{.Name}}</code></h3>
+<table>
+  <tr><th>{{index $label "name"}}</th><th>{{index $label "type"}}</th><th>{{index $label "jsonName"}}</th>{{if $request}}<th>{{index $label "in"}}</th>{{end}}<th>{{index $label "required"}}</th><th>{{index $label "desc"}}</th></tr>
+  {{range .Fields}}<tr>
+    <td><code>{{.Name}}</code></td>
+    <td>{{if .TypeAnchor}}<a href="#{{.TypeAnchor}}"><code>{{.Type}}</code></a>{{else}}<code>{{.Type}}</code>{{end}}</td>
+    <td><code>{{.JsonName}}</code></td>
+    {{if $request}}<td>{{.In}}</td>{{end}}
+    <td>{{if .Required}}{{index $label "required"}}{{else}}{{index $label "optional"}}{{end}}</td>
+    <td>{{.Doc}}</td>
+  </tr>{{end}}
+</table>{{end}}{{end}}`
+
+	styleCss = `* { box-sizing: border-box; }
+body { margin: 0; font: 14px/1.6 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; display: flex; }
+.sidebar { width: 300px; height: 100vh; overflow-y: auto; position: sticky; top: 0; padding: 16px; background: #f6f8fa; border-right: 1px solid #e1e4e8; flex-shrink: 0; }
+.sidebar .home { display: block; font-weight: 600; font-size: 16px; margin-bottom: 12px; color: #24292e; text-decoration: none; }
+.sidebar input { width: 100%; padding: 6px 8px; border: 1px solid #d1d5da; border-radius: 4px; }
+.sidebar ul { list-style: none; margin: 0; padding: 0; }
+.sidebar li a { display: block; padding: 2px 4px; color: #24292e; text-decoration: none; border-radius: 3px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.sidebar li a:hover, .sidebar li.active a { background: #e1e4e8; }
+#search-results li a { white-space: normal; }
+.service-name { margin-top: 16px; font-weight: 600; text-transform: uppercase; color: #586069; }
+.group-name { margin-top: 8px; font-weight: 600; }
+main { flex: 1; padding: 24px 48px; max-width: 1100px; }
+table { border-collapse: collapse; width: 100%; margin: 8px 0 16px; }
+th, td { border: 1px solid #e1e4e8; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 90%; }
+pre { background: #f6f8fa; padding: 12px; overflow: auto; border-radius: 4px; }
+.badge { display: inline-block; min-width: 56px; margin-right: 6px; padding: 0 4px; border-radius: 3px; color: #fff; font-size: 11px; font-weight: 600; text-align: center; }
+.badge.get { background: #2e7d32; }
+.badge.post { background: #1565c0; }
+.badge.put { background: #ef6c00; }
+.badge.patch { background: #6a1b9a; }
+.badge.delete { background: #c62828; }
+.badge.head, .badge.options { background: #546e7a; }
+.jwt { color: #b08800; font-size: 12px; }
+.endpoint { font-size: 16px; }
+.meta { color: #586069; }
+`
+
+	searchJs = `(function () {
+  var input = document.getElementById('search');
+  var results = document.getElementById('search-results');
+  var root = document.body.getAttribute('data-root') || '';
+  var index = window.API_SEARCH_INDEX || [];
+  input.addEventListener('input', function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = '';
+    if (!words.length) {
+      return;
+    }
+    index.filter(function (item) {
+      var text = [item.summary, item.method, item.path, item.handler, item.service, item.group].join(' ').toLowerCase();
+      return words.every(function (word) { return text.indexOf(word) >= 0; });
+    }).slice(0, 50).forEach(function (item) {
+      var li = document.createElement('li');
+      var a = document.createElement('a');
+      a.href = root + item.url;
+      a.textContent = item.method + ' ' + item.path + ' ' + item.summary;
+      li.appendChild(a);
+      results.appendChild(li);
+    });
+  });
+})();
+`
+)
+
+type searchItem struct {
+	Summary string `json:"summary"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	Service string `json:"service"`
+	Group   string `json:"group"`
+	Url     string `json:"url"`
+}
+
+func genHtmlSite(dir string, site *docSite) error {
+	t, e := template.New("site").Funcs(template.FuncMap{
+		"lower": strings.ToLower,
+		"dict":  dict,
+	}).Parse(htmlTemplate)
+	if e != nil {
+		return e
+	}
+
+	render := func(path, root string, route *docRoute) error {
+		e := os.MkdirAll(filepath.Dir(path), 0755)
+		if e != nil {
+			return e
+		}
+		file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		var current string
+		if route != nil {
+			current = route.Page
+		}
+		return t.ExecuteTemplate(file, "layout", map[string]interface{}{
+			"Site":    site,
+			"Root":    root,
+			"Route":   route,
+			"Current": current,
+		})
+	}
+
+	e = render(filepath.Join(dir, "index.html"), "", nil)
+	if e != nil {
+		return e
+	}
+	var items []searchItem
+	for _, service := range site.Services {
+		for _, group := range service.Groups {
+			for _, route := range group.Routes {
+				e = render(filepath.Join(dir, route.Page+".html"), "../", route)
+				if e != nil {
+					return e
+				}
+				items = append(items, searchItem{
+					Summary: route.Summary,
+					Method:  route.Method,
+					Path:    route.Path,
+					Handler: route.Handler,
+					Service: service.Name,
+					Group:   group.Name,
+					Url:     route.Page + ".html#" + route.Anchor,
+				})
+			}
+		}
+	}
+
+	index, e := json.Marshal(items)
+	if e != nil {
+		return e
+	}
+	// a script instead of json, so that the search works with file:// urls
+	e = ioutil.WriteFile(filepath.Join(dir, "search-index.js"), []byte("window.API_SEARCH_INDEX = "+string(index)+";\n"), 0644)
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(filepath.Join(dir, "search.js"), []byte(searchJs), 0644)
+	if e != nil {
+		return e
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "style.css"), []byte(styleCss), 0644)
+}
@@ -0,0 +1,157 @@
+package mdgen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	mdIndexTemplate = `# {{.Title}}
+{{$label := .Label}}{{range .Services}}
+<a id="{{.Anchor}}"></a>
+
+## {{.Name}}
+{{range .Groups}}
+### {{.Name}}{{if .Jwt}} 🔒{{end}}
+{{if .Desc}}
+{{.Desc}}
+{{end}}
+| | {{index $label "name"}} | {{index $label "handler"}} |
+|---|---|---|
+{{range .Routes}}| ` + "`{{.Method}}`" + ` | [` + "`{{.Path}}`" + `]({{.Page}}.md#{{.Anchor}}) {{cell .Summary}} | ` + "`{{.Handler}}`" + ` |
+{{end}}{{end}}{{end}}`
+
+	mdRouteTemplate = `{{$label := .Label}}{{with .Route}}[{{index $label "overview"}}](../README.md)
+
+<a id="{{.Anchor}}"></a>
+
+# {{.Summary}}
+
+` + "`{{.Method}}` `{{.Path}}`" + `{{if .Jwt}} 🔒 {{index $label "jwt"}}{{end}}
+{{if .Desc}}
+{{.Desc}}
+{{end}}
+{{index $label "handler"}}: ` + "`{{.Handler}}`" + ` · {{index $label "source"}}: ` + "`{{.File}}`" + `{{if .Timeout}} · {{index $label "timeout"}}: {{.Timeout}}{{end}}{{if .MaxBytes}} · {{index $label "maxBytes"}}: {{.MaxBytes}}{{end}}
+
+<a id="{{.Anchor}}-request"></a>
+
+## {{index $label "request"}}
+{{if .Request}}{{range .Request}}{{template "table" dict "Type" . "Label" $label "Request" true}}{{end}}{{else}}
+{{index $label "noRequest"}}
+{{end}}{{if .RequestExample}}
+### {{index $label "example"}}
+
+` + "```json" + `
+{{.RequestExample}}
+` + "```" + `
+{{end}}
+<a id="{{.Anchor}}-response"></a>
+
+## {{index $label "response"}}
+{{if .Responses}}
+| {{index $label "status"}} | {{index $label "type"}} |
+| --- | --- |
+{{range .Responses}}| {{.Status}} | {{if .Type}}[` + "`{{.Type}}`" + `](#{{.TypeAnchor}}){{else}}-{{end}} |
+{{end}}{{end}}{{if .Binary}}
+{{index $label "binary"}}
+{{else if .Response}}{{range .Response}}{{template "table" dict "Type" . "Label" $label "Request" false}}{{end}}{{else}}
+{{index $label "noResponse"}}
+{{end}}{{if .ResponseExample}}
+### {{index $label "example"}}
+
+` + "```json" + `
+{{.ResponseExample}}
+` + "```" + `
+{{end}}{{if .Errors}}
+<a id="{{.Anchor}}-errors"></a>
+
+## {{index $label "errors"}}
+
+| {{index $label "name"}} | {{index $label "code"}} | {{index $label "status"}} | {{index $label "desc"}} |
+|---|---|---|---|
+{{range .Errors}}| ` + "`{{.Name}}`" + ` | {{.Code}} | {{.Status}} | {{cell .Desc}} |
+{{end}}{{end}}{{end}}`
+
+	mdTableTemplate = `{{define "table"}}{{$label := .Label}}{{$request := .Request}}{{with .Type}}
+<a id="{{.Anchor}}"></a>
+
+### ` + "`{{.Name}}`" + `
+
+| {{index $label "name"}} | {{index $label "type"}} | {{index $label "jsonName"}} |{{if $request}} {{index $label "in"}} |{{end}} {{index $label "required"}} | {{index $label "desc"}} |
+|---|---|---|{{if $request}}---|{{end}}---|---|
+{{range .Fields}}| ` + "`{{.Name}}`" + ` | {{if .TypeAnchor}}[` + "`{{cell .Type}}`" + `](#{{.TypeAnchor}}){{else}}` + "`{{cell .Type}}`" + `{{end}} | ` + "`{{.JsonName}}`" + ` |{{if $request}} {{.In}} |{{end}} {{if .Required}}{{index $label "required"}}{{else}}{{index $label "optional"}}{{end}} | {{cell .Doc}} |
+{{end}}{{end}}{{end}}`
+)
+
+func genMdSite(dir string, site *docSite) error {
+	funcs := template.FuncMap{
+		// cell escapes the text in a table cell
+		"cell": func(s string) string {
+			return strings.ReplaceAll(s, "|", `\|`)
+		},
+		"dict": dict,
+	}
+	index, e := template.New("index").Funcs(funcs).Parse(mdIndexTemplate)
+	if e != nil {
+		return e
+	}
+	route, e := template.New("route").Funcs(funcs).Parse(mdRouteTemplate)
+	if e != nil {
+		return e
+	}
+	route, e = route.Parse(mdTableTemplate)
+	if e != nil {
+		return e
+	}
+
+	render := func(path string, t *template.Template, data interface{}) error {
+		e := os.MkdirAll(filepath.Dir(path), 0755)
+		if e != nil {
+			return e
+		}
+		file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		return t.Execute(file, data)
+	}
+
+	e = render(filepath.Join(dir, "README.md"), index, site)
+	if e != nil {
+		return e
+	}
+	var items []searchItem
+	for _, service := range site.Services {
+		for _, group := range service.Groups {
+			for _, r := range group.Routes {
+				e = render(filepath.Join(dir, r.Page+".md"), route, map[string]interface{}{
+					"Label": site.Label,
+					"Route": r,
+				})
+				if e != nil {
+					return e
+				}
+				items = append(items, searchItem{
+					Summary: r.Summary,
+					Method:  r.Method,
+					Path:    r.Path,
+					Handler: r.Handler,
+					Service: service.Name,
+					Group:   group.Name,
+					Url:     r.Page + ".md#" + r.Anchor,
+				})
+			}
+		}
+	}
+
+	b, e := json.MarshalIndent(items, "", "  ")
+	if e != nil {
+		return e
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "search-index.json"), b, 0644)
+}
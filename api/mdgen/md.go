@@ -43,7 +43,15 @@ const (
 响应体：
 
 {{.responseContent}}  
+{{if .responsesContent}}
+响应状态：
 
+{{.responsesContent}}
+{{end}}{{if .errorsContent}}
+错误：
+
+{{.errorsContent}}
+{{end}}
 `
 )
 
@@ -76,15 +84,17 @@ func genMd(api *spec.ApiSpec, path string) error {
 		t := template.Must(template.New("markdownTemplate").Parse(markdownTemplate))
 		var tmplBytes bytes.Buffer
 		err := t.Execute(&tmplBytes, map[string]string{
-			"index":           strconv.Itoa(index + 1),
-			"routeComment":    routeComment,
-			"routeDesc":       routeDesc,
-			"method":          strings.ToUpper(route.Method),
-			"uri":             route.Path,
-			"requestType":     "`" + stringx.TakeOne(route.RequestType.Name, "-") + "`",
-			"responseType":    "`" + stringx.TakeOne(route.ResponseType.Name, "-") + "`",
-			"requestContent":  requestContent,
-			"responseContent": responseContent,
+			"index":            strconv.Itoa(index + 1),
+			"routeComment":     routeComment,
+			"routeDesc":        routeDesc,
+			"method":           strings.ToUpper(route.Method),
+			"uri":              route.Path,
+			"requestType":      "`" + stringx.TakeOne(route.RequestType.Name, "-") + "`",
+			"responseType":     "`" + stringx.TakeOne(route.ResponseType.Name, "-") + "`",
+			"requestContent":   requestContent,
+			"responseContent":  responseContent,
+			"responsesContent": responsesContent(route),
+			"errorsContent":    errorsContent(api, route),
 		})
 		if err != nil {
 			return err
@@ -110,3 +120,30 @@ func requestAndResponseBody(api *spec.ApiSpec, route spec.Route) (string, string
 	}
 	return fmt.Sprintf("```go\n%s\n```", r), fmt.Sprintf("```go\n%s\n```", rp), nil
 }
+
+// errorsContent returns the table of the errors documented by the doc annotation of the route.
+func errorsContent(api *spec.ApiSpec, route spec.Route) string {
+	errs := api.GetRouteErrors(route)
+	if len(errs) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("| 错误 | code | 状态码 | 说明 |\n| --- | --- | --- | --- |\n")
+	for _, e := range errs {
+		fmt.Fprintf(&builder, "| %s | %d | %d | %s |\n", e.Name, e.Code, e.Status, strings.ReplaceAll(e.Desc, "|", "\\|"))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// responsesContent returns the table of the responses of a route with multiple responses.
+func responsesContent(route spec.Route) string {
+	if len(route.Responses) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("| 状态码 | 响应体 |\n| --- | --- |\n")
+	for _, r := range route.Responses {
+		fmt.Fprintf(&builder, "| %d | %s |\n", r.Status, stringx.TakeOne(r.Type.Name, "-"))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 
 	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
 
 	"github.com/urfave/cli"
 )
@@ -23,10 +24,10 @@ func NodeJsCommand(c *cli.Context) error {
 	if e != nil {
 		return e
 	}
-	return jsGen(string(b), dir)
+	return jsGen(string(b), dir, c.String("version"))
 }
 
-func jsGen(apiStr, dir string) error {
+func jsGen(apiStr, dir, version string) error {
 	p, e := parser.NewParserFromStr(apiStr)
 	if e != nil {
 		return e
@@ -36,11 +37,19 @@ func jsGen(apiStr, dir string) error {
 	if e != nil {
 		return e
 	}
+	api, e = util.ScopeVersion(api, version)
+	if e != nil {
+		return e
+	}
 
 	e = genBase(dir, api)
 	if e != nil {
 		return e
 	}
+	e = genFile(dir, api)
+	if e != nil {
+		return e
+	}
 	e = genApi(dir, api)
 	if e != nil {
 		return e
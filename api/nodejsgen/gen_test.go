@@ -0,0 +1,44 @@
+package nodejsgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+	p, err := parser.NewParserFromStr(`info(
+	title: user
+)
+
+type event struct {
+	text string ` + "`json:\"text\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: EventsHandler
+	)
+	get /api/events() stream(event)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, genApi(dir, api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "user_api.js"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "/api/events")
+	assert.Contains(t, buffer.String(), "the sse stream GET /api/events is skipped")
+}
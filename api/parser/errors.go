@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/spec"
+)
+
+const errorsDirective = "errors"
+
+var (
+	errorsBlockRe = regexp.MustCompile(`^\s*` + errorsDirective + `\s*\{\s*$`)
+	// e.g. UserNotFound = 1001 "user not found" 404 // the status is optional
+	errorRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)\s*=\s*(\d+)\s+("(?:[^"\\]|\\.)*")(?:\s+(\d{3}))?(?:\s*//.*)?$`)
+)
+
+// matchErrors cuts the errors block out of api and returns the errors declared in it,
+// the lines of the block are left empty to keep the line numbers of the rest.
+func matchErrors(api string) (string, []spec.Error, error) {
+	lines := strings.Split(api, "\n")
+	var errs []spec.Error
+	found := false
+	for i := 0; i < len(lines); i++ {
+		if !errorsBlockRe.MatchString(lines[i]) {
+			continue
+		}
+		if found {
+			return "", nil, fmt.Errorf("line %d: duplicate errors block", i+1)
+		}
+		found = true
+
+		start := i
+		for {
+			lines[i] = ""
+			i++
+			if i == len(lines) {
+				return "", nil, fmt.Errorf("line %d: missing %q of the errors block", start+1, rightBrace)
+			}
+			line := strings.TrimSpace(lines[i])
+			if line == string(rightBrace) {
+				lines[i] = ""
+				break
+			}
+			if len(line) == 0 || strings.HasPrefix(line, "//") {
+				continue
+			}
+
+			match := errorRe.FindStringSubmatch(line)
+			if match == nil {
+				return "", nil, fmt.Errorf(`line %d: bad error %q, it should be like UserNotFound = 1001 "user not found" 404`, i+1, line)
+			}
+			code, err := strconv.Atoi(match[2])
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			desc, err := strconv.Unquote(match[3])
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: bad desc %s", i+1, match[3])
+			}
+			status := http.StatusBadRequest
+			if len(match[4]) > 0 {
+				status, _ = strconv.Atoi(match[4])
+			}
+			errs = append(errs, spec.Error{Name: match[1], Code: code, Desc: desc, Status: status})
+		}
+	}
+	return strings.Join(lines, "\n"), errs, nil
+}
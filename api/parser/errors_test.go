@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+const errorsApi = `info(
+	title: user
+)
+
+type user struct {
+	name string ` + "`path:\"name\"`" + `
+}
+
+errors {
+	// the users
+	UserNotFound = 1001 "user not found" 404
+	Forbidden = 1002 "no \"permission\""
+}
+
+service user-api {
+	@doc(
+		summary: get user
+		errors: UserNotFound, Forbidden
+	)
+	@server(
+		handler: GetUserHandler
+	)
+	get /users/:name(user) returns(user)
+}
+`
+
+func TestErrors(t *testing.T) {
+	p, err := NewParserFromStr(errorsApi)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []spec.Error{
+		{Name: "UserNotFound", Code: 1001, Desc: "user not found", Status: 404},
+		{Name: "Forbidden", Code: 1002, Desc: `no "permission"`, Status: 400},
+	}, api.Errors)
+	assert.Equal(t, []string{"UserNotFound", "Forbidden"}, api.Service.Routes[0].Errors)
+	assert.Equal(t, "get user", api.Service.Routes[0].Summary)
+	assert.Len(t, api.GetRouteErrors(api.Service.Routes[0]), 2)
+}
+
+func TestBadErrors(t *testing.T) {
+	for _, errors := range []string{
+		"errors {\n\tUserNotFound = 1001\n}",
+		"errors {\n\tUserNotFound = 1001 \"user not found\" 200\n}",
+		"errors {\n\tUserNotFound = 1001 \"user not found\"\n\tNotFound = 1001 \"not found\"\n}",
+		"errors {\n\tForbidden = 1002 \"forbidden\"\n}",
+		"errors {\n\tUserNotFound = 1001 \"user not found\"",
+		"errors {\n\tUserNotFound = 1001 \"user\\nnot found\"\n\tForbidden = 1002 \"forbidden\"\n}",
+	} {
+		p, err := NewParserFromStr(strings.Replace(errorsApi, "errors {\n\t// the users\n\tUserNotFound = 1001 \"user not found\" 404\n\tForbidden = 1002 \"no \\\"permission\\\"\"\n}", errors, 1))
+		if err == nil {
+			_, err = p.Parse()
+		}
+		assert.NotNil(t, err, errors)
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 
 	"github.com/gofaith/goctlr/api/spec"
 )
 
 type Parser struct {
-	r  *bufio.Reader
-	st string
+	r      *bufio.Reader
+	st     string
+	errors []spec.Error
 }
 
 func NewParser(filename string) (*Parser, error) {
@@ -24,6 +26,10 @@ func NewParser(filename string) (*Parser, error) {
 }
 
 func NewParserFromStr(str string) (*Parser, error) {
+	str, errs, err := matchErrors(str)
+	if err != nil {
+		return nil, err
+	}
 	info, body, service, err := MatchStruct(str)
 	if err != nil {
 		return nil, err
@@ -32,8 +38,9 @@ func NewParserFromStr(str string) (*Parser, error) {
 	buffer.WriteString(info)
 	buffer.WriteString(service)
 	return &Parser{
-		r:  bufio.NewReader(buffer),
-		st: body,
+		r:      bufio.NewReader(buffer),
+		st:     body,
+		errors: errs,
 	}, nil
 }
 
@@ -44,6 +51,7 @@ func (p *Parser) Parse() (*spec.ApiSpec, error) {
 		return nil, err
 	}
 	api.Types = types
+	api.Errors = p.errors
 	var lineNumber = 1
 	st := newRootState(p.r, &lineNumber)
 	for {
@@ -69,6 +77,7 @@ func (p *Parser) Parse() (*spec.ApiSpec, error) {
 			if a.Name == "doc" {
 				api.Service.Routes[i].Summary = a.Properties["summary"]
 				api.Service.Routes[i].Desc = a.Properties["desc"]
+				api.Service.Routes[i].Errors = docErrors(a)
 				break
 			}
 		}
@@ -86,9 +95,22 @@ func (p *Parser) Parse() (*spec.ApiSpec, error) {
 			for _, a := range r.Annotations {
 				if a.Name == "doc" {
 					api.Service.Groups[i].Routes[j].Summary = a.Properties["summary"]
+					api.Service.Groups[i].Routes[j].Desc = a.Properties["desc"]
+					api.Service.Groups[i].Routes[j].Errors = docErrors(a)
 				}
 			}
 		}
 	}
 	return api, nil
 }
+
+// docErrors returns the names of the errors property of a doc annotation, e.g. errors: UserNotFound,Forbidden
+func docErrors(a spec.Annotation) []string {
+	var names []string
+	for _, name := range strings.Split(a.Properties["errors"], ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -1,10 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
 )
 
 type serviceState struct {
@@ -35,25 +39,124 @@ func (s *serviceState) process(api *spec.ApiSpec) (state, error) {
 		return nil, err
 	}
 
+	group, err := s.parseGroup()
+	if err != nil {
+		return nil, err
+	}
+	// the @deprecated of the group deprecates its routes without one
+	deprecation, err := parseDeprecation(s.annos)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		routes[i].Path = joinPath(group.Prefix, routes[i].Path)
+		routes[i].Version = group.Version
+		if routes[i].Deprecation == nil {
+			routes[i].Deprecation = deprecation
+		}
+	}
+	group.Routes = routes
+
 	api.Service = spec.Service{
 		Name:        name,
 		Annotations: append(api.Service.Annotations, s.annos...),
 		Routes:      append(api.Service.Routes, routes...),
-		Groups: append(api.Service.Groups, spec.Group{
-			Annotations: s.annos,
-			Routes:      routes,
-		}),
+		Groups:      append(api.Service.Groups, group),
 	}
 
 	return newRootState(s.r, s.lineNumber), nil
 }
 
+// parseGroup parses the prefix, version, timeout and maxBytes of the @server annotation of the group.
+func (s *serviceState) parseGroup() (spec.Group, error) {
+	group := spec.Group{
+		Annotations: s.annos,
+	}
+	if prefix, ok := util.GetAnnotationValue(s.annos, "server", "prefix"); ok {
+		if !strings.HasPrefix(prefix, "/") {
+			return group, fmt.Errorf("bad prefix %q, it must start with /", prefix)
+		}
+		group.Prefix = strings.TrimSuffix(prefix, "/")
+	}
+	if version, ok := util.GetAnnotationValue(s.annos, "server", "version"); ok {
+		if !versionRe.MatchString(version) {
+			return group, fmt.Errorf("bad version %q, should be like v2 or v2beta1", version)
+		}
+		// the routes of the version are served under /api/v2 for the prefix /api
+		group.Version = version
+		group.Prefix = joinPath(group.Prefix, "/"+version)
+	}
+	if timeout, ok := util.GetAnnotationValue(s.annos, "server", "timeout"); ok {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return group, fmt.Errorf("bad timeout %q, should be like 500ms or 30s", timeout)
+		}
+		group.Timeout = d
+	}
+	if maxBytes, ok := util.GetAnnotationValue(s.annos, "server", "maxBytes"); ok {
+		size, err := spec.ParseSize(maxBytes)
+		if err != nil {
+			return group, fmt.Errorf("bad maxBytes %q, should be like 1024 or 8MB", maxBytes)
+		}
+		group.MaxBytes = size
+	}
+	return group, nil
+}
+
+// parseDeprecation parses the @deprecated annotation, nil if there isn't one.
+func parseDeprecation(annos []spec.Annotation) (*spec.Deprecation, error) {
+	for _, anno := range annos {
+		if anno.Name != deprecatedAnnotation {
+			continue
+		}
+		var deprecation spec.Deprecation
+		for key, value := range anno.Properties {
+			value = strings.TrimSpace(value)
+			switch key {
+			case "since", "sunset":
+				date, err := time.Parse(spec.DateLayout, value)
+				if err != nil {
+					return nil, fmt.Errorf("bad %s %q of @%s, should be like 2026-07-01", key, value, deprecatedAnnotation)
+				}
+				if key == "since" {
+					deprecation.Since = date
+				} else {
+					deprecation.Sunset = date
+				}
+			case "link":
+				deprecation.Link = value
+			default:
+				return nil, fmt.Errorf("unknown property %q of @%s, should be since, sunset or link", key, deprecatedAnnotation)
+			}
+		}
+		if !deprecation.Since.IsZero() && !deprecation.Sunset.IsZero() && deprecation.Sunset.Before(deprecation.Since) {
+			return nil, fmt.Errorf("the sunset of @%s is before its since", deprecatedAnnotation)
+		}
+		return &deprecation, nil
+	}
+	return nil, nil
+}
+
+// joinPath joins the group prefix and the route path, /api + / is /api.
+func joinPath(prefix, path string) string {
+	if len(prefix) == 0 {
+		return path
+	}
+	if path == "/" {
+		return prefix
+	}
+	return prefix + path
+}
+
 type serviceEntityParser struct {
 	acceptName  func(name string)
 	acceptRoute func(route spec.Route)
 }
 
 func (p *serviceEntityParser) parseLine(line string, api *spec.ApiSpec, annos []spec.Annotation) error {
+	if pos := strings.Index(line, "//"); pos >= 0 {
+		line = line[:pos]
+	}
 	fields := strings.Fields(line)
 	if len(fields) < 2 {
 		return fmt.Errorf("wrong line %q", line)
@@ -72,26 +175,89 @@ func (p *serviceEntityParser) parseLine(line string, api *spec.ApiSpec, annos []
 		return fmt.Errorf("wrong line %q", line)
 	}
 	req := pathAndRequest[:pos]
-	var returns string
-	if len(fields) > 2 {
-		returns = fields[2]
+	// returns(200: user, 404: notFound) may be split by the spaces
+	returns := strings.Join(fields[2:], "")
+	stream, ok := util.GetAnnotationValue(annos, "server", "stream")
+	if ok && stream != spec.StreamSSE && stream != spec.StreamWS {
+		return fmt.Errorf("unknown stream %q, should be %s or %s", stream, spec.StreamSSE, spec.StreamWS)
+	}
+	// stream(Event) is short for @server(stream: sse) with returns(Event)
+	if strings.HasPrefix(returns, "stream") {
+		returns = strings.TrimPrefix(returns, "stream")
+		if !ok {
+			stream = spec.StreamSSE
+		}
 	}
 	returns = strings.ReplaceAll(returns, "returns", "")
 	returns = strings.ReplaceAll(returns, "(", "")
 	returns = strings.ReplaceAll(returns, ")", "")
 	returns = strings.TrimSpace(returns)
 
-	p.acceptRoute(spec.Route{
+	deprecation, err := parseDeprecation(annos)
+	if err != nil {
+		return fmt.Errorf("%s of %q", err.Error(), line)
+	}
+	route := spec.Route{
 		Annotations:  annos,
+		Deprecation:  deprecation,
 		Method:       method,
 		Path:         path,
 		RequestType:  GetType(api, req),
 		ResponseType: GetType(api, returns),
-	})
+		Stream:       stream,
+		Binary:       returns == spec.BinaryTypeName,
+	}
+	if strings.Contains(returns, ":") {
+		responses, err := parseResponses(api, returns)
+		if err != nil {
+			return fmt.Errorf("%s of %q", err.Error(), line)
+		}
+		route.Responses = responses
+		route.ResponseType = spec.Type{}
+		for _, res := range responses {
+			if res.Status < 300 {
+				route.ResponseType = res.Type
+				break
+			}
+		}
+	}
+	p.acceptRoute(route)
 
 	return nil
 }
 
+// parseResponses parses the responses keyed by the status codes, e.g. 200:user,201:created,204,404:notFound,
+// a status without type has no body.
+func parseResponses(api *spec.ApiSpec, returns string) ([]spec.Response, error) {
+	var responses []spec.Response
+	for _, item := range strings.Split(returns, ",") {
+		pair := strings.SplitN(item, ":", 2)
+		status, err := strconv.Atoi(pair[0])
+		if err != nil || status < 200 || status > 599 {
+			return nil, fmt.Errorf("bad status %q", pair[0])
+		}
+		res := spec.Response{Status: status}
+		if len(pair) == 2 && len(pair[1]) > 0 {
+			res.Type = GetType(api, pair[1])
+			if len(res.Type.Name) == 0 {
+				return nil, fmt.Errorf("unknown type %s of the status %d", pair[1], status)
+			}
+		}
+		for _, item := range responses {
+			if item.Status == status {
+				return nil, fmt.Errorf("duplicate status %d", status)
+			}
+		}
+		responses = append(responses, res)
+	}
+	for _, res := range responses {
+		if res.Status < 300 {
+			return responses, nil
+		}
+	}
+	return nil, errors.New("missing a 2xx response")
+}
+
 func (p *serviceEntityParser) setEntityName(name string) {
 	p.acceptName(name)
 }
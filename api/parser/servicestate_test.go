@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamRoutes(t *testing.T) {
+	const text = `type (
+	request struct {
+		name string ` + "`form:\"name\"`" + `
+	}
+	event struct {
+		id int ` + "`json:\"id\"`" + `
+	}
+)
+
+service chat-api {
+	@server(
+		handler: EventsHandler
+	)
+	get /events(request) stream(event)
+
+	@server(
+		handler: ChatHandler
+		stream: ws
+	)
+	get /chat(request) returns(event)
+
+	@server(
+		handler: PingHandler
+	)
+	get /ping(request) returns(event)
+}
+`
+	p, err := NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	routes := api.Service.Routes
+	assert.Len(t, routes, 3)
+	assert.Equal(t, spec.StreamSSE, routes[0].Stream)
+	assert.Equal(t, "event", routes[0].ResponseType.Name)
+	assert.Equal(t, spec.StreamWS, routes[1].Stream)
+	assert.Equal(t, "event", routes[1].ResponseType.Name)
+	assert.Equal(t, "", routes[2].Stream)
+}
+
+func TestStreamRouteErrors(t *testing.T) {
+	for _, route := range []string{
+		"@server(handler: EventsHandler)\n\tpost /events() stream(event)",
+		"@server(handler: EventsHandler)\n\tget /events() stream()",
+		"@server(\n\t\thandler: EventsHandler\n\t\tstream: grpc\n\t)\n\tget /events() returns(event)",
+	} {
+		p, err := NewParserFromStr("type event struct {\n\tid int `json:\"id\"`\n}\n\nservice chat-api {\n\t" + route + "\n}\n")
+		assert.Nil(t, err)
+		_, err = p.Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	const text = `type request struct {
+	name string ` + "`json:\"name\"`" + `
+}
+
+@server(
+	middleware: Cors, RateLimit
+)
+service user-api {
+	@server(
+		handler: LoginHandler
+		middleware: Trace,Cors
+	)
+	post /login()
+}
+`
+	p, err := NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	g := api.Service.Groups[0]
+	assert.Equal(t, []string{"Cors", "RateLimit", "Trace"}, util.GetMiddlewares(g, g.Routes[0]))
+
+	p, err = NewParserFromStr("type request struct {\n\tname string `json:\"name\"`\n}\n\nservice user-api {\n\t@server(\n\t\thandler: LoginHandler\n\t\tmiddleware: rate-limit\n\t)\n\tpost /login()\n}\n")
+	assert.Nil(t, err)
+	_, err = p.Parse()
+	assert.Error(t, err)
+}
+
+func TestGroupOptions(t *testing.T) {
+	const text = `type request struct {
+	id int ` + "`path:\"id\"`" + `
+}
+
+@server(
+	prefix: /api/v1/admin
+	timeout: 30s
+	maxBytes: 8MB
+)
+service admin-api {
+	@server(
+		handler: UserHandler
+	)
+	get /user/:id(request)
+
+	@server(
+		handler: IndexHandler
+	)
+	get /()
+}
+
+service admin-api {
+	@server(
+		handler: HealthHandler
+	)
+	get /health()
+}
+`
+	p, err := NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	g := api.Service.Groups[0]
+	assert.Equal(t, "/api/v1/admin", g.Prefix)
+	assert.Equal(t, 30*time.Second, g.Timeout)
+	assert.Equal(t, int64(8<<20), g.MaxBytes)
+	assert.Equal(t, "/api/v1/admin/user/:id", g.Routes[0].Path)
+	assert.Equal(t, "/api/v1/admin", api.Service.Routes[1].Path)
+	assert.Equal(t, "/health", api.Service.Routes[2].Path)
+
+	for _, group := range []string{
+		"@server(\n\tprefix: api\n)\nservice admin-api {\n\t@server(handler: UserHandler)\n\tget /user()\n}\n",
+		"@server(\n\ttimeout: 30\n)\nservice admin-api {\n\t@server(handler: UserHandler)\n\tget /user()\n}\n",
+		"@server(\n\tmaxBytes: 8M\n)\nservice admin-api {\n\t@server(handler: UserHandler)\n\tget /user()\n}\n",
+		"@server(\n\tprefix: /api\n)\nservice admin-api {\n\t@server(handler: UserHandler)\n\tget /user/:id()\n}\n\nservice admin-api {\n\t@server(handler: ApiUserHandler)\n\tget /api/user/:name()\n}\n",
+	} {
+		p, err := NewParserFromStr("type request struct {\n\tname string `json:\"name\"`\n}\n\n" + group)
+		assert.Nil(t, err)
+		_, err = p.Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	const text = `type (
+	user struct {
+		name string ` + "`json:\"name\"`" + `
+	}
+	notFound struct {
+		reason string ` + "`json:\"reason\"`" + `
+	}
+)
+
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /user/:name(user) returns(200: user, 202, 404: notFound) // the user
+
+	@server(
+		handler: CreateUserHandler
+	)
+	post /user(user) returns(user)
+}
+`
+	p, err := NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	route := api.Service.Routes[0]
+	assert.Equal(t, "user", route.ResponseType.Name)
+	assert.Len(t, route.Responses, 3)
+	assert.Equal(t, 202, route.Responses[1].Status)
+	assert.Equal(t, "", route.Responses[1].Type.Name)
+	assert.Equal(t, "notFound", route.Responses[2].Type.Name)
+	assert.Nil(t, api.Service.Routes[1].Responses)
+	assert.Equal(t, "user", api.Service.Routes[1].ResponseType.Name)
+
+	for _, returns := range []string{
+		"returns(404: user)",
+		"returns(200: user, 200: user)",
+		"returns(200: member)",
+		"returns(2000: user)",
+		"stream(200: user)",
+	} {
+		p, err := NewParserFromStr(strings.Replace(text, "returns(200: user, 202, 404: notFound)", returns, 1))
+		assert.Nil(t, err)
+		_, err = p.Parse()
+		assert.Error(t, err, returns)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	const text = `type user struct {
+	name string ` + "`path:\"name\"`" + `
+}
+
+@server(
+	prefix: /api
+)
+@deprecated(
+	since: 2026-01-01
+	sunset: 2026-07-01
+	link: https://example.com/docs/v2
+)
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /user/:name(user)
+}
+
+@server(
+	prefix: /api
+	version: v2
+)
+service user-api {
+	@server(
+		handler: GetUserHandler
+	)
+	get /user/:name(user)
+
+	@deprecated(sunset: 2026-12-31)
+	@server(
+		handler: ListUsersHandler
+	)
+	get /users()
+}
+`
+	p, err := NewParserFromStr(text)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	v1, v2 := api.Service.Routes[0], api.Service.Routes[1]
+	assert.Equal(t, "/api/user/:name", v1.Path)
+	assert.Equal(t, "", v1.Version)
+	assert.Equal(t, "/api/v2/user/:name", v2.Path)
+	assert.Equal(t, "v2", v2.Version)
+	assert.Equal(t, "/api/v2", api.Service.Groups[1].Prefix)
+	assert.Equal(t, "v2", api.Service.Groups[1].Version)
+
+	assert.Nil(t, v2.Deprecation)
+	assert.Equal(t, "@1767225600", v1.Deprecation.DeprecationHeader())
+	assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", v1.Deprecation.SunsetHeader())
+	assert.Equal(t, `<https://example.com/docs/v2>; rel="deprecation"`, v1.Deprecation.LinkHeader())
+	deprecation := api.Service.Routes[2].Deprecation
+	assert.Equal(t, "true", deprecation.DeprecationHeader())
+	assert.Equal(t, "deprecated, removed on 2026-12-31", deprecation.Message())
+
+	for old, bad := range map[string]string{
+		"version: v2":        "version: 2",
+		"since: 2026-01-01":  "since: 2026/01/01",
+		"sunset: 2026-07-01": "sunset: 2025-07-01",
+		"link: https":        "url: https",
+		"ListUsersHandler":   "GetUserHandler",
+	} {
+		p, err := NewParserFromStr(strings.Replace(text, old, bad, 1))
+		assert.Nil(t, err)
+		_, err = p.Parse()
+		assert.Error(t, err, bad)
+	}
+}
@@ -189,6 +189,8 @@ func parseType(expr ast.Expr) (interface{}, string, error) {
 	case *ast.Ident:
 		if isBasicType(v.Name) {
 			return &spec.BasicType{Name: v.Name, StringExpr: v.Name}, v.Name, nil
+		} else if v.Name == spec.FileTypeName && v.Obj == nil {
+			return &spec.FileType{StringExpr: v.Name}, v.Name, nil
 		} else if v.Obj != nil {
 			obj := v.Obj
 			if obj.Name != v.Name { // 防止引用自己而无限递归
@@ -3,6 +3,8 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
 
 	"github.com/gofaith/go-zero/core/stringx"
@@ -10,6 +12,12 @@ import (
 	"github.com/gofaith/goctlr/api/util"
 )
 
+var (
+	middlewareRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// the versions name the packages of their handlers, e.g. v2 or v2beta1
+	versionRe = regexp.MustCompile(`^v[0-9]+[a-z0-9]*$`)
+)
+
 func (p *Parser) validate(api *spec.ApiSpec) (err error) {
 	var builder strings.Builder
 	for _, tp := range api.Types {
@@ -20,12 +28,106 @@ func (p *Parser) validate(api *spec.ApiSpec) (err error) {
 	if ok, info := p.validateDuplicateRouteHandler(api); !ok {
 		fmt.Fprintf(&builder, info)
 	}
+	p.validateFiles(api, &builder)
+	p.validateMiddlewares(api, &builder)
+	p.validateOverlappingRoutes(api, &builder)
+	p.validateErrors(api, &builder)
+	for _, r := range api.Service.Routes {
+		if len(r.Stream) == 0 {
+			continue
+		}
+		if r.Binary {
+			fmt.Fprintf(&builder, "the %s stream %s can't return binary\n", r.Stream, r.Path)
+		}
+		if len(r.Responses) > 0 {
+			fmt.Fprintf(&builder, "the %s stream %s can't have multiple responses\n", r.Stream, r.Path)
+		}
+		if len(r.ResponseType.Name) == 0 {
+			fmt.Fprintf(&builder, "missing event type of the %s stream %s\n", r.Stream, r.Path)
+		}
+		// EventSource and the websocket handshake can only get
+		if r.Method != "get" {
+			fmt.Fprintf(&builder, "the %s stream %s must be a get route\n", r.Stream, r.Path)
+		}
+	}
 	if len(builder.String()) > 0 {
 		return errors.New(builder.String())
 	}
 	return nil
 }
 
+// validateMiddlewares checks the middleware names, they become the fields of the ServiceContext.
+func (p *Parser) validateMiddlewares(api *spec.ApiSpec, builder *strings.Builder) {
+	seen := make(map[string]bool)
+	for _, g := range api.Service.Groups {
+		for _, r := range g.Routes {
+			for _, name := range util.GetMiddlewares(g, r) {
+				if !seen[name] && !middlewareRe.MatchString(name) {
+					fmt.Fprintf(builder, "bad middleware name %q of %s, it must be an identifier\n", name, r.Path)
+				}
+				seen[name] = true
+			}
+		}
+	}
+}
+
+// validateErrors checks the errors block and the errors of the doc annotations of the routes,
+// the codes 0 and the duplicates can't be told apart by the clients.
+func (p *Parser) validateErrors(api *spec.ApiSpec, builder *strings.Builder) {
+	names := make(map[string]bool)
+	codes := make(map[int]string)
+	for _, e := range api.Errors {
+		if names[e.Name] {
+			fmt.Fprintf(builder, "duplicate error %s\n", e.Name)
+		}
+		names[e.Name] = true
+		if e.Code == 0 {
+			fmt.Fprintf(builder, "the code of the error %s can't be 0\n", e.Name)
+		} else if name, ok := codes[e.Code]; ok {
+			fmt.Fprintf(builder, "the errors %s and %s have the same code %d\n", name, e.Name, e.Code)
+		}
+		codes[e.Code] = e.Name
+		if strings.ContainsAny(e.Desc, "\r\n") {
+			fmt.Fprintf(builder, "the desc of the error %s can't have line breaks\n", e.Name)
+		}
+		if e.Status < 400 || e.Status > 599 {
+			fmt.Fprintf(builder, "the status %d of the error %s isn't an http error status\n", e.Status, e.Name)
+		}
+	}
+	for _, r := range api.Service.Routes {
+		for _, a := range r.Annotations {
+			if a.Name != "doc" {
+				continue
+			}
+			for _, name := range docErrors(a) {
+				if !names[name] {
+					fmt.Fprintf(builder, "the error %s of %s isn't declared in the errors block\n", name, r.Path)
+				}
+			}
+		}
+	}
+}
+
+// validateOverlappingRoutes checks the routes with the group prefixes joined, two routes overlap
+// if they have the same method and the same path regardless of the names of the path variables.
+func (p *Parser) validateOverlappingRoutes(api *spec.ApiSpec, builder *strings.Builder) {
+	seen := make(map[string]string)
+	for _, r := range api.Service.Routes {
+		segments := strings.Split(r.Path, "/")
+		for i, seg := range segments {
+			if strings.HasPrefix(seg, ":") {
+				segments[i] = ":"
+			}
+		}
+		key := r.Method + " " + strings.Join(segments, "/")
+		if path, ok := seen[key]; ok {
+			fmt.Fprintf(builder, "the route %s %s overlaps %s %s\n", r.Method, r.Path, r.Method, path)
+			continue
+		}
+		seen[key] = r.Path
+	}
+}
+
 func (p *Parser) validateDuplicateProperty(tp spec.Type) (bool, string) {
 	var names []string
 	for _, member := range tp.Members {
@@ -45,11 +147,53 @@ func (p *Parser) validateDuplicateRouteHandler(api *spec.ApiSpec) (bool, string)
 		if !ok {
 			return false, fmt.Sprintf("missing handler annotation for %s", r.Path)
 		}
-		if stringx.Contains(names, handler) {
+		// the handlers of the versions are generated in their own packages, e.g. v2.GetUserHandler
+		name := path.Join(r.Version, handler)
+		if stringx.Contains(names, name) {
 			return false, fmt.Sprintf(`duplicated handler for name "%s"`, handler)
 		} else {
-			names = append(names, handler)
+			names = append(names, name)
 		}
 	}
 	return true, ""
 }
+
+// validateFiles checks the file members, they can only be sent as multipart form fields of a request.
+func (p *Parser) validateFiles(api *spec.ApiSpec, builder *strings.Builder) {
+	for _, r := range api.Service.Routes {
+		_, responseTypes := util.GetAllTypes(api, r)
+		for _, tp := range responseTypes {
+			for _, member := range tp.Members {
+				if member.IsFile() {
+					fmt.Fprintf(builder, "the file member %s of type %s can't be returned by %s, use returns(binary)\n", member.Name, tp.Name, r.Path)
+				}
+			}
+		}
+
+		members := util.FlattenMembers(api.Types, r.RequestType)
+		hasFile := false
+		for _, member := range members {
+			if !member.IsFile() {
+				continue
+			}
+			hasFile = true
+			if !member.IsFormMember() {
+				fmt.Fprintf(builder, "the file member %s of type %s must have a form tag\n", member.Name, r.RequestType.Name)
+			}
+			if _, err := member.GetMaxSize(); err != nil {
+				fmt.Fprintln(builder, err.Error())
+			}
+		}
+		if !hasFile {
+			continue
+		}
+		if r.Method == "get" || r.Method == "head" {
+			fmt.Fprintf(builder, "the files of %s %s can't be uploaded without a request body\n", r.Method, r.Path)
+		}
+		for _, member := range members {
+			if member.IsBodyMember() {
+				fmt.Fprintf(builder, "the multipart request %s of %s can't have the json member %s, use a form tag\n", r.RequestType.Name, r.Path, member.Name)
+			}
+		}
+	}
+}
@@ -13,4 +13,6 @@ const (
 	rightBrace        = '}'
 	multilineBeginTag = '>'
 	multilineEndTag   = '<'
+	// deprecates the route or the group it's put on, e.g. @deprecated(sunset: 2026-07-01)
+	deprecatedAnnotation = "deprecated"
 )
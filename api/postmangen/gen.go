@@ -0,0 +1,217 @@
+package postmangen
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/urfave/cli"
+)
+
+const schemaUrl = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type (
+	collection struct {
+		Info     info       `json:"info"`
+		Item     []item     `json:"item"`
+		Variable []variable `json:"variable"`
+	}
+	info struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Schema      string `json:"schema"`
+	}
+	item struct {
+		Name    string   `json:"name"`
+		Item    []item   `json:"item,omitempty"`
+		Request *request `json:"request,omitempty"`
+	}
+	request struct {
+		Method      string     `json:"method"`
+		Header      []variable `json:"header"`
+		Url         url        `json:"url"`
+		Body        *body      `json:"body,omitempty"`
+		Description string     `json:"description,omitempty"`
+	}
+	url struct {
+		Raw      string     `json:"raw"`
+		Host     []string   `json:"host"`
+		Path     []string   `json:"path"`
+		Query    []variable `json:"query,omitempty"`
+		Variable []variable `json:"variable,omitempty"`
+	}
+	body struct {
+		Mode     string      `json:"mode"`
+		Raw      string      `json:"raw,omitempty"`
+		Formdata []formField `json:"formdata,omitempty"`
+		Options  interface{} `json:"options,omitempty"`
+	}
+	formField struct {
+		Key         string `json:"key"`
+		Value       string `json:"value,omitempty"`
+		Type        string `json:"type"`
+		Src         string `json:"src,omitempty"`
+		Description string `json:"description,omitempty"`
+		Disabled    bool   `json:"disabled,omitempty"`
+	}
+	variable struct {
+		Key         string `json:"key"`
+		Value       string `json:"value"`
+		Description string `json:"description,omitempty"`
+		Disabled    bool   `json:"disabled,omitempty"`
+	}
+)
+
+func PostmanCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+	return genCollection(dir, c.String("baseurl"), api)
+}
+
+func genCollection(dir, baseUrl string, api *spec.ApiSpec) error {
+	if len(baseUrl) == 0 {
+		baseUrl = util.GetBaseUrl(api)
+	}
+	result := collection{
+		Info: info{
+			Name:        api.Info.Title,
+			Description: api.Info.Desc,
+			Schema:      schemaUrl,
+		},
+		Variable: []variable{
+			{Key: "baseUrl", Value: baseUrl},
+			{Key: "token", Value: "", Description: "the Authorization header of the jwt routes"},
+		},
+	}
+
+	folders := make(map[string]int)
+	for _, group := range api.Service.Groups {
+		for _, route := range group.Routes {
+			it := buildItem(api, group, route)
+			folder := util.GetRouteFolder(group, route)
+			if len(folder) == 0 {
+				result.Item = append(result.Item, it)
+				continue
+			}
+			i, ok := folders[folder]
+			if !ok {
+				i = len(result.Item)
+				folders[folder] = i
+				result.Item = append(result.Item, item{Name: folder})
+			}
+			result.Item[i].Item = append(result.Item[i].Item, it)
+		}
+	}
+
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	b, e := json.MarshalIndent(result, "", "  ")
+	if e != nil {
+		return e
+	}
+	return ioutil.WriteFile(filepath.Join(dir, api.Info.Title+".postman_collection.json"), b, 0644)
+}
+
+func buildItem(api *spec.ApiSpec, group spec.Group, route spec.Route) item {
+	members := util.GetRequestMembers(api, route)
+	req := request{
+		Method:      strings.ToUpper(route.Method),
+		Header:      []variable{},
+		Description: route.Desc,
+	}
+	if group.Jwt {
+		req.Header = append(req.Header, variable{Key: "Authorization", Value: "{{token}}"})
+	}
+	for _, member := range members.Header {
+		req.Header = append(req.Header, variable{
+			Key:         member.GetTagName(),
+			Description: util.GetMemberComment(member),
+			Disabled:    member.IsOptional(),
+		})
+	}
+
+	path := util.ConvertPath(route.Path, func(name string) string {
+		member, _ := members.GetPathMember(name)
+		req.Url.Variable = append(req.Url.Variable, variable{
+			Key:         name,
+			Description: util.GetMemberComment(member),
+		})
+		return ":" + name
+	})
+	var query []string
+	if util.IsMultipart(api, route) {
+		// the form members are sent as the fields along with the files, postman sets the boundary
+		req.Body = &body{Mode: "formdata", Formdata: []formField{}}
+		for _, member := range members.Query {
+			field := formField{
+				Key:         member.GetTagName(),
+				Type:        "text",
+				Description: util.GetMemberComment(member),
+				Disabled:    member.IsOptional(),
+			}
+			if member.IsFile() {
+				field.Type = "file"
+			}
+			req.Body.Formdata = append(req.Body.Formdata, field)
+		}
+		members.Query = nil
+	}
+	for _, member := range members.Query {
+		req.Url.Query = append(req.Url.Query, variable{
+			Key:         member.GetTagName(),
+			Description: util.GetMemberComment(member),
+			Disabled:    member.IsOptional(),
+		})
+		if !member.IsOptional() {
+			query = append(query, member.GetTagName()+"=")
+		}
+	}
+	req.Url.Raw = "{{baseUrl}}" + path
+	if len(query) > 0 {
+		req.Url.Raw += "?" + strings.Join(query, "&")
+	}
+	req.Url.Host = []string{"{{baseUrl}}"}
+	req.Url.Path = strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	if len(members.Body) > 0 {
+		req.Header = append(req.Header, variable{Key: "Content-Type", Value: "application/json"})
+		req.Body = &body{
+			Mode: "raw",
+			Raw:  util.GetExampleJSON(api.Types, members.Body),
+			Options: map[string]interface{}{
+				"raw": map[string]string{"language": "json"},
+			},
+		}
+	}
+	return item{
+		Name:    util.GetRouteName(route),
+		Request: &req,
+	}
+}
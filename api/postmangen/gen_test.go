@@ -0,0 +1,118 @@
+package postmangen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const userApi = `info(
+	title: user
+	desc: user api
+)
+
+type address struct {
+	city string ` + "`json:\"city\"`" + `
+}
+
+type setRequest struct {
+	id      int     ` + "`path:\"id\"`" + `
+	token   string  ` + "`header:\"X-Token\"`" + `
+	trace   string  ` + "`header:\"X-Trace,optional\"`" + `
+	name    string  ` + "`json:\"name\"`" + `
+	age     int     ` + "`json:\"age\"`" + `
+	address address ` + "`json:\"address\"`" + `
+}
+
+type listRequest struct {
+	page int    ` + "`form:\"page\"`" + `
+	sort string ` + "`form:\"sort,optional\"`" + `
+}
+
+@server(
+	jwt: Auth
+	folder: user
+)
+service user-api {
+	@doc(
+		summary: set user
+	)
+	@server(
+		handler: SetUserHandler
+	)
+	post /api/user/:id(setRequest)
+
+	@server(
+		handler: ListUserHandler
+	)
+	get /api/users(listRequest)
+}
+`
+
+func genTestCollection(t *testing.T) collection {
+	p, e := parser.NewParserFromStr(userApi)
+	assert.Nil(t, e)
+	api, e := p.Parse()
+	assert.Nil(t, e)
+
+	dir := t.TempDir()
+	assert.Nil(t, genCollection(dir, "", api))
+	b, e := ioutil.ReadFile(filepath.Join(dir, "user.postman_collection.json"))
+	assert.Nil(t, e)
+	var result collection
+	assert.Nil(t, json.Unmarshal(b, &result))
+	return result
+}
+
+func TestCollection(t *testing.T) {
+	result := genTestCollection(t)
+	assert.Equal(t, info{Name: "user", Description: "user api", Schema: schemaUrl}, result.Info)
+	assert.Equal(t, variable{Key: "baseUrl", Value: "http://localhost:8888"}, result.Variable[0])
+	assert.Len(t, result.Item, 1)
+	assert.Equal(t, "user", result.Item[0].Name)
+	assert.Len(t, result.Item[0].Item, 2)
+}
+
+func TestBody(t *testing.T) {
+	req := genTestCollection(t).Item[0].Item[0].Request
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "raw", req.Body.Mode)
+	// only the json members are in the body
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(req.Body.Raw), &body))
+	assert.Equal(t, map[string]interface{}{
+		"name":    "string",
+		"age":     float64(0),
+		"address": map[string]interface{}{"city": "string"},
+	}, body)
+}
+
+func TestPathVariables(t *testing.T) {
+	req := genTestCollection(t).Item[0].Item[0].Request
+	assert.Equal(t, "{{baseUrl}}/api/user/:id", req.Url.Raw)
+	assert.Equal(t, []string{"{{baseUrl}}"}, req.Url.Host)
+	assert.Equal(t, []string{"api", "user", ":id"}, req.Url.Path)
+	assert.Equal(t, []variable{{Key: "id"}}, req.Url.Variable)
+
+	req = genTestCollection(t).Item[0].Item[1].Request
+	assert.Equal(t, "{{baseUrl}}/api/users?page=", req.Url.Raw)
+	assert.Equal(t, []variable{{Key: "page"}, {Key: "sort", Disabled: true}}, req.Url.Query)
+}
+
+func TestHeaders(t *testing.T) {
+	req := genTestCollection(t).Item[0].Item[0].Request
+	assert.Equal(t, []variable{
+		{Key: "Authorization", Value: "{{token}}"},
+		{Key: "X-Token"},
+		{Key: "X-Trace", Disabled: true},
+		{Key: "Content-Type", Value: "application/json"},
+	}, req.Header)
+
+	req = genTestCollection(t).Item[0].Item[1].Request
+	assert.Equal(t, []variable{{Key: "Authorization", Value: "{{token}}"}}, req.Header)
+	assert.Nil(t, req.Body)
+}
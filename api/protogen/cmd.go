@@ -0,0 +1,34 @@
+package protogen
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/urfave/cli"
+)
+
+func ProtoCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	out := c.String("o")
+	if out == "" {
+		out = strings.TrimSuffix(apiFile, ".api") + ".proto"
+	}
+	lock := c.String("lock")
+	if lock == "" {
+		lock = out + ".lock"
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	return genProto(out, lock, c.String("package"), api)
+}
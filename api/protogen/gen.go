@@ -0,0 +1,424 @@
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	protoTemplate = `// Code generated by goctlr. DO NOT EDIT.
+// The field numbers are kept in {{.Lock}}, commit it along with this file.
+syntax = "proto3";
+
+package {{.Package}};
+{{if .Imports}}
+{{range .Imports}}import "{{.}}";
+{{end}}{{end}}{{range .Messages}}
+{{range .Docs}}// {{.}}
+{{end}}message {{.Name}} {
+{{- if .ReservedNumbers}}
+  reserved {{.ReservedNumbers}};
+  reserved {{.ReservedNames}};
+{{- end}}
+{{range .Fields}}{{range .Docs}}  // {{.}}
+{{end}}  {{if .Label}}{{.Label}} {{end}}{{.Type}} {{.Name}} = {{.Number}}{{if .JsonName}} [json_name = "{{.JsonName}}"]{{end}};{{if .Comment}} // {{.Comment}}{{end}}
+{{end}}}
+{{end}}
+service {{.Service}} {
+{{range .Rpcs}}{{if .Summary}}  // {{.Summary}}
+{{end}}  rpc {{.Name}}({{.Request}}) returns({{.Response}});
+{{end}}}
+`
+
+	timestampImport = "google/protobuf/timestamp.proto"
+	structImport    = "google/protobuf/struct.proto"
+)
+
+var (
+	packageRe = regexp.MustCompile(`[^a-z0-9_]`)
+
+	scalarTypes = map[string]string{
+		"bool":    "bool",
+		"string":  "string",
+		"int":     "int64",
+		"int8":    "int32",
+		"int16":   "int32",
+		"int32":   "int32",
+		"int64":   "int64",
+		"uint":    "uint64",
+		"uint8":   "uint32",
+		"uint16":  "uint32",
+		"uint32":  "uint32",
+		"uint64":  "uint64",
+		"byte":    "uint32",
+		"rune":    "int32",
+		"float32": "float",
+		"float64": "double",
+	}
+
+	// the go types protoc-gen-go uses for the proto scalar types
+	goTypes = map[string]string{
+		"bool":   "bool",
+		"string": "string",
+		"int32":  "int32",
+		"int64":  "int64",
+		"uint32": "uint32",
+		"uint64": "uint64",
+		"float":  "float32",
+		"double": "float64",
+	}
+)
+
+type (
+	protoFile struct {
+		Lock     string
+		Package  string
+		Service  string
+		Imports  []string
+		Messages []*message
+		Rpcs     []rpc
+	}
+	message struct {
+		Name   string
+		Docs   []string
+		Fields []field
+		// the numbers and names of the removed fields, e.g. 2, 5 and "zip", "city"
+		ReservedNumbers string
+		ReservedNames   string
+	}
+	field struct {
+		Label  string
+		Type   string
+		Name   string
+		Number int
+		// set if the json name of the member differs from the default json name of the field
+		JsonName string
+		Docs     []string
+		Comment  string
+	}
+	rpc struct {
+		Name     string
+		Request  string
+		Response string
+		Summary  string
+	}
+
+	// fieldLock keeps the field numbers of every message, message name -> field name -> number.
+	// Removed fields stay in it so their numbers are never reused.
+	fieldLock map[string]map[string]int
+)
+
+func genProto(out, lockFile, pkg string, api *spec.ApiSpec) error {
+	lock, e := readLock(lockFile)
+	if e != nil {
+		return e
+	}
+
+	base := strings.TrimSuffix(api.Service.Name, "-api")
+	if pkg == "" {
+		pkg = packageRe.ReplaceAllString(strings.ToLower(strcase.ToSnake(base)), "")
+	}
+	file := &protoFile{
+		Lock:    filepath.Base(lockFile),
+		Package: pkg,
+		Service: strcase.ToCamel(base),
+	}
+	imports := make(map[string]bool)
+
+	messageNames := make(map[string]bool)
+	for _, tp := range api.Types {
+		msg, e := buildMessage(api, tp, lock, imports)
+		if e != nil {
+			return e
+		}
+		messageNames[msg.Name] = true
+		file.Messages = append(file.Messages, msg)
+	}
+
+	// an rpc always takes and returns a message, the routes without a request or response type use an empty one
+	empty := "Empty"
+	for i := 2; messageNames[empty]; i++ {
+		empty = "Empty" + strconv.Itoa(i)
+	}
+	needEmpty := false
+	for _, g := range api.Service.Groups {
+		for _, route := range g.Routes {
+			if route.Binary {
+				return fmt.Errorf("%s %s is not supported, a binary response has no message", route.Method, route.Path)
+			}
+			r := rpc{
+				Name:     rpcName(route),
+				Request:  empty,
+				Response: empty,
+				Summary:  route.Summary,
+			}
+			if len(route.RequestType.Name) > 0 {
+				r.Request = MessageName(route.RequestType.Name)
+			} else {
+				needEmpty = true
+			}
+			if len(route.ResponseType.Name) > 0 {
+				r.Response = MessageName(route.ResponseType.Name)
+			} else {
+				needEmpty = true
+			}
+			file.Rpcs = append(file.Rpcs, r)
+		}
+	}
+	if needEmpty {
+		file.Messages = append(file.Messages, &message{Name: empty})
+	}
+
+	for item := range imports {
+		file.Imports = append(file.Imports, item)
+	}
+	sort.Strings(file.Imports)
+
+	t, e := template.New("proto").Parse(protoTemplate)
+	if e != nil {
+		return e
+	}
+	fo, e := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer fo.Close()
+	e = t.Execute(fo, file)
+	if e != nil {
+		return e
+	}
+	return writeLock(lockFile, lock)
+}
+
+func readLock(path string) (fieldLock, error) {
+	lock := make(fieldLock)
+	b, e := ioutil.ReadFile(path)
+	if os.IsNotExist(e) {
+		return lock, nil
+	}
+	if e != nil {
+		return nil, e
+	}
+	e = json.Unmarshal(b, &lock)
+	if e != nil {
+		return nil, fmt.Errorf("bad lock file %s: %s", path, e.Error())
+	}
+	return lock, nil
+}
+
+func writeLock(path string, lock fieldLock) error {
+	b, e := json.MarshalIndent(lock, "", "  ")
+	if e != nil {
+		return e
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+func buildMessage(api *spec.ApiSpec, tp spec.Type, lock fieldLock, imports map[string]bool) (*message, error) {
+	msg := &message{Name: MessageName(tp.Name)}
+	numbers := lock[msg.Name]
+	if numbers == nil {
+		numbers = make(map[string]int)
+		lock[msg.Name] = numbers
+	}
+	next := 1
+	for _, n := range numbers {
+		if n >= next {
+			next = n + 1
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		label, typ, e := protoType(api, member.Type, imports)
+		if e != nil {
+			return nil, fmt.Errorf("type %s, member %s: %s", tp.Name, member.Name, e.Error())
+		}
+		name := FieldName(member)
+		number, ok := numbers[name]
+		if !ok {
+			number = next
+			next++
+			numbers[name] = number
+		}
+		used[name] = true
+		f := field{
+			Label:   label,
+			Type:    typ,
+			Name:    name,
+			Number:  number,
+			Docs:    memberDocs(member),
+			Comment: util.GetMemberComment(member),
+		}
+		// keeps the json of the api file, so the same message can be sent as json or protobuf
+		if member.IsBodyMember() && member.GetTagName() != util.LowerFirst(GoFieldName(member)) {
+			f.JsonName = member.GetTagName()
+		}
+		msg.Fields = append(msg.Fields, f)
+	}
+
+	var removed []string
+	owners := make(map[int]string)
+	for name, number := range numbers {
+		if !used[name] {
+			removed = append(removed, name)
+		}
+		if number < 1 {
+			return nil, fmt.Errorf("message %s: bad number %d of field %s in the lock file", msg.Name, number, name)
+		}
+		if owner, ok := owners[number]; ok {
+			if used[owner] {
+				owner, name = name, owner
+			}
+			return nil, fmt.Errorf("message %s: field %s reuses the number %d of field %s in the lock file",
+				msg.Name, name, number, owner)
+		}
+		owners[number] = name
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return numbers[removed[i]] < numbers[removed[j]]
+	})
+	var reservedNumbers, reservedNames []string
+	for _, name := range removed {
+		reservedNumbers = append(reservedNumbers, strconv.Itoa(numbers[name]))
+		reservedNames = append(reservedNames, strconv.Quote(name))
+	}
+	msg.ReservedNumbers = strings.Join(reservedNumbers, ", ")
+	msg.ReservedNames = strings.Join(reservedNames, ", ")
+	return msg, nil
+}
+
+// protoType maps a go type of the api file to a proto field type, with the repeated label for slices.
+func protoType(api *spec.ApiSpec, t string, imports map[string]bool) (string, string, error) {
+	if t == "[]byte" {
+		return "", "bytes", nil
+	}
+	if strings.HasPrefix(t, "[]") {
+		elem := strings.TrimPrefix(t[2:], "*")
+		if strings.HasPrefix(elem, "[]") && elem != "[]byte" || strings.HasPrefix(elem, "map[") {
+			return "", "", fmt.Errorf("%s is not supported, repeated fields can't contain repeated or map fields", t)
+		}
+		_, typ, e := protoType(api, elem, imports)
+		return "repeated", typ, e
+	}
+	if strings.HasPrefix(t, "map[") {
+		end := strings.Index(t, "]")
+		if end < 0 {
+			return "", "", fmt.Errorf("bad type %s", t)
+		}
+		key, value := t[4:end], strings.TrimPrefix(t[end+1:], "*")
+		keyType, ok := scalarTypes[key]
+		if !ok || strings.HasPrefix(keyType, "float") || keyType == "double" {
+			return "", "", fmt.Errorf("%s is not supported, map keys must be strings or integers", t)
+		}
+		label, valueType, e := protoType(api, value, imports)
+		if e != nil {
+			return "", "", e
+		}
+		if len(label) > 0 || strings.HasPrefix(valueType, "map<") {
+			return "", "", fmt.Errorf("%s is not supported, map values can't be repeated or map fields", t)
+		}
+		return "", fmt.Sprintf("map<%s, %s>", keyType, valueType), nil
+	}
+
+	// proto3 has no pointers, a missing message is nil and a missing scalar is its zero value
+	t = strings.TrimPrefix(t, "*")
+	if typ, ok := scalarTypes[t]; ok {
+		return "", typ, nil
+	}
+	switch t {
+	case "time.Time":
+		imports[timestampImport] = true
+		return "", "google.protobuf.Timestamp", nil
+	case "interface{}":
+		imports[structImport] = true
+		return "", "google.protobuf.Value", nil
+	case spec.FileTypeName:
+		return "", "", fmt.Errorf("%s is not supported, the files are uploaded as multipart/form-data", t)
+	}
+	for _, tp := range api.Types {
+		if tp.Name == t {
+			return "", MessageName(t), nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown type %s", t)
+}
+
+// MessageName returns the name of the proto message of an api type.
+func MessageName(name string) string {
+	return util.UpperFirst(name)
+}
+
+// FieldName returns the name of the proto field of a member.
+func FieldName(member spec.Member) string {
+	return strcase.ToSnake(member.Name)
+}
+
+// GoFieldName returns the name protoc-gen-go gives to the go field of a member.
+func GoFieldName(member spec.Member) string {
+	return strcase.ToCamel(FieldName(member))
+}
+
+// GoType returns the go type protoc-gen-go uses for a member of a basic type, e.g. int64 for int.
+func GoType(t string) (string, bool) {
+	typ, ok := scalarTypes[t]
+	if !ok {
+		return "", false
+	}
+	return goTypes[typ], true
+}
+
+func rpcName(route spec.Route) string {
+	handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		handler = util.RouteToFuncName(route.Method, route.Path)
+	}
+	return util.UpperFirst(strings.TrimSuffix(handler, "Handler"))
+}
+
+func memberDocs(member spec.Member) []string {
+	var docs []string
+	for _, doc := range member.Docs {
+		doc = strings.TrimSpace(strings.TrimPrefix(doc, "//"))
+		if len(doc) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// GoPath returns a go expression building the path of the route from the pb message v,
+// e.g. "/api/user/" + url.PathEscape(fmt.Sprint(v.GetName())), the caller imports fmt and net/url if the route has path variables.
+func GoPath(api *spec.ApiSpec, route spec.Route, v string) string {
+	members := util.GetRequestMembers(api, route)
+	var builder strings.Builder
+	builder.WriteString(`"`)
+	for i, seg := range strings.Split(route.Path, "/") {
+		if i > 0 {
+			builder.WriteString("/")
+		}
+		if !strings.HasPrefix(seg, ":") {
+			builder.WriteString(seg)
+			continue
+		}
+		field := strcase.ToCamel(seg[1:])
+		if member, ok := members.GetPathMember(seg[1:]); ok {
+			field = GoFieldName(member)
+		}
+		fmt.Fprintf(&builder, `" + url.PathEscape(fmt.Sprint(%s.Get%s())) + "`, v, field)
+	}
+	builder.WriteString(`"`)
+	return strings.TrimSuffix(builder.String(), ` + ""`)
+}
@@ -0,0 +1,58 @@
+package protogen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseApi(t *testing.T, members string) *spec.ApiSpec {
+	p, err := parser.NewParserFromStr(`type address struct {
+` + members + `
+}
+
+service user-api {
+	@server(
+		handler: SaveAddressHandler
+	)
+	post /api/address(address) returns(address)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+	return api
+}
+
+func TestReserved(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "user.proto")
+	lock := out + ".lock"
+	assert.Nil(t, genProto(out, lock, "", parseApi(t, "street string `json:\"street\"`\nzip string `json:\"zip\"`")))
+
+	// zip is removed, its number and name are reserved
+	assert.Nil(t, genProto(out, lock, "", parseApi(t, "street string `json:\"street\"`")))
+	b, err := ioutil.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "  reserved 2;\n  reserved \"zip\";\n")
+
+	// a new field never takes a reserved number
+	api := parseApi(t, "street string `json:\"street\"`\ncity string `json:\"city\"`")
+	assert.Nil(t, genProto(out, lock, "", api))
+	b, err = ioutil.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "string city = 3;")
+
+	// the lock is edited so that city takes the reserved number of zip
+	locked, err := readLock(lock)
+	assert.Nil(t, err)
+	locked["Address"]["city"] = 2
+	assert.Nil(t, writeLock(lock, locked))
+	err = genProto(out, lock, "", api)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field city reuses the number 2 of field zip")
+}
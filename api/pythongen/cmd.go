@@ -0,0 +1,58 @@
+package pythongen
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+	"github.com/urfave/cli"
+)
+
+func PythonCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+
+	pkg := c.String("package")
+	if pkg == "" {
+		pkg = strcase.ToSnake(api.Info.Title) + "_api"
+	}
+	e = genPyproject(dir, pkg, api)
+	if e != nil {
+		return e
+	}
+	dir = filepath.Join(dir, pkg)
+	e = genBase(dir)
+	if e != nil {
+		return e
+	}
+	e = genModels(dir, api)
+	if e != nil {
+		return e
+	}
+	e = genClient(dir, api)
+	if e != nil {
+		return e
+	}
+	return genInit(dir, api)
+}
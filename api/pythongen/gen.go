@@ -0,0 +1,598 @@
+package pythongen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	baseTemplate = `# Code generated by goctlr. DO NOT EDIT.
+import inspect
+from email.message import Message
+from typing import Any, Awaitable, Callable, Dict, List, Optional, Tuple, Union
+
+import httpx
+from pydantic import BaseModel, ConfigDict
+
+#: Provides the value of the Authorization header, None or empty means anonymous.
+TokenProvider = Callable[[], Optional[str]]
+#: The async variant may also return an awaitable.
+AsyncTokenProvider = Callable[[], Union[Optional[str], Awaitable[Optional[str]]]]
+
+
+class Model(BaseModel):
+    model_config = ConfigDict(populate_by_name=True, extra="ignore")
+
+    def to_body(self) -> Dict[str, Any]:
+        """Returns the json body, path, query and header members are excluded."""
+        return self.model_dump(mode="json", by_alias=True, exclude_none=True)
+
+
+class ApiFile(BaseModel):
+    """A file uploaded as a multipart field, or downloaded from a binary response."""
+
+    name: str = ""
+    content: bytes = b""
+    content_type: str = "application/octet-stream"
+
+    def to_part(self) -> Tuple[str, bytes, str]:
+        return self.name, self.content, self.content_type
+
+    @classmethod
+    def from_response(cls, resp: httpx.Response) -> "ApiFile":
+        """Returns the file of a binary response named by its Content-Disposition header."""
+        msg = Message()
+        msg["content-disposition"] = resp.headers.get("content-disposition", "")
+        return cls(
+            name=msg.get_filename() or "",
+            content=resp.content,
+            content_type=resp.headers.get("content-type", "application/octet-stream"),
+        )
+
+
+class ErrorCode(Exception):
+    """The error payload written by the server, status is the http status of the response."""
+
+    def __init__(self, code: int = 0, desc: str = "", status: int = 0):
+        super().__init__(f"{status}: {code} {desc}")
+        self.code = code
+        self.desc = desc
+        self.status = status
+
+
+def raise_for_error(resp: httpx.Response) -> None:
+    if resp.is_success:
+        return
+    try:
+        data = resp.json()
+    except ValueError:
+        data = None
+    if isinstance(data, dict):
+        raise ErrorCode(int(data.get("code") or 0), str(data.get("desc") or ""), resp.status_code)
+    raise ErrorCode(resp.status_code, resp.text, resp.status_code)
+
+
+class Client:
+    """Sends the requests of the generated apis, a non-2xx response raises ErrorCode."""
+
+    def __init__(
+        self,
+        base_url: str,
+        token: Optional[TokenProvider] = None,
+        headers: Optional[Dict[str, str]] = None,
+        timeout: float = 10.0,
+        http: Optional[httpx.Client] = None,
+    ):
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+        self.headers = dict(headers or {})
+        self.http = http or httpx.Client(timeout=timeout)
+
+    def request(
+        self,
+        method: str,
+        path: str,
+        params: Optional[Dict[str, Any]] = None,
+        headers: Optional[Dict[str, str]] = None,
+        body: Any = None,
+        data: Optional[Dict[str, Any]] = None,
+        files: Optional[List[Tuple[str, Tuple[str, bytes, str]]]] = None,
+    ) -> httpx.Response:
+        all_headers = dict(self.headers)
+        if self.token is not None:
+            token = self.token()
+            if token:
+                all_headers["Authorization"] = token
+        all_headers.update(headers or {})
+        resp = self.http.request(
+            method, self.base_url + path, params=params, headers=all_headers, json=body, data=data, files=files
+        )
+        raise_for_error(resp)
+        return resp
+
+    def close(self) -> None:
+        self.http.close()
+
+    def __enter__(self) -> "Client":
+        return self
+
+    def __exit__(self, *args: Any) -> None:
+        self.close()
+
+
+class AsyncClient:
+    """The asyncio variant of Client."""
+
+    def __init__(
+        self,
+        base_url: str,
+        token: Optional[AsyncTokenProvider] = None,
+        headers: Optional[Dict[str, str]] = None,
+        timeout: float = 10.0,
+        http: Optional[httpx.AsyncClient] = None,
+    ):
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+        self.headers = dict(headers or {})
+        self.http = http or httpx.AsyncClient(timeout=timeout)
+
+    async def request(
+        self,
+        method: str,
+        path: str,
+        params: Optional[Dict[str, Any]] = None,
+        headers: Optional[Dict[str, str]] = None,
+        body: Any = None,
+        data: Optional[Dict[str, Any]] = None,
+        files: Optional[List[Tuple[str, Tuple[str, bytes, str]]]] = None,
+    ) -> httpx.Response:
+        all_headers = dict(self.headers)
+        if self.token is not None:
+            token = self.token()
+            if inspect.isawaitable(token):
+                token = await token
+            if token:
+                all_headers["Authorization"] = token
+        all_headers.update(headers or {})
+        resp = await self.http.request(
+            method, self.base_url + path, params=params, headers=all_headers, json=body, data=data, files=files
+        )
+        raise_for_error(resp)
+        return resp
+
+    async def aclose(self) -> None:
+        await self.http.aclose()
+
+    async def __aenter__(self) -> "AsyncClient":
+        return self
+
+    async def __aexit__(self, *args: Any) -> None:
+        await self.aclose()
+`
+	modelsTemplate = `# Code generated by goctlr. DO NOT EDIT.
+from __future__ import annotations
+
+from datetime import datetime
+from typing import Any, Dict, List, Optional
+
+from pydantic import Field
+
+from .base import ApiFile, Model
+{{range .}}
+
+class {{.Name}}(Model):
+{{range .Members}}{{if ne .Comment ""}}    # {{.Comment}}
+{{end}}    {{.Name}}: {{.Type}} = {{.Field}}
+{{else}}    pass
+{{end}}{{end}}`
+	clientTemplate = `# Code generated by goctlr. DO NOT EDIT.
+{{if .deprecated}}import warnings
+{{end}}{{if .errors}}from enum import IntEnum
+{{end}}from typing import Any, Dict{{if .file}}, List{{end}}
+from urllib.parse import quote
+
+from .base import {{if .file}}ApiFile, {{end}}AsyncClient, Client
+from .models import *  # noqa: F401,F403
+{{if .errors}}
+
+class ErrorCodes(IntEnum):
+    """The codes of the errors declared in the api file, e.g. e.code == ErrorCodes.{{with index .errors 0}}{{.Name}}{{end}}"""
+
+{{range .errors}}    # {{.Desc}}
+    {{.Name}} = {{.Code}}
+{{end}}{{end}}{{range .classes}}
+
+class {{.Name}}:
+{{if ne $.desc ""}}    """{{$.desc}}"""
+
+{{end}}    def __init__(self, client: {{.Client}}):
+        self.client = client
+{{$async := .Async}}{{range $.routes}}
+    {{if $async}}async {{end}}def {{.Func}}(self{{if ne .Request ""}}, req: {{.Request}}{{end}}) -> {{if eq .Response ""}}None{{else}}{{.Response}}{{end}}:
+{{if ne .Doc ""}}        """{{.Doc}}"""
+{{end}}{{if ne .Deprecated ""}}        warnings.warn("{{.Func}} is {{.Deprecated}}", DeprecationWarning, stacklevel=2)
+{{end}}{{if .Query}}        params: Dict[str, Any] = {}
+{{range .Query}}        {{.}}
+{{end}}{{end}}{{if .Headers}}        headers: Dict[str, str] = {}
+{{range .Headers}}        {{.}}
+{{end}}{{end}}{{if .Files}}        files: List[Any] = []
+{{range .Files}}        {{.}}
+{{end}}{{end}}        {{if ne .Response ""}}resp = {{end}}{{if $async}}await {{end}}self.client.request("{{.Method}}", {{.Path}}{{if .Query}}, {{if .Files}}data{{else}}params{{end}}=params{{end}}{{if .Headers}}, headers=headers{{end}}{{if .Body}}, body=req.to_body(){{end}}{{if .Files}}, files=files{{end}})
+{{if .Binary}}        return ApiFile.from_response(resp)
+{{else if ne .Response ""}}        return {{.Response}}.model_validate(resp.json())
+{{end}}{{end}}{{end}}`
+	initTemplate = `# Code generated by goctlr. DO NOT EDIT.
+from .base import ApiFile, AsyncClient, Client, ErrorCode, Model
+from .client import {{.name}}, Async{{.name}}{{if .errors}}, ErrorCodes{{end}}
+{{if .types}}from .models import {{range $i, $t := .types}}{{if $i}}, {{end}}{{$t}}{{end}}
+{{end}}`
+	pyprojectTemplate = `[build-system]
+requires = ["setuptools>=61"]
+build-backend = "setuptools.build_meta"
+
+[project]
+name = "{{.name}}"
+version = "{{.version}}"
+description = "{{.desc}}"
+requires-python = ">=3.8"
+dependencies = [
+    "httpx>=0.24",
+    "pydantic>=2.0",
+]
+
+[tool.setuptools]
+packages = ["{{.pkg}}"]
+`
+)
+
+type (
+	pyMember struct {
+		Name    string
+		Type    string
+		Field   string
+		Comment string
+	}
+	pyType struct {
+		Name    string
+		Members []pyMember
+	}
+	pyRoute struct {
+		Doc      string
+		Method   string
+		Path     string
+		Func     string
+		Request  string
+		Response string
+		Query    []string
+		Headers  []string
+		Files    []string
+		Body     bool
+		Binary   bool
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+	pyClass struct {
+		Name   string
+		Client string
+		Async  bool
+	}
+)
+
+var pyReserved = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "break": true,
+	"class": true, "continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true,
+	"or": true, "pass": true, "raise": true, "return": true, "try": true, "while": true,
+	"with": true, "yield": true,
+	// attributes of pydantic.BaseModel
+	"copy": true, "dict": true, "json": true, "schema": true, "construct": true,
+	"validate": true, "to_body": true,
+}
+
+func genPyproject(dir, pkg string, api *spec.ApiSpec) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "pyproject.toml")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("pyproject.toml already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	version := api.Info.Version
+	if len(version) == 0 {
+		version = "1.0.0"
+	}
+	desc := api.Info.Desc
+	if len(desc) == 0 {
+		desc = "client of " + api.Service.Name
+	}
+	t, e := template.New("pyproject").Parse(pyprojectTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"name":    strings.ReplaceAll(pkg, "_", "-"),
+		"pkg":     pkg,
+		"version": version,
+		"desc":    strings.ReplaceAll(desc, `"`, `'`),
+	})
+}
+
+func genBase(dir string) error {
+	return writeFile(dir, "base.py", baseTemplate, nil)
+}
+
+func genModels(dir string, api *spec.ApiSpec) error {
+	var types []pyType
+	for _, tp := range api.Types {
+		types = append(types, buildPyType(api, tp))
+	}
+	return writeFile(dir, "models.py", modelsTemplate, types)
+}
+
+func genClient(dir string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "python")
+	name := strcase.ToCamel(api.Info.Title + "Api")
+	var routes []pyRoute
+	deprecated := false
+	for _, route := range api.Service.Routes {
+		routes = append(routes, buildPyRoute(api, route))
+		deprecated = deprecated || route.Deprecation != nil
+	}
+	return writeFile(dir, "client.py", clientTemplate, map[string]interface{}{
+		"desc": pyDoc(api.Info.Desc),
+		"classes": []pyClass{
+			{Name: name, Client: "Client"},
+			{Name: "Async" + name, Client: "AsyncClient", Async: true},
+		},
+		"routes":     routes,
+		"file":       util.HasFileRoute(api),
+		"errors":     pyErrors(api),
+		"deprecated": deprecated,
+	})
+}
+
+func genInit(dir string, api *spec.ApiSpec) error {
+	var types []string
+	for _, tp := range api.Types {
+		types = append(types, strcase.ToCamel(tp.Name))
+	}
+	return writeFile(dir, "__init__.py", initTemplate, map[string]interface{}{
+		"name":   strcase.ToCamel(api.Info.Title + "Api"),
+		"types":  types,
+		"errors": len(api.Errors) > 0,
+	})
+}
+
+// pyErrors returns the errors of the api file named as the members of an enum, e.g. USER_NOT_FOUND.
+func pyErrors(api *spec.ApiSpec) []spec.Error {
+	var errs []spec.Error
+	for _, e := range api.Errors {
+		e.Name = strcase.ToScreamingSnake(e.Name)
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+func writeFile(dir, name, text string, data interface{}) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New(name).Parse(text)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, data)
+}
+
+func buildPyType(api *spec.ApiSpec, tp spec.Type) pyType {
+	result := pyType{Name: strcase.ToCamel(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		typ, optional := toPyType(member)
+		def := pyDefaultValue(member.Type)
+		if optional {
+			typ = "Optional[" + typ + "]"
+			def = "None"
+		}
+		var field string
+		if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+			if len(def) == 0 {
+				typ = "Optional[" + typ + "]"
+				def = "None"
+			}
+			// sent as path, query or header, not in the json body
+			field = "exclude=True"
+		} else {
+			field = fmt.Sprintf("alias=%q", member.GetTagName())
+		}
+		switch def {
+		case "":
+			field = "..., " + field
+		case "[]":
+			field = "default_factory=list, " + field
+		case "{}":
+			field = "default_factory=dict, " + field
+		default:
+			field = def + ", " + field
+		}
+		result.Members = append(result.Members, pyMember{
+			Name:    pyName(member.Name),
+			Type:    typ,
+			Field:   "Field(" + field + ")",
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+		})
+	}
+	return result
+}
+
+func buildPyRoute(api *spec.ApiSpec, route spec.Route) pyRoute {
+	members := util.GetRequestMembers(api, route)
+	result := pyRoute{
+		Doc:        pyDoc(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strings.ToUpper(route.Method),
+		Func:       strcase.ToSnake(util.RouteToFuncName(route.Method, route.Path)),
+		Body:       len(members.Body) > 0,
+	}
+	path := util.ConvertPath(route.Path, func(name string) string {
+		member, ok := members.GetPathMember(name)
+		if !ok {
+			return ":" + name
+		}
+		return "{quote(str(req." + pyName(member.Name) + "), safe='')}"
+	})
+	if len(members.Path) > 0 {
+		result.Path = `f"` + path + `"`
+	} else {
+		result.Path = `"` + path + `"`
+	}
+	if len(route.RequestType.Name) > 0 {
+		result.Request = strcase.ToCamel(route.RequestType.Name)
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		result.Response = "ApiFile"
+		result.Binary = true
+	}
+
+	for _, member := range members.Query {
+		if member.IsFileList() {
+			result.Files = append(result.Files, fmt.Sprintf("for f in req.%s or []:\n            files.append((\"%s\", f.to_part()))",
+				pyName(member.Name), member.GetTagName()))
+			continue
+		}
+		if member.IsFile() {
+			result.Files = append(result.Files, assignValue(member, func(value string) string {
+				return fmt.Sprintf(`files.append(("%s", %s.to_part()))`, member.GetTagName(), value)
+			}))
+			continue
+		}
+		result.Query = append(result.Query, assignValue(member, func(value string) string {
+			return fmt.Sprintf(`params["%s"] = %s`, member.GetTagName(), value)
+		}))
+	}
+	for _, member := range members.Header {
+		result.Headers = append(result.Headers, assignValue(member, func(value string) string {
+			return fmt.Sprintf(`headers["%s"] = str(%s)`, member.GetTagName(), value)
+		}))
+	}
+	return result
+}
+
+// pyDoc returns the text written in a docstring, the quotes around it are removed and
+// the backslashes and quotes in it are escaped, so that it can't end the docstring.
+func pyDoc(text string) string {
+	text = strings.TrimSpace(text)
+	if unquoted, e := strconv.Unquote(text); e == nil {
+		text = strings.TrimSpace(unquoted)
+	}
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return strings.ReplaceAll(text, `"`, `\"`)
+}
+
+// assignValue returns the statement sending the member, None values are skipped.
+func assignValue(member spec.Member, fn func(value string) string) string {
+	value := "req." + pyName(member.Name)
+	if _, optional := toPyType(member); optional || len(pyDefaultValue(member.Type)) == 0 {
+		return fmt.Sprintf("if %s is not None:\n            %s", value, fn(value))
+	}
+	return fn(value)
+}
+
+func pyName(name string) string {
+	name = strcase.ToSnake(name)
+	if pyReserved[name] || strings.HasPrefix(name, "model_") {
+		return name + "_"
+	}
+	return name
+}
+
+// toPyType returns the python type of the member and whether it is optional.
+func toPyType(member spec.Member) (string, bool) {
+	optional := member.IsOptional() || member.IsOmitempty() || strings.HasPrefix(member.Type, "*")
+	return pyType2(member.Type), optional
+}
+
+func pyType2(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "List[" + pyType2(t[2:]) + "]"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "Dict[" + pyType2(tys[0]) + ", " + pyType2(tys[1]) + "]"
+	}
+
+	switch t {
+	case "string":
+		return "str"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "int"
+	case "float32", "float64":
+		return "float"
+	case "bool":
+		return "bool"
+	case "interface{}":
+		return "Any"
+	case "time.Time":
+		return "datetime"
+	case spec.FileTypeName:
+		return "ApiFile"
+	default:
+		return strcase.ToCamel(t)
+	}
+}
+
+func pyDefaultValue(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return "[]"
+	case strings.HasPrefix(t, "map"):
+		return "{}"
+	}
+	switch pyType2(t) {
+	case "str":
+		return `""`
+	case "int":
+		return "0"
+	case "float":
+		return "0.0"
+	case "bool":
+		return "False"
+	case "Any":
+		return "None"
+	default:
+		return ""
+	}
+}
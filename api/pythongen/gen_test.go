@@ -0,0 +1,83 @@
+package pythongen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+	p, err := parser.NewParserFromStr(`info(
+	title: user
+)
+
+type event struct {
+	text string ` + "`json:\"text\"`" + `
+}
+
+service user-api {
+	@server(
+		handler: EventsHandler
+	)
+	get /api/events() stream(event)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, genClient(dir, api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "client.py"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "/api/events")
+	assert.Contains(t, buffer.String(), "the sse stream GET /api/events is skipped")
+}
+
+func TestDocstrings(t *testing.T) {
+	p, err := parser.NewParserFromStr(`info(
+	title: user
+	desc: "user api"
+)
+
+type userRequest struct {
+	id int ` + "`path:\"id\"`" + `
+}
+
+service user-api {
+	@doc(
+		summary: get """user"""
+		desc: in c:\
+	)
+	@server(
+		handler: GetUserHandler
+	)
+	get /api/user/:id(userRequest)
+}
+`)
+	assert.Nil(t, err)
+	api, err := p.Parse()
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, genClient(dir, api))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "client.py"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"""user api"""`)
+	assert.Contains(t, string(b), `"""get \"\"\"user\"\"\" in c:\\"""`)
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	out, err := exec.Command("python3", "-m", "py_compile", filepath.Join(dir, "client.py")).CombinedOutput()
+	assert.Nil(t, err, string(out))
+}
@@ -0,0 +1,44 @@
+package rustgen
+
+import (
+	"errors"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+	"github.com/urfave/cli"
+)
+
+func RustCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+
+	name := c.String("crate")
+	if name == "" {
+		name = strcase.ToKebab(api.Info.Title) + "-api"
+	}
+	e = genCargo(dir, name, api)
+	if e != nil {
+		return e
+	}
+	return genSources(dir, api)
+}
@@ -0,0 +1,659 @@
+package rustgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	cargoTemplate = `[package]
+name = "{{.name}}"
+version = "{{.version}}"
+edition = "2021"
+description = "{{.desc}}"
+
+[dependencies]
+chrono = { version = "0.4", features = ["serde"] }
+reqwest = {{if .file}}{ version = "0.12", features = ["multipart"] }{{else}}"0.12"{{end}}
+serde = { version = "1", features = ["derive"] }
+serde_json = "1"
+`
+	libTemplate = `// Code generated by goctlr. DO NOT EDIT.
+pub mod api;
+pub mod client;
+pub mod types;
+
+pub use api::*;
+pub use client::*;
+pub use types::*;
+`
+	clientTemplate = `// Code generated by goctlr. DO NOT EDIT.
+use std::fmt;
+use std::sync::Arc;
+
+use reqwest::header::{HeaderMap, AUTHORIZATION, CONTENT_TYPE};{{if .}}
+use reqwest::header::{HeaderName, CONTENT_DISPOSITION};{{end}}
+use reqwest::{Method, RequestBuilder};
+use serde::{Deserialize, Serialize};
+
+/// The error payload written by the server.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+#[serde(default)]
+pub struct ErrorCode {
+    pub code: i64,
+    pub desc: String,
+}
+
+#[derive(Debug)]
+pub enum ApiError {
+    /// A non-2xx response, with the http status and the error payload.
+    Server { status: u16, error: ErrorCode },
+    /// Connection, timeout or body errors.
+    Http(reqwest::Error),
+    /// The json can't be encoded or decoded.
+    Json(serde_json::Error),
+}
+
+impl fmt::Display for ApiError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            ApiError::Server { status, error } => write!(f, "{}: {} {}", status, error.code, error.desc),
+            ApiError::Http(e) => write!(f, "http: {}", e),
+            ApiError::Json(e) => write!(f, "json: {}", e),
+        }
+    }
+}
+
+impl std::error::Error for ApiError {}
+
+impl From<reqwest::Error> for ApiError {
+    fn from(e: reqwest::Error) -> Self {
+        ApiError::Http(e)
+    }
+}
+
+impl From<serde_json::Error> for ApiError {
+    fn from(e: serde_json::Error) -> Self {
+        ApiError::Json(e)
+    }
+}
+
+{{if .}}/// A file uploaded as a multipart field, or downloaded from a binary response.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct ApiFile {
+    pub name: String,
+    pub content_type: String,
+    pub content: Vec<u8>,
+}
+
+{{end}}/// Provides the value of the Authorization header, None or empty means anonymous.
+pub type TokenProvider = Arc<dyn Fn() -> Option<String> + Send + Sync>;
+
+/// Sends the requests of the generated apis.
+#[derive(Clone)]
+pub struct ApiClient {
+    base_url: String,
+    http: reqwest::Client,
+    token: Option<TokenProvider>,
+}
+
+impl ApiClient {
+    pub fn new(base_url: impl Into<String>) -> Self {
+        Self::with_client(base_url, reqwest::Client::new())
+    }
+
+    /// Timeouts, proxies and connection pools are configured on the given client.
+    pub fn with_client(base_url: impl Into<String>, http: reqwest::Client) -> Self {
+        let mut base_url = base_url.into();
+        while base_url.ends_with('/') {
+            base_url.pop();
+        }
+        Self { base_url, http, token: None }
+    }
+
+    pub fn with_token(mut self, token: impl Fn() -> Option<String> + Send + Sync + 'static) -> Self {
+        self.token = Some(Arc::new(token));
+        self
+    }
+
+    /// Sends the request and returns the body of a 2xx response.
+    pub async fn request(
+        &self,
+        method: Method,
+        path: &str,
+        query: &[(&str, String)],
+        headers: &[(&str, String)],
+        body: Option<Vec<u8>>,
+    ) -> Result<Vec<u8>, ApiError> {
+        let mut req = self.builder(method, path, query, headers);
+        if let Some(body) = body {
+            req = req.header(CONTENT_TYPE, "application/json").body(body);
+        }
+        Ok(Self::send(req).await?.1)
+    }
+{{if .}}
+    /// Sends the fields and files as multipart/form-data and returns the body of a 2xx response.
+    pub async fn upload(
+        &self,
+        method: Method,
+        path: &str,
+        headers: &[(&str, String)],
+        fields: &[(&str, String)],
+        files: Vec<(&str, ApiFile)>,
+    ) -> Result<Vec<u8>, ApiError> {
+        let mut form = reqwest::multipart::Form::new();
+        for (key, value) in fields {
+            form = form.text(key.to_string(), value.clone());
+        }
+        for (key, file) in files {
+            let mut part = reqwest::multipart::Part::bytes(file.content).file_name(file.name);
+            if !file.content_type.is_empty() {
+                part = part.mime_str(&file.content_type)?;
+            }
+            form = form.part(key.to_string(), part);
+        }
+        let req = self.builder(method, path, &[], headers).multipart(form);
+        Ok(Self::send(req).await?.1)
+    }
+
+    /// Sends the request and returns the file of a 2xx binary response, named by its Content-Disposition header.
+    pub async fn download(
+        &self,
+        method: Method,
+        path: &str,
+        query: &[(&str, String)],
+        headers: &[(&str, String)],
+        body: Option<Vec<u8>>,
+    ) -> Result<ApiFile, ApiError> {
+        let mut req = self.builder(method, path, query, headers);
+        if let Some(body) = body {
+            req = req.header(CONTENT_TYPE, "application/json").body(body);
+        }
+        let (headers, content) = Self::send(req).await?;
+        let header = |name: HeaderName| headers.get(name).and_then(|value| value.to_str().ok()).unwrap_or("").to_string();
+        Ok(ApiFile {
+            name: file_name(&header(CONTENT_DISPOSITION)),
+            content_type: header(CONTENT_TYPE),
+            content,
+        })
+    }
+{{end}}
+    fn builder(&self, method: Method, path: &str, query: &[(&str, String)], headers: &[(&str, String)]) -> RequestBuilder {
+        let mut req = self.http.request(method, format!("{}{}", self.base_url, path));
+        if !query.is_empty() {
+            req = req.query(query);
+        }
+        if let Some(token) = self.token.as_ref().and_then(|token| token()) {
+            if !token.is_empty() {
+                req = req.header(AUTHORIZATION, token);
+            }
+        }
+        for (key, value) in headers {
+            req = req.header(*key, value.as_str());
+        }
+        req
+    }
+
+    /// Sends the request and returns the headers and the body of a 2xx response.
+    async fn send(req: RequestBuilder) -> Result<(HeaderMap, Vec<u8>), ApiError> {
+        let resp = req.send().await?;
+        let status = resp.status();
+        let headers = resp.headers().clone();
+        let data = resp.bytes().await?.to_vec();
+        if !status.is_success() {
+            let error = serde_json::from_slice::<ErrorCode>(&data).unwrap_or_else(|_| ErrorCode {
+                code: status.as_u16() as i64,
+                desc: String::from_utf8_lossy(&data).into_owned(),
+            });
+            return Err(ApiError::Server { status: status.as_u16(), error });
+        }
+        Ok((headers, data))
+    }
+}
+{{if .}}
+/// Returns the file name of a Content-Disposition header, filename* is preferred.
+fn file_name(disposition: &str) -> String {
+    let mut plain = String::new();
+    for param in disposition.split(';').map(str::trim) {
+        let lower = param.to_ascii_lowercase();
+        if lower.starts_with("filename*=") {
+            // charset'language'percent-encoded-name
+            if let Some(encoded) = param["filename*=".len()..].splitn(3, '\'').nth(2) {
+                return percent_decode(encoded);
+            }
+        } else if lower.starts_with("filename=") {
+            plain = param["filename=".len()..].trim_matches('"').to_string();
+        }
+    }
+    plain
+}
+
+fn percent_decode(value: &str) -> String {
+    let bytes = value.as_bytes();
+    let mut result = Vec::with_capacity(bytes.len());
+    let mut i = 0;
+    while i < bytes.len() {
+        if bytes[i] == b'%' && i + 2 < bytes.len() {
+            let hex = std::str::from_utf8(&bytes[i + 1..i + 3]).ok();
+            if let Some(b) = hex.and_then(|hex| u8::from_str_radix(hex, 16).ok()) {
+                result.push(b);
+                i += 3;
+                continue;
+            }
+        }
+        result.push(bytes[i]);
+        i += 1;
+    }
+    String::from_utf8_lossy(&result).into_owned()
+}
+{{end}}
+/// Escapes a value used as a path segment.
+pub fn path_escape(value: &str) -> String {
+    let mut result = String::with_capacity(value.len());
+    for b in value.bytes() {
+        match b {
+            b'A'..=b'Z' | b'a'..=b'z' | b'0'..=b'9' | b'-' | b'.' | b'_' | b'~' => result.push(b as char),
+            _ => result.push_str(&format!("%{:02X}", b)),
+        }
+    }
+    result
+}
+`
+	typesTemplate = `// Code generated by goctlr. DO NOT EDIT.
+#![allow(unused_imports)]
+use std::collections::HashMap;
+
+use serde::{Deserialize, Serialize};
+{{if .file}}
+use crate::client::ApiFile;
+{{end}}{{range .types}}
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+#[serde(default)]
+pub struct {{.Name}} {{"{"}}{{range .Members}}{{if ne .Comment ""}}
+    /// {{.Comment}}{{end}}
+    {{.Attr}}
+    pub {{.Name}}: {{.Type}},{{end}}
+}
+{{end}}`
+	apiTemplate = `// Code generated by goctlr. DO NOT EDIT.
+#![allow(unused_imports)]
+use reqwest::Method;
+
+use crate::client::{path_escape, ApiClient, ApiError{{if .file}}, ApiFile{{end}}};
+use crate::types::*;
+{{if ne .desc ""}}
+/// {{.desc}}{{end}}
+#[derive(Clone)]
+pub struct {{.name}} {
+    client: ApiClient,
+}
+
+impl {{.name}} {
+    pub fn new(client: ApiClient) -> Self {
+        Self { client }
+    }
+{{range .routes}}
+{{if ne .Doc ""}}    /// {{.Doc}}
+{{end}}{{if ne .Deprecated ""}}    #[deprecated(note = "{{.Deprecated}}")]
+{{end}}    pub async fn {{.Func}}(&self{{if ne .Request ""}}, req: &{{.Request}}{{end}}) -> Result<{{if eq .Response ""}}(){{else}}{{.Response}}{{end}}, ApiError> {
+{{if .Query}}        let mut query: Vec<(&str, String)> = Vec::new();
+{{range .Query}}        {{.}}
+{{end}}{{end}}{{if .Headers}}        let mut headers: Vec<(&str, String)> = Vec::new();
+{{range .Headers}}        {{.}}
+{{end}}{{end}}{{if .Files}}        let mut files: Vec<(&str, ApiFile)> = Vec::new();
+{{range .Files}}        {{.}}
+{{end}}{{end}}{{if .Body}}        let body = serde_json::to_vec(req)?;
+{{end}}{{if .Files}}        {{if ne .Response ""}}let data = {{end}}self
+            .client
+            .upload(
+                Method::{{.Method}},
+                {{.Path}},
+                {{if .Headers}}&headers{{else}}&[]{{end}},
+                {{if .Query}}&query{{else}}&[]{{end}},
+                files,
+            )
+            .await?;
+{{else}}        {{if .Binary}}self{{else}}{{if ne .Response ""}}let data = {{end}}self{{end}}
+            .client
+            .{{if .Binary}}download{{else}}request{{end}}(
+                Method::{{.Method}},
+                {{.Path}},
+                {{if .Query}}&query{{else}}&[]{{end}},
+                {{if .Headers}}&headers{{else}}&[]{{end}},
+                {{if .Body}}Some(body){{else}}None{{end}},
+            )
+            .await{{if not .Binary}}?;{{end}}
+{{end}}{{if not .Binary}}        {{if eq .Response ""}}Ok(()){{else}}Ok(serde_json::from_slice(&data)?){{end}}
+{{end}}    }
+{{end}}}
+{{if .errors}}
+/// The codes of the errors declared in the api file, e.g. error.code == error_codes::{{with index .errors 0}}{{.Name}}{{end}}
+pub mod error_codes {
+{{range .errors}}    /// {{.Desc}}
+    pub const {{.Name}}: i64 = {{.Code}};
+{{end}}}
+{{end}}`
+)
+
+type (
+	rustMember struct {
+		Attr    string
+		Name    string
+		Type    string
+		Comment string
+	}
+	rustType struct {
+		Name    string
+		Members []rustMember
+	}
+	rustRoute struct {
+		Doc      string
+		Method   string
+		Path     string
+		Func     string
+		Request  string
+		Response string
+		Query    []string
+		Headers  []string
+		Files    []string
+		Body     bool
+		Binary   bool
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+)
+
+var (
+	rustKeywords = map[string]bool{
+		"as": true, "break": true, "const": true, "continue": true, "else": true, "enum": true,
+		"extern": true, "false": true, "fn": true, "for": true, "if": true, "impl": true, "in": true,
+		"let": true, "loop": true, "match": true, "mod": true, "move": true, "mut": true, "pub": true,
+		"ref": true, "return": true, "static": true, "struct": true, "trait": true, "true": true,
+		"type": true, "unsafe": true, "use": true, "where": true, "while": true, "async": true,
+		"await": true, "dyn": true, "abstract": true, "become": true, "box": true, "do": true,
+		"final": true, "macro": true, "override": true, "priv": true, "typeof": true,
+		"unsized": true, "virtual": true, "yield": true, "try": true,
+	}
+	semverRe = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+)
+
+func genCargo(dir, name string, api *spec.ApiSpec) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "Cargo.toml")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("Cargo.toml already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	// cargo requires a full semver version
+	version := "0.1.0"
+	if semverRe.MatchString(api.Info.Version) {
+		version = api.Info.Version
+		for strings.Count(version, ".") < 2 {
+			version += ".0"
+		}
+	}
+	desc := api.Info.Desc
+	if len(desc) == 0 {
+		desc = "client of " + api.Service.Name
+	}
+	t, e := template.New("Cargo.toml").Parse(cargoTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"name":    name,
+		"version": version,
+		"desc":    strings.ReplaceAll(desc, `"`, `'`),
+		"file":    util.HasFileRoute(api),
+	})
+}
+
+func genSources(dir string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "rust")
+	dir = filepath.Join(dir, "src")
+	e := writeFile(dir, "lib.rs", libTemplate, nil)
+	if e != nil {
+		return e
+	}
+	e = writeFile(dir, "client.rs", clientTemplate, util.HasFileRoute(api))
+	if e != nil {
+		return e
+	}
+
+	var types []rustType
+	for _, tp := range api.Types {
+		types = append(types, buildRustType(api, tp))
+	}
+	e = writeFile(dir, "types.rs", typesTemplate, map[string]interface{}{
+		"types": types,
+		"file":  util.HasFileRoute(api),
+	})
+	if e != nil {
+		return e
+	}
+
+	var routes []rustRoute
+	for _, route := range api.Service.Routes {
+		routes = append(routes, buildRustRoute(api, route))
+	}
+	return writeFile(dir, "api.rs", apiTemplate, map[string]interface{}{
+		"name":   strcase.ToCamel(api.Info.Title + "Api"),
+		"desc":   strings.TrimSpace(api.Info.Desc),
+		"routes": routes,
+		"file":   util.HasFileRoute(api),
+		"errors": rustErrors(api),
+	})
+}
+
+// rustErrors returns the errors of the api file named as constants, e.g. USER_NOT_FOUND.
+func rustErrors(api *spec.ApiSpec) []spec.Error {
+	var errs []spec.Error
+	for _, e := range api.Errors {
+		e.Name = strcase.ToScreamingSnake(e.Name)
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+func writeFile(dir, name, text string, data interface{}) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New(name).Parse(text)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, data)
+}
+
+func buildRustType(api *spec.ApiSpec, tp spec.Type) rustType {
+	result := rustType{Name: strcase.ToCamel(tp.Name)}
+	for _, member := range util.FlattenMembers(api.Types, tp) {
+		typ, optional := toRustType(member)
+		m := rustMember{
+			Name:    rustName(member.Name),
+			Type:    typ,
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+		}
+		if optional {
+			m.Type = "Option<" + typ + ">"
+		}
+		switch {
+		case member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember():
+			// sent as path, query or header, not in the json body
+			m.Attr = "#[serde(skip)]"
+		case optional:
+			m.Attr = fmt.Sprintf(`#[serde(rename = "%s", skip_serializing_if = "Option::is_none")]`, member.GetTagName())
+		default:
+			m.Attr = fmt.Sprintf(`#[serde(rename = "%s")]`, member.GetTagName())
+		}
+		result.Members = append(result.Members, m)
+	}
+	return result
+}
+
+func buildRustRoute(api *spec.ApiSpec, route spec.Route) rustRoute {
+	members := util.GetRequestMembers(api, route)
+	result := rustRoute{
+		Doc:        strings.TrimSpace(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strings.ToUpper(route.Method),
+		Func:       strcase.ToSnake(util.RouteToFuncName(route.Method, route.Path)),
+		Body:       len(members.Body) > 0,
+	}
+	var args []string
+	path := util.ConvertPath(route.Path, func(name string) string {
+		member, ok := members.GetPathMember(name)
+		if !ok {
+			return ":" + name
+		}
+		args = append(args, "path_escape(&"+toString(member.Type, "req."+rustName(member.Name))+")")
+		return "{}"
+	})
+	if len(args) > 0 {
+		result.Path = fmt.Sprintf(`&format!("%s", %s)`, path, strings.Join(args, ", "))
+	} else {
+		result.Path = `"` + path + `"`
+	}
+	if len(route.RequestType.Name) > 0 {
+		result.Request = strcase.ToCamel(route.RequestType.Name)
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		result.Response = "ApiFile"
+		result.Binary = true
+	}
+
+	for _, member := range members.Query {
+		if member.IsFile() {
+			result.Files = append(result.Files, pushValue("files", member))
+			continue
+		}
+		result.Query = append(result.Query, pushValue("query", member))
+	}
+	for _, member := range members.Header {
+		result.Headers = append(result.Headers, pushValue("headers", member))
+	}
+	return result
+}
+
+// pushValue returns the statement appending the member to list, None values are skipped
+// and every element of a slice is sent.
+func pushValue(list string, member spec.Member) string {
+	field := "req." + rustName(member.Name)
+	key := member.GetTagName()
+	t := strings.TrimPrefix(member.Type, "*")
+	_, optional := toRustType(member)
+	if strings.HasPrefix(t, "[]") {
+		items := "&" + field
+		if optional {
+			items = field + ".iter().flatten()"
+		}
+		return fmt.Sprintf(`for value in %s {
+            %s.push(("%s", %s));
+        }`, items, list, key, toString(t[2:], "value"))
+	}
+	if optional {
+		return fmt.Sprintf(`if let Some(value) = &%s {
+            %s.push(("%s", %s));
+        }`, field, list, key, toString(t, "value"))
+	}
+	return fmt.Sprintf(`%s.push(("%s", %s));`, list, key, toString(t, field))
+}
+
+func toString(t, expr string) string {
+	switch strings.TrimPrefix(t, "*") {
+	case "time.Time":
+		return expr + ".to_rfc3339()"
+	case spec.FileTypeName:
+		// the files are sent as they are
+		return expr + ".clone()"
+	}
+	return expr + ".to_string()"
+}
+
+func rustName(name string) string {
+	name = strcase.ToSnake(name)
+	switch {
+	case name == "self" || name == "super" || name == "crate":
+		return name + "_"
+	case rustKeywords[name]:
+		return "r#" + name
+	}
+	return name
+}
+
+// toRustType returns the rust type of the member and whether it is optional.
+func toRustType(member spec.Member) (string, bool) {
+	optional := member.IsOptional() || member.IsOmitempty() || strings.HasPrefix(member.Type, "*")
+	return rustType2(member.Type), optional
+}
+
+func rustType2(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "Vec<" + rustType2(t[2:]) + ">"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "HashMap<" + rustType2(tys[0]) + ", " + rustType2(tys[1]) + ">"
+	}
+
+	switch t {
+	case "string":
+		return "String"
+	case "int", "int64":
+		return "i64"
+	case "int8", "int16", "int32":
+		return "i" + strings.TrimPrefix(t, "int")
+	case "uint", "uint64":
+		return "u64"
+	case "uint8", "uint16", "uint32":
+		return "u" + strings.TrimPrefix(t, "uint")
+	case "float32":
+		return "f32"
+	case "float64":
+		return "f64"
+	case "bool":
+		return "bool"
+	case "interface{}":
+		return "serde_json::Value"
+	case "time.Time":
+		return "chrono::DateTime<chrono::Utc>"
+	case spec.FileTypeName:
+		return "ApiFile"
+	default:
+		return strcase.ToCamel(t)
+	}
+}
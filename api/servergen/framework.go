@@ -0,0 +1,52 @@
+package servergen
+
+import "github.com/gofaith/goctlr/api/spec"
+
+// Framework is the code of a web framework in the generated project: the main file, the httpx helpers,
+// the middlewares, the handlers and the route registration. The config, the types, the logic and the
+// ServiceContext are shared by the frameworks.
+//
+// The templates can include the snippets shared by the frameworks with {{template "name"}}, see snippets.go,
+// and call join to separate a list by commas. The generated go files are formatted by gofmt.
+type Framework struct {
+	// Name is the name of the framework in the messages, e.g. gin
+	Name string
+	// GoVersion is the go version of the go.mod created for a project out of any module,
+	// empty if the project requires dependencies to be added by go mod tidy
+	GoVersion string
+	// ConfigExt is the extension of the config file in etc, e.g. json
+	ConfigExt string
+	// EtcTemplate and ConfigTemplate are the config file and the config package, executed with
+	// serviceName, host, port and auths, the names of the jwt configs
+	EtcTemplate    string
+	ConfigTemplate string
+	// MainTemplate is executed with configPkg, handlerPkg, svcPkg and configFile
+	MainTemplate string
+	// HttpxTemplate is the package of the error json, the request parsing and the file helpers
+	HttpxTemplate string
+	// ContextTemplate is the ServiceContext, executed with configPkg, httpxPkg, middlewarePkg and middlewares
+	ContextTemplate string
+	// MiddlewareTemplate is a middleware declared by the api file, executed with name
+	MiddlewareTemplate string
+	// JwtTemplate and LimitTemplate are the Jwt, Timeout and MaxBytes middlewares, executed with httpxPkg
+	JwtTemplate   string
+	LimitTemplate string
+	// HandlerTemplate is executed with package, pkg, logicPkg, handler, name, request, response,
+	// responses, html, binary, files and maxBytes, responses tells the logic returns a result of
+	// multiple responses, see GetRouteResult
+	HandlerTemplate string
+	// RoutesTemplate is executed with imports, time, httpxPkg, hasMiddlewares, deprecated and groups, see routeGroup.
+	// The handlers of the deprecated routes are wrapped by deprecated(handler, deprecation, sunset, link),
+	// which the template declares if deprecated, e.g. by the deprecated snippet for http.HandlerFunc
+	RoutesTemplate string
+	// Methods maps the methods of the api file to the methods of the router, e.g. get to GET
+	Methods map[string]string
+	// Path converts a path of the api file to the pattern of the router, nil keeps the :name variables
+	Path func(path string) string
+	// Tag returns the tag of a member in the types, nil keeps the tag of the api file
+	Tag func(member spec.Member) string
+	// HtmlParams are the parameters of the logic of the html routes, which write the responses themselves,
+	// and HtmlImport is their import, e.g. "net/http"
+	HtmlParams string
+	HtmlImport string
+}
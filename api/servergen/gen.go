@@ -0,0 +1,91 @@
+package servergen
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofaith/go-zero/core/logx"
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+	"github.com/logrusorgru/aurora"
+	"github.com/urfave/cli"
+)
+
+// GoCommand generates the project of the -api file in -dir served by fw.
+func GoCommand(c *cli.Context, fw *Framework) error {
+	apiFile := c.String("api")
+	dir := c.String("dir")
+	onlyTypes := c.Bool("onlyTypes")
+	if len(apiFile) == 0 {
+		return errors.New("missing -api")
+	}
+	if len(dir) == 0 {
+		return errors.New("missing -dir")
+	}
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		log.Println(apiFile + ":" + e.Error())
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		log.Println(apiFile + ":" + e.Error())
+		return e
+	}
+
+	if onlyTypes {
+		logx.Must(genTypes(dir, fw, api))
+		return nil
+	}
+	if e := checkApi(fw, api); e != nil {
+		log.Println(apiFile + ":" + e.Error())
+		return e
+	}
+	logx.Must(util.MkdirIfNotExist(dir))
+	logx.Must(Generate(dir, fw, api))
+
+	fmt.Println(aurora.Green("Done."))
+	return nil
+}
+
+// Generate generates the project of api in dir, the files existing are kept except the routes and the types.
+func Generate(dir string, fw *Framework, api *spec.ApiSpec) error {
+	for _, gen := range []func(string, *Framework, *spec.ApiSpec) error{
+		genGoMod,
+		genEtc,
+		genConfig,
+		genMain,
+		genHttpx,
+		genErrors,
+		genMiddlewares,
+		genServiceContext,
+		genTypes,
+		genHandlers,
+		genRoutes,
+		genLogic,
+	} {
+		if e := gen(dir, fw, api); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// checkApi rejects the routes that can't be served by fw.
+func checkApi(fw *Framework, api *spec.ApiSpec) error {
+	for _, g := range api.Service.Groups {
+		if _, ok := apiutil.GetAnnotationValue(g.Annotations, "server", "signature"); ok {
+			return fmt.Errorf("the signature of the groups isn't supported by %s, use jwt or a middleware instead", fw.Name)
+		}
+		for _, r := range g.Routes {
+			if len(r.Stream) > 0 {
+				return fmt.Errorf("the %s stream %s isn't supported by %s", r.Stream, r.Path, fw.Name)
+			}
+		}
+	}
+	return nil
+}
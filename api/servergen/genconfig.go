@@ -0,0 +1,53 @@
+package servergen
+
+import "github.com/gofaith/goctlr/api/spec"
+
+const configFile = "config.go"
+
+func genConfig(dir string, fw *Framework, api *spec.ApiSpec) error {
+	return genFile(dir, configDir, configFile, fw.ConfigTemplate, getConfigData(api))
+}
+
+// JsonEtcTemplate and JsonConfigTemplate are the config loaded by encoding/json.
+const (
+	JsonEtcTemplate = `{
+	"Name": "{{.serviceName}}",
+	"Host": "{{.host}}",
+	"Port": {{.port}}{{range .auths}},
+	"{{.}}": {
+		"AccessSecret": "change-me",
+		"AccessExpire": 86400
+	}{{end}}
+}
+`
+	JsonConfigTemplate = `package config
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type Config struct {
+	Name string
+	Host string
+	Port int
+	{{- range .auths}}
+	{{.}} struct {
+		AccessSecret string
+		AccessExpire int64
+	}
+	{{- end}}
+}
+
+// MustLoad loads the json config file into c, it panics on errors.
+func MustLoad(file string, c *Config) {
+	b, e := os.ReadFile(file)
+	if e != nil {
+		panic(e)
+	}
+	if e := json.Unmarshal(b, c); e != nil {
+		panic(e)
+	}
+}
+`
+)
@@ -0,0 +1,40 @@
+package servergen
+
+import (
+	"path"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/util"
+)
+
+const (
+	errorsFile     = "errors.go"
+	errorsTemplate = `// DO NOT EDIT, generated by goctl
+package httpx
+
+// the codes of the errors declared in the api file
+const (
+	{{- range .}}
+	// Code{{.Name}} {{.Desc}}
+	Code{{.Name}} = {{.Code}}
+	{{- end}}
+)
+{{range .}}
+// New{{.Name}} returns the error {{.Name}}, {{.Desc}}
+func New{{.Name}}() *CodeError {
+	return &CodeError{Status: {{.Status}}, Code: Code{{.Name}}, Msg: {{quote .Desc}}}
+}
+{{end}}`
+)
+
+// genErrors generates the codes and the constructors of the errors declared in the api file,
+// the file is regenerated since the errors block may change.
+func genErrors(dir string, _ *Framework, api *spec.ApiSpec) error {
+	if len(api.Errors) == 0 {
+		return nil
+	}
+	if err := util.RemoveOrQuit(path.Join(dir, httpxDir, errorsFile)); err != nil {
+		return err
+	}
+	return genFile(dir, httpxDir, errorsFile, errorsTemplate, api.Errors)
+}
@@ -0,0 +1,62 @@
+package servergen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+)
+
+const (
+	defaultPort = 8888
+	etcDir      = "etc"
+)
+
+func genEtc(dir string, fw *Framework, api *spec.ApiSpec) error {
+	return genFile(dir, etcDir, fmt.Sprintf("%s.%s", api.Service.Name, fw.ConfigExt), fw.EtcTemplate, getConfigData(api))
+}
+
+func getConfigData(api *spec.ApiSpec) map[string]interface{} {
+	service := api.Service
+	host, ok := util.GetAnnotationValue(service.Annotations, "server", "host")
+	if !ok {
+		host = "0.0.0.0"
+	}
+	port, ok := util.GetAnnotationValue(service.Annotations, "server", "port")
+	if !ok {
+		port = strconv.Itoa(defaultPort)
+	}
+	return map[string]interface{}{
+		"serviceName": service.Name,
+		"host":        host,
+		"port":        port,
+		"auths":       getAuths(api),
+	}
+}
+
+// genGoMod creates the go.mod of the project if it's not in a module yet and the framework has no dependency.
+func genGoMod(dir string, fw *Framework, _ *spec.ApiSpec) error {
+	if len(fw.GoVersion) == 0 {
+		return nil
+	}
+	if _, ok := ctlutil.FindGoModPath(dir); ok {
+		return nil
+	}
+	fp, created, err := util.MaybeCreateFile(dir, "", "go.mod")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(fp, "module %s\n\ngo %s\n", parentPkg, fw.GoVersion)
+	return err
+}
@@ -0,0 +1,125 @@
+package servergen
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+)
+
+// the form fields besides the files of a multipart request
+const multipartFormBytes = 1 << 20
+
+type fileParam struct {
+	Name     string
+	Field    string
+	List     bool
+	MaxSize  int64
+	Optional bool
+}
+
+func genHandler(dir string, fw *Framework, api *spec.ApiSpec, group spec.Group, route spec.Route) error {
+	handler, ok := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		return fmt.Errorf("missing handler annotation for %q", route.Path)
+	}
+	typ, _ := apiutil.GetAnnotationValue(route.Annotations, "server", "type")
+	handler = GetHandlerBaseName(handler) + "Handler"
+	folderPath := GetHandlerFolderPath(group, route)
+	if folderPath != handlerDir {
+		handler = strings.Title(handler)
+	}
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	var files []fileParam
+	var maxBytes int64
+	if apiutil.IsMultipart(api, route) {
+		for _, member := range apiutil.FlattenMembers(api.Types, route.RequestType) {
+			if !member.IsFile() {
+				continue
+			}
+			maxSize, err := member.GetMaxSize()
+			if err != nil {
+				return err
+			}
+			files = append(files, fileParam{
+				Name:     util.Title(member.Name),
+				Field:    member.GetTagName(),
+				List:     member.IsFileList(),
+				MaxSize:  maxSize,
+				Optional: member.IsOptional(),
+			})
+		}
+		if maxBytes, err = GetMultipartMaxBytes(api, route); err != nil {
+			return err
+		}
+	}
+
+	return genFile(dir, folderPath, strings.ToLower(handler)+".go", fw.HandlerTemplate, map[string]interface{}{
+		"package":   filepath.Base(folderPath),
+		"pkg":       parentPkg,
+		"logicPkg":  util.JoinPackages(parentPkg, GetLogicFolderPath(group, route)),
+		"handler":   handler,
+		"name":      strings.Title(GetHandlerBaseName(handler)),
+		"request":   util.Title(route.RequestType.Name),
+		"response":  len(route.ResponseType.Name) > 0,
+		"responses": len(route.Responses) > 0,
+		"html":      typ == SERVER_TYPE_HTML,
+		"binary":    route.Binary,
+		"files":     files,
+		"maxBytes":  maxBytes,
+	})
+}
+
+func genHandlers(dir string, fw *Framework, api *spec.ApiSpec) error {
+	for _, group := range api.Service.Groups {
+		for _, route := range group.Routes {
+			if err := genHandler(dir, fw, api, group, route); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetMultipartMaxBytes returns the limit of the body of a multipart route, the sum of the maxSize of its files
+// and the size of its form fields.
+func GetMultipartMaxBytes(api *spec.ApiSpec, route spec.Route) (int64, error) {
+	maxBytes := int64(multipartFormBytes)
+	for _, member := range apiutil.FlattenMembers(api.Types, route.RequestType) {
+		if !member.IsFile() {
+			continue
+		}
+		maxSize, err := member.GetMaxSize()
+		if err != nil {
+			return 0, err
+		}
+		maxBytes += maxSize
+	}
+	return maxBytes, nil
+}
+
+// GetHandlerBaseName returns the name of the handler without the Handler suffix, e.g. login of LoginHandler.
+func GetHandlerBaseName(handler string) string {
+	handlerName := util.Untitle(handler)
+	if strings.HasSuffix(handlerName, "handler") {
+		handlerName = strings.ReplaceAll(handlerName, "handler", "")
+	} else if strings.HasSuffix(handlerName, "Handler") {
+		handlerName = strings.ReplaceAll(handlerName, "Handler", "")
+	}
+	return handlerName
+}
+
+// GetHandlerFolderPath returns the folder of the handler of the route, the folder annotation of the route
+// takes precedence over the group, the routes of a version are in its folder, e.g. v2/user.
+func GetHandlerFolderPath(group spec.Group, route spec.Route) string {
+	return path.Join(handlerDir, apiutil.GetRouteFolder(group, route))
+}
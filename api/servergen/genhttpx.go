@@ -0,0 +1,11 @@
+package servergen
+
+import "github.com/gofaith/goctlr/api/spec"
+
+const httpxFile = "httpx.go"
+
+// genHttpx generates the helpers shared by the handlers and the middlewares, such as the request parsing
+// and the error json.
+func genHttpx(dir string, fw *Framework, _ *spec.ApiSpec) error {
+	return genFile(dir, httpxDir, httpxFile, fw.HttpxTemplate, nil)
+}
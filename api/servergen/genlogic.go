@@ -0,0 +1,156 @@
+package servergen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+)
+
+const logicTemplate = `package logic
+
+import (
+	{{.imports}}
+)
+
+type {{.logic}} struct {
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func New{{.logic}}(ctx context.Context, svcCtx *svc.ServiceContext) *{{.logic}} {
+	return &{{.logic}}{
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// {{.summary}}{{if .desc}}
+// {{.desc}}{{end}}
+func (l *{{.logic}}) {{.function}}({{.request}}) {{.responseType}} {
+	// todo: add your logic here and delete this line
+
+	{{.returnString}}
+}
+`
+
+func genLogic(dir string, fw *Framework, api *spec.ApiSpec) error {
+	for _, g := range api.Service.Groups {
+		for _, r := range g.Routes {
+			if err := genLogicByRoute(dir, fw, g, r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func genLogicByRoute(dir string, fw *Framework, group spec.Group, route spec.Route) error {
+	handler, ok := util.GetAnnotationValue(route.Annotations, "server", "handler")
+	if !ok {
+		return fmt.Errorf("missing handler annotation for %q", route.Path)
+	}
+	typ, _ := util.GetAnnotationValue(route.Annotations, "server", "type")
+	handler = strings.TrimSuffix(handler, "handler")
+	handler = strings.TrimSuffix(handler, "Handler")
+	logic := strings.Title(handler) + "Logic"
+	fp, created, err := util.MaybeCreateFile(dir, GetLogicFolderPath(group, route), strings.ToLower(handler)+"logic.go")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	imports := []string{`"context"`}
+	var requestString, responseString, returnString string
+	if len(route.RequestType.Name) > 0 {
+		requestString = "req types." + ctlutil.Title(route.RequestType.Name)
+	}
+	switch {
+	case route.Binary:
+		// the handler sends the content as a download of the file name
+		imports = append(imports, `"io"`)
+		responseString = "(name string, content io.Reader, err error)"
+		returnString = `return "", nil, nil`
+	case typ == SERVER_TYPE_HTML:
+		// the logic writes the response itself
+		if len(requestString) > 0 {
+			requestString = fw.HtmlParams + ", " + requestString
+		} else {
+			requestString = fw.HtmlParams
+		}
+		responseString = "error"
+		returnString = "return nil"
+	case len(route.Responses) > 0:
+		// the handler writes the response chosen by the logic with its status
+		responseString, returnString = GetResultReturn(route)
+	case len(route.ResponseType.Name) > 0:
+		resp := ctlutil.Title(route.ResponseType.Name)
+		responseString = "(*types." + resp + ", error)"
+		returnString = fmt.Sprintf("return &types.%s{}, nil", resp)
+	default:
+		responseString = "error"
+		returnString = "return nil"
+	}
+	imports = append(imports, "")
+	if len(route.RequestType.Name) > 0 || strings.Contains(responseString, "types.") {
+		imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, typesDir)))
+	}
+	imports = append(imports, fmt.Sprintf("\"%s\"", ctlutil.JoinPackages(parentPkg, contextDir)))
+	if typ == SERVER_TYPE_HTML {
+		// the standard library first, then the packages of the project and the framework
+		if strings.Contains(fw.HtmlImport, ".") {
+			imports = append(imports, "", fw.HtmlImport)
+		} else {
+			imports = append([]string{fw.HtmlImport}, imports...)
+		}
+	}
+
+	summary, _ := util.GetAnnotationValue(route.Annotations, "doc", "summary")
+	if len(summary) == 0 {
+		summary = route.Summary
+	}
+	if len(summary) == 0 {
+		summary = strings.Title(handler)
+	}
+	desc, _ := util.GetAnnotationValue(route.Annotations, "doc", "desc")
+	if len(desc) == 0 {
+		desc = route.Desc
+	}
+
+	t := template.Must(template.New("logicTemplate").Parse(logicTemplate))
+	buffer := new(bytes.Buffer)
+	err = t.Execute(buffer, map[string]string{
+		"imports":      strings.Join(imports, "\n\t"),
+		"logic":        logic,
+		"summary":      summary,
+		"desc":         desc,
+		"function":     strings.Title(handler),
+		"request":      requestString,
+		"responseType": responseString,
+		"returnString": returnString,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(formatCode(buffer.String()))
+	return err
+}
+
+// GetLogicFolderPath returns the folder of the logic of the route, the folder annotation of the route
+// takes precedence over the group, the routes of a version are in its folder, e.g. v2/user.
+func GetLogicFolderPath(group spec.Group, route spec.Route) string {
+	return path.Join(logicDir, util.GetRouteFolder(group, route))
+}
@@ -0,0 +1,26 @@
+package servergen
+
+import (
+	"strings"
+
+	"github.com/gofaith/goctlr/api/spec"
+	ctlutil "github.com/gofaith/goctlr/util"
+)
+
+func genMain(dir string, fw *Framework, api *spec.ApiSpec) error {
+	name := strings.ToLower(api.Service.Name)
+	if strings.HasSuffix(name, "-api") {
+		name = strings.ReplaceAll(name, "-api", "")
+	}
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	return genFile(dir, "", name+".go", fw.MainTemplate, map[string]string{
+		"configPkg":  ctlutil.JoinPackages(parentPkg, configDir),
+		"handlerPkg": ctlutil.JoinPackages(parentPkg, handlerDir),
+		"svcPkg":     ctlutil.JoinPackages(parentPkg, contextDir),
+		"configFile": etcDir + "/" + api.Service.Name + "." + fw.ConfigExt,
+	})
+}
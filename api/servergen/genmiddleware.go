@@ -0,0 +1,57 @@
+package servergen
+
+import (
+	"strings"
+
+	"github.com/gofaith/go-zero/core/stringx"
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/iancoleman/strcase"
+)
+
+func genMiddlewares(dir string, fw *Framework, api *spec.ApiSpec) error {
+	for _, name := range GetMiddlewares(api) {
+		err := genFile(dir, middlewareDir, strings.ToLower(name)+"middleware.go", fw.MiddlewareTemplate, map[string]string{
+			"name": strcase.ToCamel(name),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		"httpxPkg": ctlutil.JoinPackages(parentPkg, httpxDir),
+	}
+	if len(getAuths(api)) > 0 {
+		if err := genFile(dir, middlewareDir, "jwt.go", fw.JwtTemplate, data); err != nil {
+			return err
+		}
+	}
+	for _, g := range api.Service.Groups {
+		if g.Timeout > 0 || g.MaxBytes > 0 {
+			return genFile(dir, middlewareDir, "limit.go", fw.LimitTemplate, data)
+		}
+	}
+	return nil
+}
+
+// GetMiddlewares returns the names of the middlewares declared by the groups and the routes
+// in the order they are declared.
+func GetMiddlewares(api *spec.ApiSpec) []string {
+	var names []string
+	for _, g := range api.Service.Groups {
+		for _, r := range g.Routes {
+			for _, name := range util.GetMiddlewares(g, r) {
+				if !stringx.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,110 @@
+package servergen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+)
+
+const resultTemplate = `{{range .}}{{$result := .}}
+// {{.Name}} is the response returned by {{.Func}}, the handler writes its Body as json with its Status.
+type {{.Name}} struct {
+	Status int
+	Body   interface{}
+}
+
+// HasBody tells whether the Body is set, a nil pointer of a response type isn't.
+func (r *{{.Name}}) HasBody() bool {
+	{{- if .Types}}
+	switch body := r.Body.(type) { {{- range .Types}}
+	case *{{.}}:
+		return body != nil
+	{{- end}}
+	}
+	{{- end}}
+	return r.Body != nil
+}
+{{range .Responses}}
+// {{.Func}} returns the {{.Status}} response of {{$result.Func}}{{if not .Type}}, which has no body{{end}}.
+func {{.Func}}({{if .Type}}body *{{.Type}}{{end}}) *{{$result.Name}} {
+	return &{{$result.Name}}{Status: {{.Status}}{{if .Type}}, Body: body{{end}}}
+}
+{{end}}{{end}}`
+
+type (
+	// RouteResult is the tagged result returned by the logic of a route with multiple responses.
+	RouteResult struct {
+		Name      string
+		Func      string
+		Responses []ResultResponse
+		// Types are the distinct types of the Responses
+		Types []string
+	}
+	// ResultResponse is a response of a RouteResult built by Func.
+	ResultResponse struct {
+		Func   string
+		Status int
+		Type   string
+	}
+)
+
+// GetRouteResult returns the result of the logic of the route, e.g. GetUserResult built by GetUserOK and GetUserNotFound.
+func GetRouteResult(route spec.Route) RouteResult {
+	handler, _ := apiutil.GetAnnotationValue(route.Annotations, "server", "handler")
+	fn := strings.Title(strings.TrimSuffix(strings.TrimSuffix(handler, "handler"), "Handler"))
+	result := RouteResult{Name: fn + "Result", Func: fn}
+	for _, res := range route.Responses {
+		item := ResultResponse{Func: fn + apiutil.StatusName(res.Status), Status: res.Status}
+		if len(res.Type.Name) > 0 {
+			item.Type = util.Title(res.Type.Name)
+			found := false
+			for _, typ := range result.Types {
+				found = found || typ == item.Type
+			}
+			if !found {
+				result.Types = append(result.Types, item.Type)
+			}
+		}
+		result.Responses = append(result.Responses, item)
+	}
+	return result
+}
+
+// GetResultReturn returns the return type of the logic of a route with multiple responses and the statement
+// returning its first success response, e.g. return types.GetUserOK(&types.User{}), nil.
+func GetResultReturn(route spec.Route) (string, string) {
+	result := GetRouteResult(route)
+	ok := result.Responses[0]
+	for _, res := range result.Responses {
+		if res.Status < 300 {
+			ok = res
+			break
+		}
+	}
+	returnString := fmt.Sprintf("return types.%s(), nil", ok.Func)
+	if len(ok.Type) > 0 {
+		returnString = fmt.Sprintf("return types.%s(&types.%s{}), nil", ok.Func, ok.Type)
+	}
+	return "(*types." + result.Name + ", error)", returnString
+}
+
+// BuildResults returns the results of the routes with multiple responses, which are generated along with the types.
+func BuildResults(routes []spec.Route) (string, error) {
+	var results []RouteResult
+	for _, route := range routes {
+		if len(route.Responses) > 0 {
+			results = append(results, GetRouteResult(route))
+		}
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	buffer := new(bytes.Buffer)
+	err := template.Must(template.New("resultTemplate").Parse(resultTemplate)).Execute(buffer, results)
+	return buffer.String(), err
+}
@@ -0,0 +1,181 @@
+package servergen
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofaith/go-zero/core/stringx"
+	"github.com/gofaith/goctlr/api/spec"
+	apiutil "github.com/gofaith/goctlr/api/util"
+	"github.com/gofaith/goctlr/util"
+	"github.com/iancoleman/strcase"
+)
+
+const routesFilename = "routes.go"
+
+type (
+	// routeGroup is a group of the api file in RoutesTemplate.
+	routeGroup struct {
+		Prefix string
+		// the jwt, the limits and the middlewares of the group, e.g. serverCtx.Cors
+		Middlewares []string
+		Routes      []route
+	}
+	route struct {
+		// the method of the router by Methods
+		Method string
+		// the pattern of the route relative to the prefix, and the pattern with the prefix
+		Path     string
+		FullPath string
+		// e.g. user.LoginHandler(serverCtx), wrapped by deprecated if the route is deprecated
+		Handler string
+		// the middlewares declared by the route besides the group
+		Middlewares []string
+	}
+)
+
+func genRoutes(dir string, fw *Framework, api *spec.ApiSpec) error {
+	groups, err := getRoutes(fw, api)
+	if err != nil {
+		return err
+	}
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(dir, handlerDir, routesFilename)
+	if err := util.RemoveOrQuit(filename); err != nil {
+		return err
+	}
+
+	hasTimeout, hasMiddlewares, hasDeprecated := false, false, false
+	for _, g := range groups {
+		for _, r := range g.Routes {
+			if len(g.Middlewares) > 0 || len(r.Middlewares) > 0 {
+				hasMiddlewares = true
+			}
+		}
+	}
+	for _, g := range api.Service.Groups {
+		if g.Timeout > 0 {
+			hasTimeout = true
+		}
+		for _, r := range g.Routes {
+			if r.Deprecation != nil {
+				hasDeprecated = true
+			}
+		}
+	}
+	return genFile(dir, handlerDir, routesFilename, fw.RoutesTemplate, map[string]interface{}{
+		"imports":        genRouteImports(parentPkg, api),
+		"time":           hasTimeout,
+		"httpxPkg":       util.JoinPackages(parentPkg, httpxDir),
+		"hasMiddlewares": hasMiddlewares,
+		"deprecated":     hasDeprecated,
+		"groups":         groups,
+	})
+}
+
+// genRouteImports returns the packages of the project used by the routes.
+func genRouteImports(parentPkg string, api *spec.ApiSpec) string {
+	var hasMiddleware bool
+	imports := []string{fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, contextDir))}
+	for _, group := range api.Service.Groups {
+		if group.Timeout > 0 || group.MaxBytes > 0 || group.Jwt {
+			hasMiddleware = true
+		}
+		for _, route := range group.Routes {
+			folder := apiutil.GetRouteFolder(group, route)
+			if len(folder) == 0 {
+				continue
+			}
+			item := fmt.Sprintf("%s \"%s\"", apiutil.GetRoutePackage(group, route), util.JoinPackages(parentPkg, handlerDir, folder))
+			if !stringx.Contains(imports, item) {
+				imports = append(imports, item)
+			}
+		}
+	}
+	if hasMiddleware {
+		imports = append(imports, fmt.Sprintf("\"%s\"", util.JoinPackages(parentPkg, middlewareDir)))
+	}
+	sort.Strings(imports)
+	return strings.Join(imports, "\n\t")
+}
+
+// FormatDuration formats d as a go expression, e.g. 30 * time.Second.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d * time.Minute", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d * time.Second", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%d * time.Millisecond", d/time.Millisecond)
+	default:
+		return fmt.Sprintf("time.Duration(%d)", d)
+	}
+}
+
+// getRoutes returns the groups of the api file with their middlewares, the jwt and the limits of the group
+// run first, then the middlewares of the group and the route.
+func getRoutes(fw *Framework, api *spec.ApiSpec) ([]routeGroup, error) {
+	var groups []routeGroup
+
+	for _, g := range api.Service.Groups {
+		item := routeGroup{Prefix: g.Prefix}
+		if auth, ok := apiutil.GetAnnotationValue(g.Annotations, "server", "jwt"); ok {
+			item.Middlewares = append(item.Middlewares, fmt.Sprintf("middleware.Jwt(serverCtx.Config.%s.AccessSecret)", auth))
+		}
+		if g.Timeout > 0 {
+			item.Middlewares = append(item.Middlewares, fmt.Sprintf("middleware.Timeout(%s)", FormatDuration(g.Timeout)))
+		}
+		if g.MaxBytes > 0 {
+			item.Middlewares = append(item.Middlewares, fmt.Sprintf("middleware.MaxBytes(%d)", g.MaxBytes))
+		}
+		groupMiddlewares := apiutil.GetMiddlewares(g, spec.Route{})
+		for _, name := range groupMiddlewares {
+			item.Middlewares = append(item.Middlewares, "serverCtx."+strcase.ToCamel(name))
+		}
+
+		for _, r := range g.Routes {
+			handler, ok := apiutil.GetAnnotationValue(r.Annotations, "server", "handler")
+			if !ok {
+				return nil, fmt.Errorf("missing handler annotation for route %q", r.Path)
+			}
+			handler = GetHandlerBaseName(handler) + "Handler(serverCtx)"
+			if len(apiutil.GetRouteFolder(g, r)) > 0 {
+				handler = apiutil.GetRoutePackage(g, r) + "." + strings.ToUpper(handler[:1]) + handler[1:]
+			}
+			if d := r.Deprecation; d != nil {
+				handler = fmt.Sprintf("deprecated(%s, %q, %q, %q)", handler, d.DeprecationHeader(), d.SunsetHeader(), d.LinkHeader())
+			}
+			var middlewares []string
+			// the group middlewares come first, the rest are declared by the route
+			for _, name := range apiutil.GetMiddlewares(g, r)[len(groupMiddlewares):] {
+				middlewares = append(middlewares, "serverCtx."+strcase.ToCamel(name))
+			}
+			item.Routes = append(item.Routes, route{
+				Method: fw.Methods[r.Method],
+				// the prefix is the path of the group, /api + "" is /api
+				Path:        getPath(fw, strings.TrimPrefix(r.Path, g.Prefix)),
+				FullPath:    getPath(fw, r.Path),
+				Handler:     handler,
+				Middlewares: middlewares,
+			})
+		}
+		groups = append(groups, item)
+	}
+
+	return groups, nil
+}
+
+func getPath(fw *Framework, path string) string {
+	if fw.Path == nil {
+		return path
+	}
+	return fw.Path(path)
+}
@@ -0,0 +1,33 @@
+package servergen
+
+import (
+	"errors"
+
+	"github.com/gofaith/goctlr/api/spec"
+	ctlutil "github.com/gofaith/goctlr/util"
+	"github.com/iancoleman/strcase"
+)
+
+const contextFilename = "servicecontext.go"
+
+func genServiceContext(dir string, fw *Framework, api *spec.ApiSpec) error {
+	parentPkg, err := getParentPackage(dir)
+	if err != nil {
+		return err
+	}
+	var middlewares []string
+	for _, name := range GetMiddlewares(api) {
+		name = strcase.ToCamel(name)
+		if name == "Config" {
+			return errors.New("the middleware Config conflicts with the Config of ServiceContext")
+		}
+		middlewares = append(middlewares, name)
+	}
+
+	return genFile(dir, contextDir, contextFilename, fw.ContextTemplate, map[string]interface{}{
+		"configPkg":     ctlutil.JoinPackages(parentPkg, configDir),
+		"httpxPkg":      ctlutil.JoinPackages(parentPkg, httpxDir),
+		"middlewarePkg": ctlutil.JoinPackages(parentPkg, middlewareDir),
+		"middlewares":   middlewares,
+	})
+}
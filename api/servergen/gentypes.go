@@ -1,4 +1,4 @@
-package gingen
+package servergen
 
 import (
 	"bytes"
@@ -17,15 +17,20 @@ import (
 const (
 	typesFile     = "types.go"
 	typesTemplate = `// DO NOT EDIT, generated by goctl
-package types{{if .containsTime}}
+package types{{if or .containsTime .containsFile}}
 import (
+	{{- if .containsFile}}
+	"mime/multipart"
+	{{- end}}
+	{{- if .containsTime}}
 	"time"
+	{{- end}}
 ){{end}}
 {{.types}}
 `
 )
 
-func BuildTypes(types []spec.Type) (string, error) {
+func buildTypes(fw *Framework, types []spec.Type) (string, error) {
 	var builder strings.Builder
 	first := true
 	for _, tp := range types {
@@ -34,7 +39,7 @@ func BuildTypes(types []spec.Type) (string, error) {
 		} else {
 			builder.WriteString("\n\n")
 		}
-		if err := writeType(&builder, tp, types); err != nil {
+		if err := writeType(&builder, fw, tp, types); err != nil {
 			return "", apiutil.WrapErr(err, "Type "+tp.Name+" generate error")
 		}
 	}
@@ -42,11 +47,16 @@ func BuildTypes(types []spec.Type) (string, error) {
 	return builder.String(), nil
 }
 
-func genTypes(dir string, api *spec.ApiSpec) error {
-	val, err := BuildTypes(api.Types)
+func genTypes(dir string, fw *Framework, api *spec.ApiSpec) error {
+	val, err := buildTypes(fw, api.Types)
 	if err != nil {
 		return err
 	}
+	results, err := BuildResults(api.Service.Routes)
+	if err != nil {
+		return err
+	}
+	val += "\n" + results
 
 	filename := path.Join(dir, typesDir, strings.ToLower(strings.TrimSuffix(api.Service.Name, "-api"))+typesFile)
 	if err := util.RemoveOrQuit(filename); err != nil {
@@ -67,6 +77,7 @@ func genTypes(dir string, api *spec.ApiSpec) error {
 	err = t.Execute(buffer, map[string]interface{}{
 		"types":        val,
 		"containsTime": api.ContainsTime(),
+		"containsFile": api.ContainsFile(),
 	})
 	if err != nil {
 		return nil
@@ -104,7 +115,7 @@ func convertTypeCase(types []spec.Type, t string) (string, error) {
 	return t, nil
 }
 
-func writeType(writer io.Writer, tp spec.Type, types []spec.Type) error {
+func writeType(writer io.Writer, fw *Framework, tp spec.Type, types []spec.Type) error {
 	fmt.Fprintf(writer, "type %s struct {\n", util.Title(tp.Name))
 	for _, member := range tp.Members {
 		if member.IsInline {
@@ -127,6 +138,10 @@ func writeType(writer io.Writer, tp spec.Type, types []spec.Type) error {
 		if err != nil {
 			return err
 		}
+		if member.IsFile() {
+			// the uploaded files are kept by the multipart form of the request
+			tpString = strings.Replace(strings.TrimPrefix(tpString, "*"), spec.FileTypeName, "*multipart.FileHeader", 1)
+		}
 		// pm, err := member.GetPropertyName()
 		// if err != nil {
 		// 	return err
@@ -137,7 +152,11 @@ func writeType(writer io.Writer, tp spec.Type, types []spec.Type) error {
 		// 			"should set json tag as `json:\"%s\"` \n", tp.Name, member.Name, util.Untitle(member.Name))
 		// 	}
 		// }
-		if err := writeProperty(writer, member.Name, tpString, member.Tag, member.Comment, 1); err != nil {
+		tag := member.Tag
+		if fw.Tag != nil {
+			tag = fw.Tag(member)
+		}
+		if err := writeProperty(writer, member.Name, tpString, tag, member.Comment, 1); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,75 @@
+package servergen
+
+// NetHttpHandlerTemplate is the handler of the frameworks serving http.HandlerFunc, their httpx has the
+// httpResponse snippet and Parse(r *http.Request, v any).
+const NetHttpHandlerTemplate = `package {{.package}}
+
+import (
+	"net/http"
+
+	logic "{{.logicPkg}}"
+	"{{.pkg}}/internal/httpx"
+	"{{.pkg}}/internal/svc"{{if .request}}
+	"{{.pkg}}/internal/types"{{end}}
+)
+
+func {{.handler}}(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		{{- if .files}}
+		// the files are limited by their maxSize, the form fields by the rest
+		r.Body = http.MaxBytesReader(w, r.Body, {{.maxBytes}})
+		{{- end}}
+		{{- if .request}}
+		var req types.{{.request}}
+		if e := httpx.Parse(r, &req); e != nil {
+			httpx.Error(w, e)
+			return
+		}
+		{{- range .files}}
+		if e := httpx.CheckFiles("{{.Field}}", {{.MaxSize}}, {{.Optional}}, req.{{.Name}}{{if .List}}...{{end}}); e != nil {
+			httpx.Error(w, e)
+			return
+		}
+		{{- end}}
+		{{- end}}
+
+		l := logic.New{{.name}}Logic(r.Context(), svcCtx)
+		{{- if .html}}
+		if e := l.{{.name}}(w, r{{if .request}}, req{{end}}); e != nil {
+			httpx.Error(w, e)
+		}
+		{{- else if .binary}}
+		name, content, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(w, e)
+			return
+		}
+		httpx.WriteBinary(w, r, name, content)
+		{{- else if .responses}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(w, e)
+		} else if resp == nil {
+			http.Error(w, "no response", http.StatusInternalServerError)
+		} else if !resp.HasBody() {
+			w.WriteHeader(resp.Status)
+		} else {
+			httpx.WriteJson(w, resp.Status, resp.Body)
+		}
+		{{- else if .response}}
+		resp, e := l.{{.name}}({{if .request}}req{{end}})
+		if e != nil {
+			httpx.Error(w, e)
+			return
+		}
+		httpx.WriteJson(w, http.StatusOK, resp)
+		{{- else}}
+		if e := l.{{.name}}({{if .request}}req{{end}}); e != nil {
+			httpx.Error(w, e)
+			return
+		}
+		httpx.Ok(w)
+		{{- end}}
+	}
+}
+`
@@ -0,0 +1,360 @@
+package servergen
+
+// snippets are the code shared by the templates of the frameworks, the templates include them by name
+// and import the packages they use.
+const snippets = `{{define "codeError"}}
+// CodeError is the json of the failed requests, return it from the logic to choose the status code.
+// Status is the status of the errors declared in the api file, whose codes aren't http statuses.
+type CodeError struct {
+	Status int    ` + "`json:\"-\"`" + `
+	Code   int    ` + "`json:\"code\"`" + `
+	Msg    string ` + "`json:\"desc\"`" + `
+}
+
+func NewCodeError(code int, msg string) *CodeError {
+	return &CodeError{Code: code, Msg: msg}
+}
+
+func (e *CodeError) Error() string {
+	return e.Msg
+}
+
+// toCodeError returns err as a CodeError and its status, the status is the Status if set, otherwise
+// the code if it's an http error status, otherwise 400.
+func toCodeError(err error) (int, *CodeError) {
+	var codeErr *CodeError
+	if !errors.As(err, &codeErr) {
+		codeErr = NewCodeError(http.StatusBadRequest, err.Error())
+	}
+	status := codeErr.Status
+	if status == 0 {
+		status = codeErr.Code
+	}
+	if status < http.StatusBadRequest || status > 599 {
+		status = http.StatusBadRequest
+	}
+	return status, codeErr
+}
+{{end}}
+
+{{define "httpResponse"}}
+// Error writes err as a CodeError, the status is its code if it's an http error status, otherwise 400.
+func Error(w http.ResponseWriter, err error) {
+	status, codeErr := toCodeError(err)
+	WriteJson(w, status, codeErr)
+}
+
+// WriteJson writes v as the json body with the status code.
+func WriteJson(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Ok writes an empty body with 200.
+func Ok(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+}
+{{end}}
+
+{{define "deprecated"}}
+// deprecated sets the Deprecation, Sunset and Link headers of the responses of a deprecated route, the empty ones aren't set.
+func deprecated(next http.HandlerFunc, deprecation, sunset, link string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		if len(sunset) > 0 {
+			w.Header().Set("Sunset", sunset)
+		}
+		if len(link) > 0 {
+			w.Header().Add("Link", link)
+		}
+		next(w, r)
+	}
+}
+{{end}}
+
+{{define "parse"}}
+// the size of the files kept in memory while parsing a multipart request, the rest is written to temporary files
+const multipartMemory = 32 << 20
+
+var (
+	fileType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	filesType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// parse parses the json body, the path, form and header parameters of the request into v by the tags
+// of its fields, the parameters take precedence over the body. pathValue returns the path parameter name.
+func parse(r *http.Request, pathValue func(name string) string, v any) error {
+	contentType := r.Header.Get("Content-Type")
+	if r.ContentLength > 0 && strings.Contains(contentType, "application/json") {
+		if e := json.NewDecoder(r.Body).Decode(v); e != nil {
+			return e
+		}
+	}
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		if e := r.ParseMultipartForm(multipartMemory); e != nil {
+			return e
+		}
+	} else if e := r.ParseForm(); e != nil {
+		return e
+	}
+	return parseParams(r, pathValue, reflect.ValueOf(v).Elem())
+}
+
+func parseParams(r *http.Request, pathValue func(name string) string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if e := parseParams(r, pathValue, rv.Field(i)); e != nil {
+				return e
+			}
+			continue
+		}
+		for _, key := range []string{"path", "form", "header"} {
+			tag, ok := field.Tag.Lookup(key)
+			if !ok {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			var values []string
+			switch key {
+			case "path":
+				if value := pathValue(name); value != "" {
+					values = []string{value}
+				}
+			case "form":
+				if field.Type == fileType || field.Type == filesType {
+					if e := setFiles(r, name, rv.Field(i)); e != nil {
+						return e
+					}
+					continue
+				}
+				values = r.Form[name]
+			case "header":
+				values = r.Header.Values(name)
+			}
+			if len(values) == 0 {
+				value, ok := getDefault(options)
+				if !ok {
+					if isOptional(options) {
+						continue
+					}
+					return fmt.Errorf("%s %s is not set", key, name)
+				}
+				values = []string{value}
+			}
+			if e := setValues(rv.Field(i), values); e != nil {
+				return fmt.Errorf("bad %s %s: %w", key, name, e)
+			}
+		}
+	}
+	return nil
+}
+
+func getDefault(options string) (string, bool) {
+	for _, option := range strings.Split(options, ",") {
+		if value, ok := strings.CutPrefix(option, "default="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func isOptional(options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if option == "optional" {
+			return true
+		}
+	}
+	return false
+}
+
+// setFiles sets the files uploaded as the form field name, a missing file is checked by CheckFiles.
+func setFiles(r *http.Request, name string, v reflect.Value) error {
+	if r.MultipartForm == nil {
+		return nil
+	}
+	files := r.MultipartForm.File[name]
+	if len(files) == 0 {
+		return nil
+	}
+	if v.Type() == fileType {
+		v.Set(reflect.ValueOf(files[0]))
+	} else {
+		v.Set(reflect.ValueOf(files))
+	}
+	return nil
+}
+
+func setValues(v reflect.Value, values []string) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if e := setValue(slice.Index(i), value); e != nil {
+				return e
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if e := setValues(elem.Elem(), values); e != nil {
+			return e
+		}
+		v.Set(elem)
+		return nil
+	default:
+		return setValue(v, values[0])
+	}
+}
+
+func setValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if e := setValue(elem.Elem(), value); e != nil {
+			return e
+		}
+		v.Set(elem)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, e := strconv.ParseBool(value)
+		if e != nil {
+			return e
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, e := strconv.ParseInt(value, 10, v.Type().Bits())
+		if e != nil {
+			return e
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, e := strconv.ParseUint(value, 10, v.Type().Bits())
+		if e != nil {
+			return e
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, e := strconv.ParseFloat(value, v.Type().Bits())
+		if e != nil {
+			return e
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+{{end}}
+
+{{define "checkFiles"}}
+// CheckFiles checks the files uploaded as the form field name, their total size can't be larger than maxSize bytes.
+func CheckFiles(name string, maxSize int64, optional bool, files ...*multipart.FileHeader) error {
+	var size int64
+	var n int
+	for _, file := range files {
+		if file != nil {
+			size += file.Size
+			n++
+		}
+	}
+	if n == 0 && !optional {
+		return fmt.Errorf("file %s is not set", name)
+	}
+	if size > maxSize {
+		return fmt.Errorf("file %s is larger than %d bytes", name, maxSize)
+	}
+	return nil
+}
+{{end}}
+
+{{define "writeBinary"}}
+// WriteBinary sends content as the download name, the content type is guessed from the extension of name.
+// A seekable content is served with range requests, and the content is closed if it's an io.Closer.
+func WriteBinary(w http.ResponseWriter, r *http.Request, name string, content io.Reader) {
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		// lets the browsers of other origins read the file name
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	}
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, time.Time{}, seeker)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if content != nil {
+		io.Copy(w, content)
+	}
+}
+{{end}}
+
+{{define "parseToken"}}
+// parseToken verifies the HMAC signature and the exp and nbf claims of the token, then returns its claims.
+func parseToken(token string, secret []byte) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string ` + "`json:\"alg\"`" + `
+	}
+	if e := decodeSegment(parts[0], &header); e != nil {
+		return nil, e
+	}
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, errors.New("unexpected signing method " + header.Alg)
+	}
+	signature, e := base64.RawURLEncoding.DecodeString(parts[2])
+	if e != nil {
+		return nil, e
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("bad signature")
+	}
+
+	var claims map[string]any
+	if e := decodeSegment(parts[1], &claims); e != nil {
+		return nil, e
+	}
+	now := float64(time.Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return nil, errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, e := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if e != nil {
+		return e
+	}
+	return json.Unmarshal(b, v)
+}
+{{end}}`
@@ -1,55 +1,23 @@
-package gingen
+package servergen
 
 import (
 	"bytes"
 	"fmt"
 	goformat "go/format"
 	"io"
-	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
 
-	"github.com/gofaith/go-zero/core/collection"
+	"github.com/gofaith/go-zero/core/stringx"
 	"github.com/gofaith/goctlr/api/spec"
 	"github.com/gofaith/goctlr/api/util"
 	goctlutil "github.com/gofaith/goctlr/util"
 )
 
-func ReplaceLine(file string, prefix string, replacement string) error {
-	b, e := ioutil.ReadFile(file)
-	if e != nil {
-		log.Println(e)
-		return e
-	}
-	out := new(bytes.Buffer)
-
-	for _, s := range strings.Split(string(b), "\n") {
-		if strings.HasPrefix(s, prefix) {
-			if s == replacement {
-				return nil
-			}
-			s = replacement
-		}
-		out.WriteString(s + "\n")
-	}
-
-	fo, e := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if e != nil {
-		log.Println(e)
-		return e
-	}
-	defer fo.Close()
-	_, e = fo.Write(out.Bytes())
-	if e != nil {
-		log.Println(e)
-		return e
-	}
-	return nil
-}
-
 func getParentPackage(dir string) (string, error) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
@@ -79,6 +47,49 @@ func getParentPackage(dir string) (string, error) {
 	return rootPath, nil
 }
 
+// genFile generates the file of the template text in the folder of dir if it doesn't exist,
+// the go files are formatted.
+func genFile(dir, folder, file, text string, data interface{}) error {
+	fp, created, err := util.MaybeCreateFile(dir, folder, file)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	defer fp.Close()
+
+	code, err := execute(file, text, data)
+	if err != nil {
+		return err
+	}
+	_, err = fp.WriteString(code)
+	return err
+}
+
+// execute executes the template text with the snippets, the go code is formatted.
+func execute(name, text string, data interface{}) (string, error) {
+	t := template.Must(template.New(name).Funcs(funcs).Parse(text))
+	template.Must(t.New("snippets").Parse(snippets))
+	buffer := new(bytes.Buffer)
+	if err := t.ExecuteTemplate(buffer, name, data); err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(name, ".go") {
+		return formatCode(buffer.String()), nil
+	}
+	return buffer.String(), nil
+}
+
+var funcs = template.FuncMap{
+	// join separates the items by commas, e.g. the middlewares passed to a router
+	"join": func(items []string) string {
+		return strings.Join(items, ", ")
+	},
+	// quote writes a string literal, e.g. the desc of an error
+	"quote": strconv.Quote,
+}
+
 func writeIndent(writer io.Writer, indent int) {
 	for i := 0; i < indent; i++ {
 		fmt.Fprint(writer, "\t")
@@ -98,17 +109,15 @@ func writeProperty(writer io.Writer, name, tp, tag, comment string, indent int)
 	return err
 }
 
+// getAuths returns the names of the jwt configs of the groups in the order they are declared.
 func getAuths(api *spec.ApiSpec) []string {
-	authNames := collection.NewSet()
+	var names []string
 	for _, g := range api.Service.Groups {
-		if value, ok := util.GetAnnotationValue(g.Annotations, "server", "jwt"); ok {
-			authNames.Add(value)
-		}
-		if value, ok := util.GetAnnotationValue(g.Annotations, "server", "signature"); ok {
-			authNames.Add(value)
+		if value, ok := util.GetAnnotationValue(g.Annotations, "server", "jwt"); ok && !stringx.Contains(names, value) {
+			names = append(names, value)
 		}
 	}
-	return authNames.KeysStr()
+	return names
 }
 
 func formatCode(code string) string {
@@ -0,0 +1,15 @@
+package servergen
+
+const (
+	interval      = "internal/"
+	typesPacket   = "types"
+	configDir     = interval + "config"
+	contextDir    = interval + "svc"
+	handlerDir    = interval + "handler"
+	httpxDir      = interval + "httpx"
+	middlewareDir = interval + "middleware"
+	logicDir      = interval + "logic"
+	typesDir      = interval + typesPacket
+
+	SERVER_TYPE_HTML = "html"
+)
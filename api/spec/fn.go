@@ -2,7 +2,10 @@ package spec
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/gofaith/go-zero/core/stringx"
@@ -14,6 +17,19 @@ const (
 	NameKey   = "name"
 	OptionKey = "option"
 	BodyTag   = "json"
+	PathTag   = "path"
+	FormTag   = "form"
+	HeaderTag = "header"
+
+	StreamSSE = "sse"
+	StreamWS  = "ws"
+
+	FileTypeName   = "file"
+	BinaryTypeName = "binary"
+	// the size limit of a file member without the maxSize option
+	DefaultMaxFileSize = 10 << 20
+	// the layout of the dates of @deprecated
+	DateLayout = "2006-01-02"
 )
 
 var (
@@ -137,3 +153,161 @@ func (t Type) GetNonBodyMembers() []Member {
 	}
 	return result
 }
+
+// GetTagKey returns the key of the member tag, such as json, path, form or header.
+func (m Member) GetTagKey() string {
+	matches := TagRe.FindStringSubmatch(m.Tag)
+	for i := range matches {
+		if TagSubNames[i] == TagKey {
+			return matches[i]
+		}
+	}
+	return ""
+}
+
+// GetTagName returns the name declared in the member tag, falls back to the untitled member name.
+func (m Member) GetTagName() string {
+	name, err := m.GetPropertyName()
+	if err != nil || len(name) == 0 {
+		return util.Untitle(m.Name)
+	}
+	return name
+}
+
+func (m Member) IsPathMember() bool {
+	return m.GetTagKey() == PathTag
+}
+
+func (m Member) IsFormMember() bool {
+	return m.GetTagKey() == FormTag
+}
+
+func (m Member) IsHeaderMember() bool {
+	return m.GetTagKey() == HeaderTag
+}
+
+// GetPathParams returns the names of the path variables, e.g. name for /api/user/:name
+func (r Route) GetPathParams() []string {
+	var result []string
+	for _, seg := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(seg, ":") {
+			result = append(result, seg[1:])
+		}
+	}
+	return result
+}
+
+// IsFile tells whether the member is a file or a list of files, see FileTypeName.
+func (m Member) IsFile() bool {
+	t := strings.TrimPrefix(m.Type, "*")
+	return t == FileTypeName || t == "[]"+FileTypeName
+}
+
+// IsFileList tells whether the member is a list of files.
+func (m Member) IsFileList() bool {
+	return strings.TrimPrefix(m.Type, "*") == "[]"+FileTypeName
+}
+
+// GetMaxSize returns the maxSize option of a file member in bytes, e.g. maxSize=2MB,
+// it's the limit of the total size of the files for a list of files.
+func (m Member) GetMaxSize() (int64, error) {
+	var option string
+	matches := TagRe.FindStringSubmatch(m.Tag)
+	for i := range matches {
+		if TagSubNames[i] == OptionKey {
+			option = matches[i]
+		}
+	}
+	for _, field := range strings.Split(option, ",") {
+		if !strings.HasPrefix(field, "maxSize=") {
+			continue
+		}
+		size, err := ParseSize(strings.TrimPrefix(field, "maxSize="))
+		if err != nil {
+			return 0, fmt.Errorf("bad maxSize option of member %s: %s", m.Name, field)
+		}
+		return size, nil
+	}
+	return DefaultMaxFileSize, nil
+}
+
+// ParseSize parses a positive size in bytes with an optional KB, MB or GB unit, e.g. 2MB.
+func ParseSize(value string) (int64, error) {
+	unit := int64(1)
+	for suffix, n := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(value, suffix) {
+			value, unit = strings.TrimSuffix(value, suffix), n
+			break
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("bad size %q", value)
+	}
+	return size * unit, nil
+}
+
+// GetError returns the error declared in the errors block by name.
+func (spec *ApiSpec) GetError(name string) (Error, bool) {
+	for _, e := range spec.Errors {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Error{}, false
+}
+
+// GetRouteErrors returns the errors the route may return, in the order of its doc annotation.
+func (spec *ApiSpec) GetRouteErrors(route Route) []Error {
+	var errs []Error
+	for _, name := range route.Errors {
+		if e, ok := spec.GetError(name); ok {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// DeprecationHeader returns the Deprecation header of the responses, the date it's deprecated since, e.g. @1767225600,
+// or true if the date is unknown.
+func (d *Deprecation) DeprecationHeader() string {
+	if d.Since.IsZero() {
+		return "true"
+	}
+	return "@" + strconv.FormatInt(d.Since.Unix(), 10)
+}
+
+// SunsetHeader returns the Sunset header of the responses, e.g. Wed, 01 Jul 2026 00:00:00 GMT, empty if there is no sunset.
+func (d *Deprecation) SunsetHeader() string {
+	if d.Sunset.IsZero() {
+		return ""
+	}
+	return d.Sunset.UTC().Format(http.TimeFormat)
+}
+
+// LinkHeader returns the Link header of the responses, e.g. <https://...>; rel="deprecation", empty if there is no link.
+func (d *Deprecation) LinkHeader() string {
+	if len(d.Link) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("<%s>; rel=\"deprecation\"", d.Link)
+}
+
+// Message describes the deprecation for the clients, e.g. deprecated since 2026-01-01, removed on 2026-07-01, see https://...,
+// empty if d is nil, which the routes not deprecated have.
+func (d *Deprecation) Message() string {
+	if d == nil {
+		return ""
+	}
+	message := "deprecated"
+	if !d.Since.IsZero() {
+		message += " since " + d.Since.Format(DateLayout)
+	}
+	if !d.Sunset.IsZero() {
+		message += ", removed on " + d.Sunset.Format(DateLayout)
+	}
+	if len(d.Link) > 0 {
+		message += ", see " + d.Link
+	}
+	return message
+}
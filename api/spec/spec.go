@@ -1,5 +1,7 @@
 package spec
 
+import "time"
+
 type (
 	Annotation struct {
 		Name       string
@@ -7,14 +9,32 @@ type (
 	}
 
 	ApiSpec struct {
-		Info    Info
-		Types   []Type
+		Info  Info
+		Types []Type
+		// Errors are declared in the errors block, in their order
+		Errors  []Error
 		Service Service
 	}
 
+	// Error is declared in the errors block, e.g. UserNotFound = 1001 "user not found" 404
+	Error struct {
+		Name string
+		Code int
+		Desc string
+		// Status is the http status of the responses of the error, 400 if it's omitted
+		Status int
+	}
+
 	Group struct {
-		Desc        string
-		Jwt         bool
+		Desc string
+		Jwt  bool
+		// Prefix is already joined into the paths of Routes, e.g. /api/v1/admin
+		Prefix string
+		// Version is the version of the routes, e.g. v2 of @server(version: v2), it's joined into the Prefix
+		Version string
+		// Timeout and MaxBytes override the ones of the server if they are not zero
+		Timeout     time.Duration
+		MaxBytes    int64
 		Annotations []Annotation
 		Routes      []Route
 	}
@@ -54,6 +74,34 @@ type (
 		Path         string
 		RequestType  Type
 		ResponseType Type
+		// StreamSSE or StreamWS for streaming routes, the ResponseType is the type of the events
+		Stream string
+		// returns(binary), the response is a file download and the ResponseType is empty
+		Binary bool
+		// Errors are the names of the errors the route may return, e.g. @doc(errors: UserNotFound,Forbidden)
+		Errors []string
+		// Responses are the responses of returns(200: user, 404: notFound) keyed by the status codes,
+		// the ResponseType is the type of the first 2xx response
+		Responses []Response
+		// Version is the version of the group of the route, e.g. v2
+		Version string
+		// Deprecation is declared by the @deprecated annotation of the route or its group, nil if it's not deprecated
+		Deprecation *Deprecation
+	}
+
+	// Deprecation is declared by @deprecated(since: 2026-01-01, sunset: 2026-07-01, link: https://...),
+	// its fields are optional
+	Deprecation struct {
+		Since  time.Time
+		Sunset time.Time
+		// Link documents the replacement of the route
+		Link string
+	}
+
+	// Response is a response of a route with multiple responses, the Type is empty if it has no body
+	Response struct {
+		Status int
+		Type   Type
 	}
 
 	Service struct {
@@ -117,6 +165,10 @@ type (
 	StructType struct {
 		StringExpr string
 	}
+	// an uploaded file of a multipart request
+	FileType struct {
+		StringExpr string
+	}
 )
 
 func (spec *ApiSpec) ContainsTime() bool {
@@ -130,3 +182,15 @@ func (spec *ApiSpec) ContainsTime() bool {
 	}
 	return false
 }
+
+// ContainsFile tells whether a type has a file member, see FileTypeName.
+func (spec *ApiSpec) ContainsFile() bool {
+	for _, item := range spec.Types {
+		for _, member := range item.Members {
+			if member.IsFile() {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package stdhttpgen
+
+import (
+	"strings"
+
+	"github.com/gofaith/goctlr/api/servergen"
+	"github.com/urfave/cli"
+)
+
+var framework = &servergen.Framework{
+	Name: "stdhttp",
+	// the method and wildcard patterns of http.ServeMux need go 1.22
+	GoVersion:          "1.22",
+	ConfigExt:          "json",
+	EtcTemplate:        servergen.JsonEtcTemplate,
+	ConfigTemplate:     servergen.JsonConfigTemplate,
+	MainTemplate:       mainTemplate,
+	HttpxTemplate:      httpxTemplate,
+	ContextTemplate:    contextTemplate,
+	MiddlewareTemplate: middlewareTemplate,
+	JwtTemplate:        jwtMiddlewareTemplate,
+	LimitTemplate:      limitMiddlewareTemplate,
+	HandlerTemplate:    servergen.NetHttpHandlerTemplate,
+	RoutesTemplate:     routesTemplate,
+	Methods: map[string]string{
+		"delete": "DELETE",
+		"get":    "GET",
+		"head":   "HEAD",
+		"post":   "POST",
+		"put":    "PUT",
+		"patch":  "PATCH",
+		"all":    "",
+	},
+	Path:       getPattern,
+	HtmlParams: "w http.ResponseWriter, r *http.Request",
+	HtmlImport: `"net/http"`,
+}
+
+func GoCommand(c *cli.Context) error {
+	return servergen.GoCommand(c, framework)
+}
+
+// getPattern converts the path to a pattern of http.ServeMux, e.g. /users/{id} for /users/:id.
+func getPattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	pattern := strings.Join(segments, "/")
+	// a pattern ending with a slash matches all the paths under it
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{$}"
+	}
+	return pattern
+}
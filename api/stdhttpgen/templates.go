@@ -0,0 +1,203 @@
+package stdhttpgen
+
+const (
+	mainTemplate = `package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"{{.configPkg}}"
+	"{{.handlerPkg}}"
+	"{{.svcPkg}}"
+)
+
+var configFile = flag.String("f", "{{.configFile}}", "the config file")
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	config.MustLoad(*configFile, &c)
+
+	ctx := svc.NewServiceContext(c)
+	mux := http.NewServeMux()
+	handler.RegisterHandlers(mux, ctx)
+
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	fmt.Printf("Starting server at %s...\n", addr)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+`
+	httpxTemplate = `package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware wraps a handler, it returns without calling next to reject the request.
+type Middleware func(next http.HandlerFunc) http.HandlerFunc
+
+// Chain wraps handler with the middlewares, the first one runs first.
+func Chain(handler http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+{{template "codeError"}}
+{{template "httpResponse"}}
+// Parse parses the json body, the path, form and header parameters of the request into v by the tags
+// of its fields, the parameters take precedence over the body.
+func Parse(r *http.Request, v any) error {
+	return parse(r, r.PathValue, v)
+}
+{{template "parse"}}
+{{template "checkFiles"}}
+{{template "writeBinary"}}`
+	contextTemplate = `package svc
+
+import (
+	"{{.configPkg}}"{{if .middlewares}}
+	"{{.httpxPkg}}"
+	"{{.middlewarePkg}}"{{end}}
+)
+
+type ServiceContext struct {
+	Config config.Config
+	{{- range .middlewares}}
+	{{.}} httpx.Middleware
+	{{- end}}
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+		{{- range .middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+		{{- end}}
+	}
+}
+`
+	middlewareTemplate = `package middleware
+
+import "net/http"
+
+type {{.name}}Middleware struct {
+}
+
+func New{{.name}}Middleware() *{{.name}}Middleware {
+	return &{{.name}}Middleware{}
+}
+
+func (m *{{.name}}Middleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// todo: add your logic here, return without calling next to reject the request
+
+		next(w, r)
+	}
+}
+`
+	jwtMiddlewareTemplate = `package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"{{.httpxPkg}}"
+)
+
+// Jwt rejects the requests without a valid token signed by secret as the bearer of the Authorization header,
+// the claims of the token are set as the values of the request context.
+func Jwt(secret string) httpx.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, e := parseToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), []byte(secret))
+			if e != nil {
+				httpx.Error(w, httpx.NewCodeError(http.StatusUnauthorized, e.Error()))
+				return
+			}
+			ctx := r.Context()
+			for k, v := range claims {
+				ctx = context.WithValue(ctx, k, v)
+			}
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+{{template "parseToken"}}`
+	limitMiddlewareTemplate = `package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"{{.httpxPkg}}"
+)
+
+// Timeout responds 503 if the handler doesn't finish in d.
+func Timeout(d time.Duration) httpx.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable)).ServeHTTP
+	}
+}
+
+// MaxBytes limits the request bodies to n bytes.
+func MaxBytes(n int64) httpx.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next(w, r)
+		}
+	}
+}
+`
+	routesTemplate = `// DO NOT EDIT, generated by goctl
+package handler
+
+import (
+	"net/http"{{if .time}}
+	"time"{{end}}
+
+	{{.imports}}{{if .hasMiddlewares}}
+	"{{.httpxPkg}}"{{end}}
+)
+
+func RegisterHandlers(mux *http.ServeMux, serverCtx *svc.ServiceContext) {
+	{{- range .groups}}{{$group := .}}
+	{{- range .Routes}}
+	{{- if or $group.Middlewares .Middlewares}}
+	mux.HandleFunc("{{if .Method}}{{.Method}} {{end}}{{.FullPath}}", httpx.Chain({{.Handler}}{{range $group.Middlewares}}, {{.}}{{end}}{{range .Middlewares}}, {{.}}{{end}}))
+	{{- else}}
+	mux.HandleFunc("{{if .Method}}{{.Method}} {{end}}{{.FullPath}}", {{.Handler}})
+	{{- end}}
+	{{- end}}
+	{{- end}}
+}
+{{- if .deprecated}}
+{{template "deprecated"}}
+{{- end}}
+`
+)
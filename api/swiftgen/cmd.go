@@ -0,0 +1,48 @@
+package swiftgen
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/urfave/cli"
+)
+
+func SwiftCommand(c *cli.Context) error {
+	apiFile := c.String("api")
+	if apiFile == "" {
+		return errors.New("missing -api")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		return errors.New("missing -dir")
+	}
+	pkg := c.String("package")
+
+	p, e := parser.NewParser(apiFile)
+	if e != nil {
+		return e
+	}
+	api, e := p.Parse()
+	if e != nil {
+		return e
+	}
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		return e
+	}
+
+	if len(pkg) > 0 {
+		e = genPackage(dir, pkg)
+		if e != nil {
+			return e
+		}
+		dir = filepath.Join(dir, "Sources", pkg)
+	}
+	e = genBase(dir)
+	if e != nil {
+		return e
+	}
+	return genApi(dir, api)
+}
@@ -0,0 +1,689 @@
+package swiftgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/gofaith/goctlr/api/util"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	apiClientTemplate = `// Code generated by goctlr. DO NOT EDIT.
+import Foundation
+#if canImport(FoundationNetworking)
+import FoundationNetworking
+#endif
+
+/// The error payload written by the server, status is the http status of the response.
+public struct ErrorCode: Error, Codable, CustomStringConvertible {
+    public var code: Int
+    public var desc: String
+    public var status: Int = 0
+
+    enum CodingKeys: String, CodingKey {
+        case code = "code"
+        case desc = "desc"
+    }
+
+    public init(code: Int = 0, desc: String = "", status: Int = 0) {
+        self.code = code
+        self.desc = desc
+        self.status = status
+    }
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.container(keyedBy: CodingKeys.self)
+        code = try container.decodeIfPresent(Int.self, forKey: .code) ?? 0
+        desc = try container.decodeIfPresent(String.self, forKey: .desc) ?? ""
+    }
+
+    public var description: String {
+        "\(status): \(code) \(desc)"
+    }
+}
+
+/// An arbitrary json value, used for interface{} members.
+public enum JSONValue: Codable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let value = try? container.decode(Bool.self) {
+            self = .bool(value)
+        } else if let value = try? container.decode(Double.self) {
+            self = .number(value)
+        } else if let value = try? container.decode(String.self) {
+            self = .string(value)
+        } else if let value = try? container.decode([JSONValue].self) {
+            self = .array(value)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null:
+            try container.encodeNil()
+        case .bool(let value):
+            try container.encode(value)
+        case .number(let value):
+            try container.encode(value)
+        case .string(let value):
+            try container.encode(value)
+        case .array(let value):
+            try container.encode(value)
+        case .object(let value):
+            try container.encode(value)
+        }
+    }
+}
+
+/// A file uploaded as a multipart field, or downloaded from a binary response.
+public struct ApiFile: Equatable {
+    public var name: String
+    public var contentType: String
+    public var content: Data
+
+    public init(name: String = "", contentType: String = "", content: Data = Data()) {
+        self.name = name
+        self.contentType = contentType
+        self.content = content
+    }
+}
+
+/// Sends the requests of the generated apis, requires iOS 15 / macOS 12 for async URLSession.
+public final class ApiClient {
+    /// Provides the value of the Authorization header, nil or empty means anonymous.
+    public typealias TokenProvider = () async throws -> String?
+
+    public let baseURL: URL
+    public let session: URLSession
+    public var timeout: TimeInterval
+    public var defaultHeaders: [String: String]
+    public var token: TokenProvider?
+
+    public init(
+        baseURL: URL,
+        session: URLSession = .shared,
+        timeout: TimeInterval = 10,
+        defaultHeaders: [String: String] = [:],
+        token: TokenProvider? = nil
+    ) {
+        self.baseURL = baseURL
+        self.session = session
+        self.timeout = timeout
+        self.defaultHeaders = defaultHeaders
+        self.token = token
+    }
+
+    public static let encoder: JSONEncoder = {
+        let encoder = JSONEncoder()
+        encoder.dateEncodingStrategy = .custom { date, encoder in
+            var container = encoder.singleValueContainer()
+            try container.encode(formatDate(date))
+        }
+        return encoder
+    }()
+
+    public static let decoder: JSONDecoder = {
+        let decoder = JSONDecoder()
+        decoder.dateDecodingStrategy = .custom { decoder in
+            let container = try decoder.singleValueContainer()
+            let str = try container.decode(String.self)
+            guard let date = parseDate(str) else {
+                throw DecodingError.dataCorruptedError(in: container, debugDescription: "invalid RFC 3339 time: \(str)")
+            }
+            return date
+        }
+        return decoder
+    }()
+
+    // encoding/json writes time.Time as RFC 3339, the fractional seconds are optional
+    static func parseDate(_ str: String) -> Date? {
+        let formatter = ISO8601DateFormatter()
+        formatter.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+        if let date = formatter.date(from: str) {
+            return date
+        }
+        formatter.formatOptions = [.withInternetDateTime]
+        return formatter.date(from: str)
+    }
+
+    static func formatDate(_ date: Date) -> String {
+        let formatter = ISO8601DateFormatter()
+        formatter.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+        return formatter.string(from: date)
+    }
+
+    /// Escapes a value used as a path segment.
+    public static func pathEscape(_ value: CustomStringConvertible) -> String {
+        var allowed = CharacterSet.urlPathAllowed
+        allowed.remove("/")
+        let str = value.description
+        return str.addingPercentEncoding(withAllowedCharacters: allowed) ?? str
+    }
+
+    /// Sends the request and returns the body of a 2xx response, otherwise throws ErrorCode.
+    @discardableResult
+    public func request(
+        _ method: String,
+        _ path: String,
+        query: [URLQueryItem] = [],
+        headers: [String: String] = [:],
+        body: (any Encodable)? = nil
+    ) async throws -> Data {
+        let req = try await makeRequest(method, path, query: query, headers: headers, body: body)
+        return try await send(req).0
+    }
+
+    /// Sends the fields and files as multipart/form-data and returns the body of a 2xx response, otherwise throws ErrorCode.
+    @discardableResult
+    public func upload(
+        _ method: String,
+        _ path: String,
+        headers: [String: String] = [:],
+        fields: [URLQueryItem] = [],
+        files: [(String, ApiFile)] = []
+    ) async throws -> Data {
+        var req = try await makeRequest(method, path, query: [], headers: headers, body: nil)
+        let boundary = "goctlr-\(UUID().uuidString)"
+        var body = Data()
+        func append(_ str: String) {
+            body.append(Data(str.utf8))
+        }
+        for field in fields {
+            append("--\(boundary)\r\nContent-Disposition: form-data; name=\"\(Self.quote(field.name))\"\r\n\r\n")
+            append("\(field.value ?? "")\r\n")
+        }
+        for (name, file) in files {
+            append("--\(boundary)\r\nContent-Disposition: form-data; name=\"\(Self.quote(name))\"; filename=\"\(Self.quote(file.name))\"\r\n")
+            append("Content-Type: \(file.contentType.isEmpty ? "application/octet-stream" : file.contentType)\r\n\r\n")
+            body.append(file.content)
+            append("\r\n")
+        }
+        append("--\(boundary)--\r\n")
+        req.setValue("multipart/form-data; boundary=\(boundary)", forHTTPHeaderField: "Content-Type")
+        req.httpBody = body
+        return try await send(req).0
+    }
+
+    /// Sends the request and returns the file of a 2xx binary response, named by its Content-Disposition header.
+    public func download(
+        _ method: String,
+        _ path: String,
+        query: [URLQueryItem] = [],
+        headers: [String: String] = [:],
+        body: (any Encodable)? = nil
+    ) async throws -> ApiFile {
+        let req = try await makeRequest(method, path, query: query, headers: headers, body: body)
+        let (data, response) = try await send(req)
+        return ApiFile(
+            name: Self.fileName(response.value(forHTTPHeaderField: "Content-Disposition") ?? ""),
+            contentType: response.value(forHTTPHeaderField: "Content-Type") ?? "",
+            content: data
+        )
+    }
+
+    private func makeRequest(
+        _ method: String,
+        _ path: String,
+        query: [URLQueryItem],
+        headers: [String: String],
+        body: (any Encodable)?
+    ) async throws -> URLRequest {
+        guard var components = URLComponents(url: baseURL, resolvingAgainstBaseURL: false) else {
+            throw URLError(.badURL)
+        }
+        var basePath = components.percentEncodedPath
+        if basePath.hasSuffix("/") {
+            basePath.removeLast()
+        }
+        components.percentEncodedPath = basePath + path
+        if !query.isEmpty {
+            components.queryItems = query
+        }
+        guard let url = components.url else {
+            throw URLError(.badURL)
+        }
+
+        var req = URLRequest(url: url, timeoutInterval: timeout)
+        req.httpMethod = method
+        for (key, value) in defaultHeaders {
+            req.setValue(value, forHTTPHeaderField: key)
+        }
+        if let token = token, let value = try await token(), !value.isEmpty {
+            req.setValue(value, forHTTPHeaderField: "Authorization")
+        }
+        for (key, value) in headers {
+            req.setValue(value, forHTTPHeaderField: key)
+        }
+        if let body = body {
+            req.setValue("application/json", forHTTPHeaderField: "Content-Type")
+            req.httpBody = try Self.encoder.encode(body)
+        }
+        return req
+    }
+
+    private func send(_ req: URLRequest) async throws -> (Data, HTTPURLResponse) {
+        let (data, response) = try await session.data(for: req)
+        guard let response = response as? HTTPURLResponse, (200..<300).contains(response.statusCode) else {
+            let status = (response as? HTTPURLResponse)?.statusCode ?? 0
+            var error = (try? Self.decoder.decode(ErrorCode.self, from: data))
+                ?? ErrorCode(code: status, desc: String(decoding: data, as: UTF8.self))
+            error.status = status
+            throw error
+        }
+        return (data, response)
+    }
+
+    // the quoted-string of mime/multipart
+    static func quote(_ str: String) -> String {
+        str.replacingOccurrences(of: "\\", with: "\\\\").replacingOccurrences(of: "\"", with: "\\\"")
+    }
+
+    /// Returns the file name of a Content-Disposition header, filename* is preferred.
+    static func fileName(_ disposition: String) -> String {
+        var plain = ""
+        for item in disposition.split(separator: ";") {
+            let param = item.trimmingCharacters(in: .whitespaces)
+            let lower = param.lowercased()
+            if lower.hasPrefix("filename*=") {
+                // charset'language'percent-encoded-name
+                let parts = param.dropFirst("filename*=".count).split(separator: "'", maxSplits: 2, omittingEmptySubsequences: false)
+                if parts.count == 3, let name = String(parts[2]).removingPercentEncoding {
+                    return name
+                }
+            } else if lower.hasPrefix("filename=") {
+                plain = param.dropFirst("filename=".count).trimmingCharacters(in: CharacterSet(charactersIn: "\""))
+            }
+        }
+        return plain
+    }
+
+    public func decode<T: Decodable>(_ type: T.Type, from data: Data) throws -> T {
+        try Self.decoder.decode(type, from: data)
+    }
+}
+`
+	packageTemplate = `// swift-tools-version:5.7
+import PackageDescription
+
+let package = Package(
+    name: "{{.}}",
+    platforms: [.iOS(.v15), .macOS(.v12), .tvOS(.v15), .watchOS(.v8)],
+    products: [
+        .library(name: "{{.}}", targets: ["{{.}}"]),
+    ],
+    targets: [
+        .target(name: "{{.}}"),
+    ]
+)
+`
+	apiTemplate = `// Code generated by goctlr. DO NOT EDIT.
+import Foundation
+{{range .types}}
+public struct {{.Name}}{{if .Codable}}: Codable{{end}} {
+{{range .Members}}{{if ne .Comment ""}}    /// {{.Comment}}
+{{end}}    public var {{.Name}}: {{.Type}}{{if not .Body}}{{if ne .Default ""}} = {{.Default}}{{end}}{{end}}
+{{end}}{{if .Keys}}
+    enum CodingKeys: String, CodingKey {
+{{range .Keys}}        case {{.Name}} = "{{.Key}}"
+{{end}}    }
+{{end}}
+    public init({{range $i, $m := .Members}}{{if $i}}, {{end}}{{$m.Name}}: {{$m.Type}}{{if ne $m.Default ""}} = {{$m.Default}}{{end}}{{end}}) {
+{{range .Members}}        self.{{.Name}} = {{.Name}}
+{{end}}    }
+}
+{{end}}
+{{if ne .desc ""}}/// {{.desc}}
+{{end}}public final class {{.name}} {
+    public let client: ApiClient
+
+    public init(client: ApiClient) {
+        self.client = client
+    }
+{{range .routes}}
+{{if ne .Doc ""}}    /// {{.Doc}}
+{{end}}{{if ne .Deprecated ""}}    @available(*, deprecated, message: "{{.Deprecated}}")
+{{end}}    public func {{.Func}}({{if ne .Request ""}}_ req: {{.Request}}{{end}}) async throws{{if ne .Response ""}} -> {{.Response}}{{end}} {
+{{if .Query}}        var query: [URLQueryItem] = []
+{{range .Query}}        {{.}}
+{{end}}{{end}}{{if .Headers}}        var headers: [String: String] = [:]
+{{range .Headers}}        {{.}}
+{{end}}{{end}}{{if .Files}}        var files: [(String, ApiFile)] = []
+{{range .Files}}        {{.}}
+{{end}}        {{if ne .Response ""}}let data = {{end}}try await client.upload("{{.Method}}", "{{.Path}}"{{if .Headers}}, headers: headers{{end}}{{if .Query}}, fields: query{{end}}, files: files)
+{{else if .Binary}}        return try await client.download("{{.Method}}", "{{.Path}}"{{if .Query}}, query: query{{end}}{{if .Headers}}, headers: headers{{end}}{{if .Body}}, body: req{{end}})
+{{else}}        {{if ne .Response ""}}let data = {{end}}try await client.request("{{.Method}}", "{{.Path}}"{{if .Query}}, query: query{{end}}{{if .Headers}}, headers: headers{{end}}{{if .Body}}, body: req{{end}})
+{{end}}{{if and (ne .Response "") (not .Binary)}}        return try client.decode({{.Response}}.self, from: data)
+{{end}}    }
+{{end}}{{if .errors}}
+    /// The codes of the errors declared in the api file, e.g. error.code == {{.name}}.ErrorCodes.{{with index .errors 0}}{{swiftName .Name}}{{end}}
+    public enum ErrorCodes {
+{{range .errors}}        /// {{.Desc}}
+        public static let {{swiftName .Name}} = {{.Code}}
+{{end}}    }
+{{end}}}
+`
+)
+
+type (
+	swiftMember struct {
+		Name    string
+		Key     string
+		Type    string
+		Default string
+		Comment string
+		Body    bool
+	}
+	swiftType struct {
+		Name    string
+		Codable bool
+		Members []swiftMember
+		Keys    []swiftMember
+	}
+	swiftRoute struct {
+		Doc      string
+		Method   string
+		Path     string
+		Func     string
+		Request  string
+		Response string
+		Query    []string
+		Headers  []string
+		Files    []string
+		Body     bool
+		Binary   bool
+		// Deprecated is the message of the deprecated route, empty if it isn't
+		Deprecated string
+	}
+)
+
+var swiftKeywords = map[string]bool{
+	"associatedtype": true, "class": true, "deinit": true, "enum": true, "extension": true,
+	"fileprivate": true, "func": true, "import": true, "init": true, "inout": true,
+	"internal": true, "let": true, "open": true, "operator": true, "private": true,
+	"protocol": true, "public": true, "static": true, "struct": true, "subscript": true,
+	"typealias": true, "var": true, "break": true, "case": true, "continue": true,
+	"default": true, "defer": true, "do": true, "else": true, "fallthrough": true,
+	"for": true, "guard": true, "if": true, "in": true, "repeat": true, "return": true,
+	"switch": true, "where": true, "while": true, "as": true, "catch": true, "false": true,
+	"is": true, "nil": true, "rethrows": true, "super": true, "self": true, "throw": true,
+	"throws": true, "true": true, "try": true,
+}
+
+func genBase(dir string) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	file, e := os.OpenFile(filepath.Join(dir, "ApiClient.swift"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	_, e = file.WriteString(apiClientTemplate)
+	return e
+}
+
+func genPackage(dir, pkg string) error {
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+	path := filepath.Join(dir, "Package.swift")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("Package.swift already exists, skipped it.")
+		return nil
+	}
+
+	file, e := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	t, e := template.New("Package.swift").Parse(packageTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, pkg)
+}
+
+func genApi(dir string, api *spec.ApiSpec) error {
+	api = util.SkipStreams(api, "swift")
+	name := strcase.ToCamel(api.Info.Title + "Api")
+	e := os.MkdirAll(dir, 0755)
+	if e != nil {
+		return e
+	}
+
+	file, e := os.OpenFile(filepath.Join(dir, name+".swift"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	var types []swiftType
+	for _, tp := range api.Types {
+		types = append(types, buildSwiftType(api, tp))
+	}
+	var routes []swiftRoute
+	for _, route := range api.Service.Routes {
+		routes = append(routes, buildSwiftRoute(api, route))
+	}
+
+	t, e := template.New(name).Funcs(template.FuncMap{"swiftName": swiftName}).Parse(apiTemplate)
+	if e != nil {
+		return e
+	}
+	return t.Execute(file, map[string]interface{}{
+		"name":   name,
+		"desc":   strings.TrimSpace(api.Info.Desc),
+		"types":  types,
+		"routes": routes,
+		"errors": api.Errors,
+	})
+}
+
+func buildSwiftType(api *spec.ApiSpec, tp spec.Type) swiftType {
+	result := swiftType{Name: strcase.ToCamel(tp.Name)}
+	members := util.FlattenMembers(api.Types, tp)
+	for _, member := range members {
+		typ, optional := toSwiftType(member)
+		m := swiftMember{
+			Name:    swiftName(member.Name),
+			Key:     member.GetTagName(),
+			Type:    typ,
+			Comment: strings.TrimSpace(strings.TrimPrefix(member.Comment, "//")),
+			Body:    !member.IsPathMember() && !member.IsFormMember() && !member.IsHeaderMember(),
+		}
+		if optional {
+			m.Type += "?"
+			m.Default = "nil"
+		} else {
+			m.Default = swiftDefaultValue(member.Type)
+		}
+		if !m.Body && len(m.Default) == 0 {
+			// excluded from CodingKeys, so it must have a default value
+			m.Type += "?"
+			m.Default = "nil"
+		}
+		if m.Body {
+			result.Keys = append(result.Keys, m)
+		}
+		result.Members = append(result.Members, m)
+	}
+	// a type only sent as path, query or header has nothing to encode
+	result.Codable = len(members) == 0 || len(result.Keys) > 0
+	return result
+}
+
+func buildSwiftRoute(api *spec.ApiSpec, route spec.Route) swiftRoute {
+	members := util.GetRequestMembers(api, route)
+	result := swiftRoute{
+		Doc:        strings.TrimSpace(route.Summary + " " + route.Desc),
+		Deprecated: route.Deprecation.Message(),
+		Method:     strings.ToUpper(route.Method),
+		Path: util.ConvertPath(route.Path, func(name string) string {
+			member, ok := members.GetPathMember(name)
+			if !ok {
+				return ":" + name
+			}
+			return `\(ApiClient.pathEscape(req.` + swiftName(member.Name) + `))`
+		}),
+		Func: util.RouteToFuncName(route.Method, route.Path),
+		Body: len(members.Body) > 0,
+	}
+	if len(route.RequestType.Name) > 0 {
+		result.Request = strcase.ToCamel(route.RequestType.Name)
+	}
+	if len(route.ResponseType.Name) > 0 {
+		result.Response = strcase.ToCamel(route.ResponseType.Name)
+	}
+	if route.Binary {
+		result.Response = "ApiFile"
+		result.Binary = true
+	}
+
+	for _, member := range members.Query {
+		if member.IsFile() {
+			result.Files = append(result.Files, appendFile(member))
+			continue
+		}
+		result.Query = append(result.Query, assignValue(member, func(value string) string {
+			return fmt.Sprintf(`query.append(URLQueryItem(name: "%s", value: %s))`, member.GetTagName(), value)
+		}))
+	}
+	for _, member := range members.Header {
+		result.Headers = append(result.Headers, assignValue(member, func(value string) string {
+			return fmt.Sprintf(`headers["%s"] = %s`, member.GetTagName(), value)
+		}))
+	}
+	return result
+}
+
+// assignValue returns the statement sending the member, nil values are skipped
+// and every element of a list is sent.
+func assignValue(member spec.Member, fn func(value string) string) string {
+	name := swiftName(member.Name)
+	_, optional := toSwiftType(member)
+	if strings.HasPrefix(strings.TrimPrefix(member.Type, "*"), "[]") {
+		items := "req." + name
+		if optional {
+			items += " ?? []"
+		}
+		return fmt.Sprintf(`for value in %s { %s }`, items, fn(`"\(value)"`))
+	}
+	if optional {
+		return fmt.Sprintf(`if let value = req.%s { %s }`, name, fn(`"\(value)"`))
+	}
+	return fn(`"\(req.` + name + `)"`)
+}
+
+// appendFile returns the statement appending the file member to files, every element of a list is sent.
+func appendFile(member spec.Member) string {
+	name := swiftName(member.Name)
+	_, optional := toSwiftType(member)
+	switch {
+	case member.IsFileList() && optional:
+		return fmt.Sprintf(`for value in req.%s ?? [] { files.append(("%s", value)) }`, name, member.GetTagName())
+	case member.IsFileList():
+		return fmt.Sprintf(`for value in req.%s { files.append(("%s", value)) }`, name, member.GetTagName())
+	case optional:
+		return fmt.Sprintf(`if let value = req.%s { files.append(("%s", value)) }`, name, member.GetTagName())
+	}
+	return fmt.Sprintf(`files.append(("%s", req.%s))`, member.GetTagName(), name)
+}
+
+func swiftName(name string) string {
+	name = strcase.ToLowerCamel(name)
+	if swiftKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// toSwiftType returns the swift type of the member and whether it is optional.
+func toSwiftType(member spec.Member) (string, bool) {
+	optional := member.IsOptional() || member.IsOmitempty() || strings.HasPrefix(member.Type, "*")
+	return swiftType2(member.Type), optional
+}
+
+func swiftType2(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return "[" + swiftType2(t[2:]) + "]"
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := util.DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			log.Fatalf("bad map type %q", t)
+		}
+		return "[" + swiftType2(tys[0]) + ": " + swiftType2(tys[1]) + "]"
+	}
+
+	switch t {
+	case "string":
+		return "String"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "Int"
+	case "int8", "int16", "int32", "uint8", "uint16":
+		return "Int32"
+	case "float32":
+		return "Float"
+	case "float64":
+		return "Double"
+	case "bool":
+		return "Bool"
+	case "interface{}":
+		return "JSONValue"
+	case "time.Time":
+		return "Date"
+	case spec.FileTypeName:
+		return "ApiFile"
+	default:
+		return strcase.ToCamel(t)
+	}
+}
+
+func swiftDefaultValue(t string) string {
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return "[]"
+	case strings.HasPrefix(t, "map"):
+		return "[:]"
+	}
+	switch swiftType2(t) {
+	case "String":
+		return `""`
+	case "Int", "Int32", "Float", "Double":
+		return "0"
+	case "Bool":
+		return "false"
+	case "JSONValue":
+		return ".null"
+	case "Date":
+		return "Date(timeIntervalSince1970: 0)"
+	case "ApiFile":
+		return "ApiFile()"
+	default:
+		return ""
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/gofaith/goctlr/api/parser"
+	"github.com/gofaith/goctlr/api/util"
 	"github.com/urfave/cli"
 )
 
@@ -23,12 +24,36 @@ func TsCommand(c *cli.Context) error {
 		return e
 	}
 
+	api, e = util.ScopeVersion(api, c.String("version"))
+	if e != nil {
+		log.Println(e)
+		return e
+	}
+
 	e = genBase(dir, api)
 	if e != nil {
 		log.Println(e)
 		return e
 	}
 
+	e = genStreamBase(dir, api)
+	if e != nil {
+		log.Println(e)
+		return e
+	}
+
+	e = genFileBase(dir, api)
+	if e != nil {
+		log.Println(e)
+		return e
+	}
+
+	e = genResultBase(dir, api)
+	if e != nil {
+		log.Println(e)
+		return e
+	}
+
 	e = genApi(dir, api)
 	if e != nil {
 		log.Println(e)
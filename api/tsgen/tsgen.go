@@ -1,6 +1,7 @@
 package tsgen
 
 import (
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -21,33 +22,53 @@ const (
 	}
 }
 
+/** ApiOptions are the options of the requests, set by setApiOptions */
+export interface ApiOptions {
+	/** the url the uris of the routes are appended to, e.g. https://api.example.com, empty for the origin of the page */
+	baseUrl: string;
+	/** handles the 401 responses instead of onFail, e.g. to log in again */
+	onUnauthorized?: (e: ErrorCode) => void;
+}
+
+export const apiOptions: ApiOptions = {baseUrl: ''};
+
+/** setApiOptions sets the options of the requests, the options not passed are kept */
+export function setApiOptions(options: Partial<ApiOptions>) {
+	Object.assign(apiOptions, options);
+}
+
+/** apiFail passes the error of a failed response to onFail, or to onUnauthorized if it's a 401 */
+export function apiFail(status: number, text: string, onFail: (e: ErrorCode) => void) {
+	let err: ErrorCode;
+	try {
+		err = JSON.parse(text);
+	} catch (e) {
+		err = new ErrorCode(status, text || JSON.stringify(e));
+	}
+	if (status == 401 && apiOptions.onUnauthorized) {
+		apiOptions.onUnauthorized(err);
+	} else {
+		onFail(err);
+	}
+}
+
 export function apiRequest(method: string, uri: string, body: any, onOk: (res: string) => void, onFail: (e: ErrorCode) => void, eventually?: () => void, headers?: Record<string, string>) {
 	const xhr = new XMLHttpRequest();
 	xhr.onreadystatechange = function (ev: Event) {
 		if (xhr.readyState != 4) {
 			return;
 		}
+		warnDeprecation(method, uri, xhr);
 		if (xhr.status == 200) {
 			onOk(xhr.responseText);
-		}else if(xhr.status==401){
-			doLogout();
 		} else {
-			try{
-				let err:ErrorCode=JSON.parse(xhr.responseText)
-				if (err.code==4){
-					doLogout();
-				}else{
-					onFail(err)
-				}
-			}catch(e){
-				onFail(new ErrorCode(1,JSON.stringify(e)))
-			}
+			apiFail(xhr.status, xhr.responseText, onFail);
 		}
 		if (eventually) {
 			eventually();
 		}
 	}
-	xhr.open(method, 'http://localhost:8080' + uri, true);
+	xhr.open(method, apiOptions.baseUrl + uri, true);
 	if (headers) {
 		for (let key in headers) {
 			xhr.setRequestHeader(key, headers[key]);
@@ -69,15 +90,293 @@ export function apiRequest(method: string, uri: string, body: any, onOk: (res: s
 	}
 }
 
-export function doLogout(){
-	//TODO
-}`
+const warnedDeprecations: Record<string, boolean> = {};
+
+/** warns once per route that the server deprecated it, the Sunset header tells when it's removed */
+export function warnDeprecation(method: string, uri: string, xhr: XMLHttpRequest) {
+	const route = method + ' ' + uri.split('?')[0];
+	if (!xhr.getResponseHeader('Deprecation') || warnedDeprecations[route]) {
+		return;
+	}
+	warnedDeprecations[route] = true;
+	const sunset = xhr.getResponseHeader('Sunset');
+	console.warn(route + ' is deprecated' + (sunset ? ', it will be removed on ' + sunset : ''));
+}
+`
+
+	streamBaseTemplate = `import {apiOptions, ErrorCode} from "./api"
+
+// apiQuery returns the query string of the params, the undefined and null values are skipped
+export function apiQuery(params: Record<string, any>): string {
+	const items: string[] = [];
+	for (let key in params) {
+		const values = Array.isArray(params[key]) ? params[key] : [params[key]];
+		for (let value of values) {
+			if (value !== undefined && value !== null) {
+				items.push(encodeURIComponent(key) + '=' + encodeURIComponent(String(value)));
+			}
+		}
+	}
+	return items.length > 0 ? '?' + items.join('&') : '';
+}
+
+// apiEventSource listens to the server-sent events of uri, a fail event from the server closes the source
+export function apiEventSource<T>(uri: string, parse: (json: any) => T, onEvent: (ev: T) => void, onFail: (e: ErrorCode) => void): EventSource {
+	const source = new EventSource(apiOptions.baseUrl + uri, {withCredentials: true});
+	source.onmessage = function (ev: MessageEvent) {
+		onEvent(parse(JSON.parse(ev.data)));
+	}
+	source.addEventListener('fail', function (ev: Event) {
+		source.close();
+		onFail(new ErrorCode(1, JSON.parse((ev as MessageEvent).data)));
+	});
+	source.onerror = function () {
+		if (source.readyState == EventSource.CLOSED) {
+			onFail(new ErrorCode(1, 'connection closed'));
+		}
+	}
+	return source;
+}
+
+// ApiSocket sends messages of type S and receives events of type T as json over a websocket
+export class ApiSocket<S, T> {
+	public socket: WebSocket;
+	constructor(uri: string, parse: (json: any) => T, onEvent: (ev: T) => void, onFail: (e: ErrorCode) => void, eventually?: () => void) {
+		this.socket = new WebSocket(socketUrl(uri));
+		this.socket.onmessage = function (ev: MessageEvent) {
+			onEvent(parse(JSON.parse(ev.data)));
+		}
+		this.socket.onclose = function (ev: CloseEvent) {
+			if (ev.code != 1000) {
+				onFail(new ErrorCode(1, ev.reason || 'connection closed'));
+			}
+			if (eventually) {
+				eventually();
+			}
+		}
+	}
+	send(message: S) {
+		this.socket.send(JSON.stringify(message));
+	}
+	close() {
+		this.socket.close();
+	}
+}
+
+// socketUrl returns the websocket url of uri relative to the baseUrl of the options
+function socketUrl(uri: string): string {
+	const url = new URL(apiOptions.baseUrl + uri, location.href);
+	url.protocol = url.protocol == 'https:' ? 'wss:' : 'ws:';
+	return url.toString();
+}
+`
+
+	fileBaseTemplate = `import {apiFail, apiOptions, ErrorCode, warnDeprecation} from "./api"
+
+// apiFileQuery returns the query string of the params, the undefined and null values are skipped
+export function apiFileQuery(params: Record<string, any>): string {
+	const items: string[] = [];
+	for (let key in params) {
+		const values = Array.isArray(params[key]) ? params[key] : [params[key]];
+		for (let value of values) {
+			if (value !== undefined && value !== null) {
+				items.push(encodeURIComponent(key) + '=' + encodeURIComponent(String(value)));
+			}
+		}
+	}
+	return items.length > 0 ? '?' + items.join('&') : '';
+}
+
+// apiForm returns the multipart form of the fields, the undefined and null values are skipped
+export function apiForm(fields: Record<string, any>): FormData {
+	const form = new FormData();
+	for (let key in fields) {
+		const values = Array.isArray(fields[key]) ? fields[key] : [fields[key]];
+		for (let value of values) {
+			if (value === undefined || value === null) {
+				continue;
+			}
+			if (value instanceof Blob) {
+				form.append(key, value, value instanceof File ? value.name : key);
+			} else {
+				form.append(key, String(value));
+			}
+		}
+	}
+	return form;
+}
+
+// apiFileRequest sends a multipart form or a json body, a binary response is passed to onOk as a Blob
+// along with the file name of its Content-Disposition header
+export function apiFileRequest(method: string, uri: string, body: any, binary: boolean, onOk: (res: any, name: string) => void, onFail: (e: ErrorCode) => void, eventually?: () => void, headers?: Record<string, string>) {
+	const xhr = new XMLHttpRequest();
+	const done = function () {
+		if (eventually) {
+			eventually();
+		}
+	}
+	xhr.onreadystatechange = function () {
+		if (xhr.readyState != 4) {
+			return;
+		}
+		warnDeprecation(method, uri, xhr);
+		if (xhr.status == 200 || xhr.status == 206) {
+			if (binary) {
+				onOk(xhr.response, fileName(xhr.getResponseHeader('Content-Disposition')));
+			} else {
+				onOk(xhr.responseText, '');
+			}
+			done();
+		} else {
+			readText(xhr, function (text: string) {
+				apiFail(xhr.status, text, onFail);
+				done();
+			});
+		}
+	}
+	xhr.open(method, apiOptions.baseUrl + uri, true);
+	if (binary) {
+		xhr.responseType = 'blob';
+	}
+	if (headers) {
+		for (let key in headers) {
+			xhr.setRequestHeader(key, headers[key]);
+		}
+	}
+	if (body instanceof FormData) {
+		// the browser sets the multipart boundary
+		xhr.send(body);
+	} else if (body) {
+		xhr.setRequestHeader('Content-Type', 'application/json');
+		xhr.send(JSON.stringify(body));
+	} else {
+		xhr.send();
+	}
+}
+
+function readText(xhr: XMLHttpRequest, fn: (text: string) => void) {
+	if (xhr.responseType == 'blob' && xhr.response) {
+		(xhr.response as Blob).text().then(fn, function () {
+			fn('');
+		});
+	} else {
+		fn(xhr.responseText);
+	}
+}
+
+// fileName returns the file name of a Content-Disposition header, filename* is preferred
+function fileName(disposition: string | null): string {
+	if (!disposition) {
+		return '';
+	}
+	const encoded = /filename\*=[^']*'[^']*'([^;]+)/i.exec(disposition);
+	if (encoded) {
+		return decodeURIComponent(encoded[1]);
+	}
+	const plain = /filename="?([^";]+)"?/i.exec(disposition);
+	return plain ? plain[1] : '';
+}
+`
+
+	resultBaseTemplate = `import {apiFail, apiOptions, ErrorCode, warnDeprecation} from "./api"
 
-	apiTemplate = `import {apiRequest, ErrorCode} from "./api"
+// apiResultQuery returns the query string of the params, the undefined and null values are skipped
+export function apiResultQuery(params: Record<string, any>): string {
+	const items: string[] = [];
+	for (let key in params) {
+		const values = Array.isArray(params[key]) ? params[key] : [params[key]];
+		for (let value of values) {
+			if (value !== undefined && value !== null) {
+				items.push(encodeURIComponent(key) + '=' + encodeURIComponent(String(value)));
+			}
+		}
+	}
+	return items.length > 0 ? '?' + items.join('&') : '';
+}
+
+// apiResultRequest sends a json body like apiRequest, the responses of the statuses declared by the route
+// are passed to onResult along with their status, the other responses fail
+export function apiResultRequest(method: string, uri: string, body: any, statuses: number[], onResult: (status: number, res: string) => void, onFail: (e: ErrorCode) => void, eventually?: () => void, headers?: Record<string, string>) {
+	const xhr = new XMLHttpRequest();
+	xhr.onreadystatechange = function () {
+		if (xhr.readyState != 4) {
+			return;
+		}
+		warnDeprecation(method, uri, xhr);
+		if (statuses.indexOf(xhr.status) >= 0) {
+			onResult(xhr.status, xhr.responseText);
+		} else {
+			apiFail(xhr.status, xhr.responseText, onFail);
+		}
+		if (eventually) {
+			eventually();
+		}
+	}
+	xhr.open(method, apiOptions.baseUrl + uri, true);
+	if (headers) {
+		for (let key in headers) {
+			xhr.setRequestHeader(key, headers[key]);
+		}
+	}
+	if (body) {
+		xhr.setRequestHeader('Content-Type', 'application/json');
+		xhr.send(JSON.stringify(body));
+	} else {
+		xhr.send();
+	}
+}
+`
+
+	apiTemplate = `import {apiRequest, ErrorCode} from "./api"{{if hasStream}}
+import {apiEventSource, apiQuery, ApiSocket} from "./stream"{{end}}{{if hasFile}}
+import {apiFileQuery, apiFileRequest, apiForm} from "./file"{{end}}{{if hasResponses}}
+import {apiResultQuery, apiResultRequest} from "./result"{{end}}
 
 export class {{with .Info}}{{.Title}}{{end}} { {{with .Service}}{{range .Routes}}
 	/** {{.Summary}}{{if ne .Desc ""}}
-	{{.Desc}}{{end}}*/
+	{{.Desc}}{{end}}{{if .Deprecation}}
+	@deprecated {{.Deprecation.Message}}{{end}}*/{{if eq .Stream "sse"}}
+	static {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
+		req: {{.Name}},{{end}}{{end}}
+		onEvent: (ev: {{.ResponseType.Name}}) => void,
+		onFail: (e: ErrorCode) => void
+	): EventSource {
+		return apiEventSource({{streamUri .}}, {{.ResponseType.Name}}.fromJson, onEvent, onFail);
+	}{{else if eq .Stream "ws"}}
+	static {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
+		req: {{.Name}},{{end}}{{end}}
+		onEvent: (ev: {{.ResponseType.Name}}) => void,
+		onFail: (e: ErrorCode) => void,
+		eventually?: () => void
+	): ApiSocket<{{with .RequestType}}{{if ne .Name ""}}{{.Name}}{{else}}null{{end}}{{end}}, {{.ResponseType.Name}}> {
+		return new ApiSocket({{streamUri .}}, {{.ResponseType.Name}}.fromJson, onEvent, onFail, eventually);
+	}{{else if isFileRoute .}}
+	static {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
+		req: {{.Name}},{{end}}{{end}}
+		onOk: ({{if .Binary}}res: Blob, name: string{{else}}{{with .ResponseType}}{{if ne .Name ""}}res: {{.Name}}{{end}}{{end}}{{end}}) => void,
+		onFail: (e: ErrorCode) => void,
+		eventually?: () => void,
+		headers?: Record<string, string>
+	) {
+		apiFileRequest('{{upperCase .Method}}', {{fileUri .}}, {{fileBody .}}, {{.Binary}}, (res, name) => {
+			onOk({{if .Binary}}res, name{{else}}{{with .ResponseType}}{{if ne .Name ""}}{{.Name}}.fromJson(JSON.parse(res)){{end}}{{end}}{{end}})
+		}, onFail, eventually, headers);
+	}{{else if .Responses}}
+	static {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
+		req: {{.Name}},{{end}}{{end}}
+		onResult: (res: {{resultName .}}) => void,
+		onFail: (e: ErrorCode) => void,
+		eventually?: () => void,
+		headers?: Record<string, string>
+	) {
+		apiResultRequest('{{upperCase .Method}}', {{resultUri .}}, {{resultBody .}}, [{{range $i, $r := .Responses}}{{if $i}}, {{end}}{{$r.Status}}{{end}}], (status, res) => {
+			switch (status) { {{range .Responses}}
+				case {{.Status}}:
+					onResult({status: {{.Status}}{{if ne .Type.Name ""}}, body: {{.Type.Name}}.fromJson(JSON.parse(res)){{end}}});
+					break;{{end}}
+			}
+		}, onFail, eventually, headers);
+	}{{else}}
 	static {{routeToFuncName .Method .Path}}({{with .RequestType}}{{if ne .Name ""}}
 		req:{{.Name}},{{end}}{{end}}
 		onOk: ({{with .ResponseType}}{{if ne .Name ""}}res: {{.Name}}{{end}}{{end}}) => void, 
@@ -88,18 +387,28 @@ export class {{with .Info}}{{.Title}}{{end}} { {{with .Service}}{{range .Routes}
         apiRequest('{{upperCase .Method}}', '{{.Path}}', {{with .RequestType}}{{if ne .Name ""}}req{{else}}null{{end}}{{end}}, res=>{
             onOk({{with .ResponseType}}{{if ne .Name ""}}{{.Name}}.fromJson(JSON.parse(res)){{end}}{{end}})
         }, onFail, eventually, headers);
-	}{{end}}{{end}}
+	}{{end}}{{end}}{{end}}
+}
+{{if .Errors}}
+/** the codes of the errors declared in the api file, e.g. e.code == ErrorCodes.{{with index .Errors 0}}{{.Name}}{{end}} */
+export enum ErrorCodes { {{range .Errors}}
+	/** {{.Desc}} */
+	{{.Name}} = {{.Code}},{{end}}
 }
-{{range .Types}}
+{{end}}{{with .Service}}{{range .Routes}}{{if .Responses}}
+/** the responses of {{routeToFuncName .Method .Path}} told apart by their status */
+export type {{resultName .}} = {{range $i, $r := .Responses}}{{if $i}}
+	| {{end}}{status: {{$r.Status}}{{if ne $r.Type.Name ""}}, body: {{$r.Type.Name}}{{end}}}{{end}};
+{{end}}{{end}}{{end}}{{range .Types}}
 export class {{.Name}} { {{range .Members}}
-	public {{tagGet .Tag "json"}}: {{toTsType .Type}};	//{{tagTail .Tag "json"}}，{{.Comment}} {{end}}
+	public {{tsProperty .GetTagName}}: {{toTsType .Type}};	//{{tagTail .Tag "json"}}，{{.Comment}} {{end}}
 	constructor() { {{range .Members}}
-		this.{{tagGet .Tag "json"}} = {{tsDefaultValue .Type}};{{end}}
+		{{jsAccess "this" .GetTagName}} = {{tsDefaultValue .Type}};{{end}}
 	}
 	static fromJson(json: any): {{.Name}} {
 		const obj = new {{.Name}}();
 		{{range .Members}}
-		obj.{{tagGet .Tag "json"}} = json['{{tagGet .Tag "json"}}'];{{end}}
+		{{jsAccess "obj" .GetTagName}} = json['{{.GetTagName}}'];{{end}}
 		return obj;
 	}
 }{{end}}
@@ -157,10 +466,122 @@ func genApi(dir string, api *spec.ApiSpec) error {
 	}
 	defer file.Close()
 
-	t, e := template.New(name).Funcs(util.FuncsMap).Parse(apiTemplate)
+	t, e := template.New(name).Funcs(util.FuncsMap).Funcs(template.FuncMap{
+		"hasStream": func() bool {
+			return hasStream(api)
+		},
+		"streamUri": func(route spec.Route) string {
+			return util.JsRouteUri(api, route, "apiQuery")
+		},
+		"tsProperty": tsProperty,
+		"jsAccess":   util.JsAccess,
+		"hasFile": func() bool {
+			return util.HasFileRoute(api)
+		},
+		"isFileRoute": func(route spec.Route) bool {
+			return util.IsFileRoute(api, route)
+		},
+		"fileUri": func(route spec.Route) string {
+			if util.IsMultipart(api, route) {
+				// the form members are sent in the multipart body
+				return util.JsRouteUri(api, route, "")
+			}
+			return util.JsRouteUri(api, route, "apiFileQuery")
+		},
+		"fileBody": func(route spec.Route) string {
+			return fileBody(api, route)
+		},
+		"hasResponses": func() bool {
+			return util.HasResponses(api)
+		},
+		"resultName": resultName,
+		"resultUri": func(route spec.Route) string {
+			return util.JsRouteUri(api, route, "apiResultQuery")
+		},
+		"resultBody": func(route spec.Route) string {
+			if len(util.GetRequestMembers(api, route).Body) > 0 {
+				return "req"
+			}
+			return "null"
+		},
+	}).Parse(apiTemplate)
 	if e != nil {
 		log.Println(e)
 		return e
 	}
 	return t.Execute(file, api)
 }
+
+// genStreamBase writes stream.ts with the EventSource and WebSocket wrappers if the api has streams.
+func genStreamBase(dir string, api *spec.ApiSpec) error {
+	if !hasStream(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "stream.ts")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("stream.ts already exists, skipped it.")
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(streamBaseTemplate), 0644)
+}
+
+func hasStream(api *spec.ApiSpec) bool {
+	for _, route := range api.Service.Routes {
+		if len(route.Stream) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// genFileBase writes file.ts with the multipart upload and binary download helpers if the api has such routes.
+func genFileBase(dir string, api *spec.ApiSpec) error {
+	if !util.HasFileRoute(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "file.ts")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("file.ts already exists, skipped it.")
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(fileBaseTemplate), 0644)
+}
+
+// genResultBase writes result.ts with the request telling the responses apart by status
+// if the api has routes with multiple responses.
+func genResultBase(dir string, api *spec.ApiSpec) error {
+	if !util.HasResponses(api) {
+		return nil
+	}
+	path := filepath.Join(dir, "result.ts")
+	if _, e := os.Stat(path); e == nil {
+		log.Println("result.ts already exists, skipped it.")
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(resultBaseTemplate), 0644)
+}
+
+// resultName returns the name of the union of the responses of a route, e.g. GetUserResult.
+func resultName(route spec.Route) string {
+	return strcase.ToCamel(util.RouteToFuncName(route.Method, route.Path)) + "Result"
+}
+
+// fileBody returns the typescript expression of the body of a file route, the form members of a multipart route
+// or the json of req if it has body members.
+func fileBody(api *spec.ApiSpec, route spec.Route) string {
+	if util.IsMultipart(api, route) {
+		return "apiForm(" + util.JsFormFields(api, route) + ")"
+	}
+	if len(util.GetRequestMembers(api, route).Body) > 0 {
+		return "req"
+	}
+	return "null"
+}
+
+// tsProperty returns the declaration name of a class property, names like X-Token are quoted.
+func tsProperty(name string) string {
+	if util.IsJsIdentifier(name) {
+		return name
+	}
+	return "'" + name + "'"
+}
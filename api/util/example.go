@@ -0,0 +1,114 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofaith/goctlr/api/spec"
+)
+
+// exampleObject keeps the order of the members when marshaled.
+type exampleObject []exampleField
+
+type exampleField struct {
+	Key   string
+	Value interface{}
+}
+
+func (o exampleObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, e := json.Marshal(field.Key)
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, e := json.Marshal(field.Value)
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// GetExampleJSON returns an indented json example of the members, the members
+// sent as path, query or header are skipped.
+func GetExampleJSON(types []spec.Type, members []spec.Member) string {
+	b, e := json.MarshalIndent(exampleMembers(types, members, map[string]bool{}), "", "  ")
+	if e != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// GetExampleValue returns an example of the go type t, e.g. 0 for int64.
+func GetExampleValue(types []spec.Type, t string) interface{} {
+	return exampleValue(types, t, map[string]bool{})
+}
+
+func exampleMembers(types []spec.Type, members []spec.Member, seen map[string]bool) exampleObject {
+	result := exampleObject{}
+	for _, member := range members {
+		if member.IsPathMember() || member.IsFormMember() || member.IsHeaderMember() {
+			continue
+		}
+		result = append(result, exampleField{
+			Key:   member.GetTagName(),
+			Value: exampleValue(types, member.Type, seen),
+		})
+	}
+	return result
+}
+
+func exampleValue(types []spec.Type, t string, seen map[string]bool) interface{} {
+	t = strings.TrimPrefix(t, "*")
+	if strings.HasPrefix(t, "[]") {
+		return []interface{}{exampleValue(types, t[2:], seen)}
+	}
+	if strings.HasPrefix(t, "map") {
+		tys, e := DecomposeType(t)
+		if e != nil || len(tys) != 2 {
+			return exampleObject{}
+		}
+		key := "key"
+		if tys[0] != "string" {
+			key = "0"
+		}
+		return exampleObject{{Key: key, Value: exampleValue(types, tys[1], seen)}}
+	}
+
+	switch t {
+	case "string":
+		return "string"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return 0
+	case "bool":
+		return false
+	case "time.Time":
+		return "2006-01-02T15:04:05Z"
+	case "interface{}":
+		return exampleObject{}
+	}
+	for _, ty := range types {
+		if ty.Name != t {
+			continue
+		}
+		// recursive types end with null
+		if seen[t] {
+			return nil
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return exampleMembers(types, FlattenMembers(types, ty), seen)
+	}
+	return nil
+}
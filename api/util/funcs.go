@@ -6,6 +6,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/gofaith/goctlr/api/spec"
 	"github.com/iancoleman/strcase"
 )
 
@@ -19,13 +20,13 @@ var FuncsMap = template.FuncMap{
 	"toKtType":            toKtType,
 	"toTsType":            toTsType,
 	"tsDefaultValue":      tsDefaultValue,
-	"dartDefaultValue":    dartDefaultValue,
+	"dartDefaultValue":    DartDefaultValue,
 	"ktDefaultValue":      ktDefaultValue,
 	"toJavaType":          toJavaType,
 	"toJavaPrimitiveType": toJavaPrimitiveType,
 	"isJavaTypeNullable":  isJavaTypeNullable,
 	"toJavaGetFunc":       toJavaGetTypeFunc,
-	"toDartType":          toDartType,
+	"toDartType":          ToDartType,
 	"add":                 add,
 	"upperCase":           upperCase,
 	"isDirectType":        isDirectType,
@@ -104,7 +105,7 @@ func isJavaTypeNullable(t string) bool {
 		return true
 	}
 }
-func toDartType(t string) string {
+func ToDartType(t string) string {
 	return toDartType2(t, true)
 }
 func toDartType2(t string, nullable bool) string {
@@ -127,7 +128,7 @@ func toDartType2(t string, nullable bool) string {
 	switch t {
 	case "string":
 		return "String"
-	case "int", "int32", "int64":
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
 		return "int"
 	case "float32", "float64":
 		return "double"
@@ -135,12 +136,17 @@ func toDartType2(t string, nullable bool) string {
 		return "bool"
 	case "interface{}":
 		return "dynamic"
+	case "time.Time":
+		t = "DateTime"
+	case spec.FileTypeName:
+		t = "ApiFile"
 	default:
-		if !nullable {
-			return t
-		}
-		return t + "?"
+		t = strcase.ToCamel(t)
+	}
+	if !nullable {
+		return t
 	}
+	return t + "?"
 }
 
 func toKtType(t string) string {
@@ -204,6 +210,8 @@ func toTsType(t string) string {
 		return "number"
 	case "bool":
 		return "boolean"
+	case spec.FileTypeName:
+		return "Blob"
 	default:
 		return t
 	}
@@ -228,8 +236,8 @@ func tsDefaultValue(typ string) string {
 		return `new ` + typ + `()`
 	}
 }
-func dartDefaultValue(typ string) string {
-	typ = toDartType(typ)
+func DartDefaultValue(typ string) string {
+	typ = ToDartType(typ)
 	if strings.HasPrefix(typ, "List<") {
 		return `const []`
 	}
@@ -295,6 +303,8 @@ func toJavaPrimitiveType(t string) string {
 		return "double"
 	case "bool":
 		return "boolean"
+	case spec.FileTypeName:
+		return "Blob"
 	default:
 		return t
 	}
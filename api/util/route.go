@@ -0,0 +1,252 @@
+package util
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gofaith/go-zero/core/stringx"
+	"github.com/gofaith/goctlr/api/spec"
+)
+
+// RequestMembers groups the members of a request type by where they are sent.
+type RequestMembers struct {
+	Path   []spec.Member
+	Query  []spec.Member
+	Header []spec.Member
+	Body   []spec.Member
+}
+
+func GetRequestMembers(api *spec.ApiSpec, route spec.Route) RequestMembers {
+	var result RequestMembers
+	for _, member := range FlattenMembers(api.Types, route.RequestType) {
+		switch {
+		case member.IsPathMember():
+			result.Path = append(result.Path, member)
+		case member.IsFormMember():
+			result.Query = append(result.Query, member)
+		case member.IsHeaderMember():
+			result.Header = append(result.Header, member)
+		default:
+			result.Body = append(result.Body, member)
+		}
+	}
+	return result
+}
+
+// GetPathMember returns the member bound to the path variable name.
+func (r RequestMembers) GetPathMember(name string) (spec.Member, bool) {
+	for _, member := range r.Path {
+		if member.GetTagName() == name {
+			return member, true
+		}
+	}
+	return spec.Member{}, false
+}
+
+// ConvertPath replaces every path variable with the result of fn, e.g. /users/:id to /users/{id}
+func ConvertPath(path string, fn func(name string) string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			segments[i] = fn(seg[1:])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// GetRouteFolder returns the folder annotation of the route, or of its group.
+func GetRouteFolder(group spec.Group, route spec.Route) string {
+	folder, ok := GetAnnotationValue(route.Annotations, "server", "folder")
+	if !ok {
+		folder, _ = GetAnnotationValue(group.Annotations, "server", "folder")
+	}
+	// the routes of a version are generated in its folder, e.g. v2/user
+	return path.Join(route.Version, strings.Trim(folder, "/"))
+}
+
+// GetRoutePackage returns the name the handler package of the route is imported as, e.g. v2user for v2/user.
+func GetRoutePackage(group spec.Group, route spec.Route) string {
+	return strings.ReplaceAll(GetRouteFolder(group, route), "/", "")
+}
+
+// GetMiddlewares returns the middleware annotation of the group followed by the one of the route,
+// e.g. middleware: Auth,RateLimit.
+func GetMiddlewares(group spec.Group, route spec.Route) []string {
+	var result []string
+	for _, annotations := range [][]spec.Annotation{group.Annotations, route.Annotations} {
+		value, _ := GetAnnotationValue(annotations, "server", "middleware")
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) == 0 || stringx.Contains(result, name) {
+				continue
+			}
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// GetRouteName returns the summary of the route, or the handler name if the summary is absent.
+func GetRouteName(route spec.Route) string {
+	if len(route.Summary) > 0 {
+		return route.Summary
+	}
+	if handler, ok := GetAnnotationValue(route.Annotations, "server", "handler"); ok {
+		return handler
+	}
+	return strings.ToUpper(route.Method) + " " + route.Path
+}
+
+// GetBaseUrl returns the local url of the service, the port is the one written to etc/*.yaml.
+func GetBaseUrl(api *spec.ApiSpec) string {
+	port, ok := GetAnnotationValue(api.Service.Annotations, "server", "port")
+	if !ok {
+		port = "8888"
+	}
+	return "http://localhost:" + port
+}
+
+// GetMemberComment returns the comment of the member without the leading //.
+func GetMemberComment(member spec.Member) string {
+	return strings.TrimSpace(strings.TrimPrefix(member.Comment, "//"))
+}
+
+// IsMultipart tells whether the request of the route has file members, which is sent as multipart/form-data
+// with the form members as the other fields.
+func IsMultipart(api *spec.ApiSpec, route spec.Route) bool {
+	for _, member := range FlattenMembers(api.Types, route.RequestType) {
+		if member.IsFile() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFileRoute tells whether the route uploads files or downloads binary, which the clients send apart from json.
+func IsFileRoute(api *spec.ApiSpec, route spec.Route) bool {
+	return route.Binary || IsMultipart(api, route)
+}
+
+// HasFileRoute tells whether any route of the api uploads files or downloads binary.
+func HasFileRoute(api *spec.ApiSpec) bool {
+	for _, route := range api.Service.Routes {
+		if IsFileRoute(api, route) {
+			return true
+		}
+	}
+	return false
+}
+
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// IsJsIdentifier tells whether name can be used as a javascript property without quotes, X-Token can't.
+func IsJsIdentifier(name string) bool {
+	return jsIdentifier.MatchString(name)
+}
+
+// JsAccess returns the javascript expression accessing the property name of obj.
+func JsAccess(obj, name string) string {
+	if IsJsIdentifier(name) {
+		return obj + "." + name
+	}
+	return obj + "['" + name + "']"
+}
+
+// JsRouteUri returns the javascript expression of the uri of a route, the path members of req are put into it,
+// and the form members too by the function query unless it's empty,
+// e.g. '/api/chat/' + encodeURIComponent(String(req.room)) + apiQuery({'topic': req.topic})
+func JsRouteUri(api *spec.ApiSpec, route spec.Route, query string) string {
+	uri := "'" + ConvertPath(route.Path, func(name string) string {
+		return "' + encodeURIComponent(String(" + JsAccess("req", name) + ")) + '"
+	}) + "'"
+	uri = strings.TrimSuffix(uri, " + ''")
+	if len(query) == 0 {
+		return uri
+	}
+	if fields := JsFormFields(api, route); fields != "{}" {
+		uri += " + " + query + "(" + fields + ")"
+	}
+	return uri
+}
+
+// JsFormFields returns the javascript object of the form members of req, e.g. {'name': req.name, 'avatar': req.avatar}
+func JsFormFields(api *spec.ApiSpec, route spec.Route) string {
+	var fields []string
+	for _, member := range GetRequestMembers(api, route).Query {
+		fields = append(fields, fmt.Sprintf("'%s': %s", member.GetTagName(), JsAccess("req", member.GetTagName())))
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// SkipStreams returns the api without the stream routes, which the clients of lang can't call,
+// and logs a warning for each of them. It's the api itself if it has no stream routes.
+func SkipStreams(api *spec.ApiSpec, lang string) *spec.ApiSpec {
+	skipped := *api
+	skipped.Service.Routes = nil
+	skipped.Service.Groups = nil
+	found := false
+	for _, group := range api.Service.Groups {
+		var routes []spec.Route
+		for _, route := range group.Routes {
+			if len(route.Stream) > 0 {
+				log.Printf("the %s stream %s %s is skipped, the %s client doesn't support stream routes",
+					route.Stream, strings.ToUpper(route.Method), route.Path, lang)
+				found = true
+				continue
+			}
+			routes = append(routes, route)
+		}
+		group.Routes = routes
+		skipped.Service.Groups = append(skipped.Service.Groups, group)
+		skipped.Service.Routes = append(skipped.Service.Routes, routes...)
+	}
+	if !found {
+		return api
+	}
+	return &skipped
+}
+
+// RetrofitBody tells whether the @Body of the route has to be declared by @HTTP(hasBody = true),
+// retrofit rejects @Body on @DELETE and @OPTIONS, and okhttp rejects any body of GET and HEAD.
+func RetrofitBody(route spec.Route) (bool, error) {
+	switch method := strings.ToUpper(route.Method); method {
+	case "GET", "HEAD":
+		return false, fmt.Errorf("%s %s: a %s request can't have json members, tag them with form instead",
+			route.Method, route.Path, method)
+	case "DELETE", "OPTIONS":
+		return true, nil
+	}
+	return false, nil
+}
+
+// StatusName returns the name of the response of a status in returns(200: user, 404: notFound),
+// e.g. OK, NotFound, or Status299 for a status without text.
+func StatusName(status int) string {
+	words := strings.FieldsFunc(http.StatusText(status), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "Status" + strconv.Itoa(status)
+	}
+	var builder strings.Builder
+	for _, word := range words {
+		builder.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return builder.String()
+}
+
+// HasResponses tells whether any route of the api has multiple responses keyed by status.
+func HasResponses(api *spec.ApiSpec) bool {
+	for _, route := range api.Service.Routes {
+		if len(route.Responses) > 0 {
+			return true
+		}
+	}
+	return false
+}
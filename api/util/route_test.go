@@ -0,0 +1,55 @@
+package util
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gofaith/goctlr/api/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+
+	user := spec.Route{Method: "get", Path: "/api/user/:id"}
+	events := spec.Route{Method: "get", Path: "/api/events", Stream: spec.StreamSSE}
+	chat := spec.Route{Method: "get", Path: "/api/chat", Stream: spec.StreamWS}
+	api := &spec.ApiSpec{Service: spec.Service{
+		Name:   "user-api",
+		Routes: []spec.Route{user, events, chat},
+		Groups: []spec.Group{
+			{Jwt: true, Routes: []spec.Route{user, events}},
+			{Routes: []spec.Route{chat}},
+		},
+	}}
+
+	skipped := SkipStreams(api, "dart")
+	assert.Equal(t, []spec.Route{user}, skipped.Service.Routes)
+	assert.Len(t, skipped.Service.Groups, 2)
+	assert.Equal(t, []spec.Route{user}, skipped.Service.Groups[0].Routes)
+	assert.True(t, skipped.Service.Groups[0].Jwt)
+	assert.Empty(t, skipped.Service.Groups[1].Routes)
+	assert.Contains(t, buffer.String(), "the sse stream GET /api/events is skipped, the dart client doesn't support stream routes")
+	assert.Contains(t, buffer.String(), "the ws stream GET /api/chat is skipped")
+	// the api itself is left as it is
+	assert.Len(t, api.Service.Routes, 3)
+	assert.Len(t, api.Service.Groups[0].Routes, 2)
+}
+
+func TestSkipNoStreams(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+
+	user := spec.Route{Method: "get", Path: "/api/user/:id"}
+	api := &spec.ApiSpec{Service: spec.Service{
+		Routes: []spec.Route{user},
+		Groups: []spec.Group{{Routes: []spec.Route{user}}},
+	}}
+	assert.True(t, SkipStreams(api, "dart") == api)
+	assert.Empty(t, buffer.String())
+}
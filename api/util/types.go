@@ -53,6 +53,15 @@ func GetAllTypes(api *spec.ApiSpec, route spec.Route) ([]spec.Type, []spec.Type)
 	types := api.Types
 	getTypeRecursive(route.RequestType, types, &rts)
 	getTypeRecursive(route.ResponseType, types, &rpts)
+	for _, res := range route.Responses {
+		found := false
+		for _, item := range rpts {
+			found = found || item.Name == res.Type.Name
+		}
+		if !found {
+			getTypeRecursive(res.Type, types, &rpts)
+		}
+	}
 	return rts, rpts
 }
 
@@ -164,3 +173,22 @@ func GetSharedTypes(api *spec.ApiSpec) []spec.Type {
 	}
 	return result
 }
+
+// FlattenMembers returns the members of ty, the inline members are replaced with the members of the inline type.
+func FlattenMembers(types []spec.Type, ty spec.Type) []spec.Member {
+	var result []spec.Member
+	for _, member := range ty.Members {
+		if !member.IsInline {
+			result = append(result, member)
+			continue
+		}
+		name := strings.TrimPrefix(member.Type, "*")
+		for _, item := range types {
+			if item.Name == name {
+				result = append(result, FlattenMembers(types, item)...)
+				break
+			}
+		}
+	}
+	return result
+}
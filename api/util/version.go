@@ -0,0 +1,86 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/gofaith/goctlr/api/spec"
+)
+
+// GetVersions returns the versions of the routes in their order, e.g. v2 of @server(version: v2).
+func GetVersions(api *spec.ApiSpec) []string {
+	var versions []string
+	for _, group := range api.Service.Groups {
+		if len(group.Version) == 0 {
+			continue
+		}
+		found := false
+		for _, version := range versions {
+			found = found || version == group.Version
+		}
+		if !found {
+			versions = append(versions, group.Version)
+		}
+	}
+	return versions
+}
+
+// GetTypeVersions returns the version of each type only used by the routes of that version, e.g. userV2 of v2,
+// the other types are shared by the versions and the routes without version.
+func GetTypeVersions(api *spec.ApiSpec) map[string]string {
+	used := make(map[string]map[string]bool)
+	for _, route := range api.Service.Routes {
+		rts, rpts := GetAllTypes(api, route)
+		for _, tp := range append(rts, rpts...) {
+			if used[tp.Name] == nil {
+				used[tp.Name] = make(map[string]bool)
+			}
+			used[tp.Name][route.Version] = true
+		}
+	}
+
+	result := make(map[string]string)
+	for name, versions := range used {
+		if len(versions) != 1 {
+			continue
+		}
+		for version := range versions {
+			if len(version) > 0 {
+				result[name] = version
+			}
+		}
+	}
+	return result
+}
+
+// ScopeVersion returns the api with the routes of the version and the routes without version, and without the types
+// only used by the other versions. It's the api itself if the version is empty.
+func ScopeVersion(api *spec.ApiSpec, version string) (*spec.ApiSpec, error) {
+	if len(version) == 0 {
+		return api, nil
+	}
+	found := false
+	for _, item := range GetVersions(api) {
+		found = found || item == version
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown version %s, the api has %v", version, GetVersions(api))
+	}
+	typeVersions := GetTypeVersions(api)
+	scoped := *api
+	scoped.Types = nil
+	for _, tp := range api.Types {
+		if item, ok := typeVersions[tp.Name]; !ok || item == version {
+			scoped.Types = append(scoped.Types, tp)
+		}
+	}
+	scoped.Service.Routes = nil
+	scoped.Service.Groups = nil
+	for _, group := range api.Service.Groups {
+		if len(group.Version) > 0 && group.Version != version {
+			continue
+		}
+		scoped.Service.Groups = append(scoped.Service.Groups, group)
+		scoped.Service.Routes = append(scoped.Service.Routes, group.Routes...)
+	}
+	return &scoped, nil
+}
@@ -6,16 +6,27 @@ import (
 
 	"github.com/gofaith/go-zero/core/logx"
 	"github.com/gofaith/goctlr/api/apigen"
+	"github.com/gofaith/goctlr/api/changelog"
+	"github.com/gofaith/goctlr/api/chigen"
+	"github.com/gofaith/goctlr/api/csharpgen"
 	"github.com/gofaith/goctlr/api/dartgen"
+	"github.com/gofaith/goctlr/api/echogen"
 	"github.com/gofaith/goctlr/api/format"
 	"github.com/gofaith/goctlr/api/gingen"
 	"github.com/gofaith/goctlr/api/gocligen"
 	"github.com/gofaith/goctlr/api/gogen"
+	"github.com/gofaith/goctlr/api/httpgen"
 	"github.com/gofaith/goctlr/api/javagen"
 	"github.com/gofaith/goctlr/api/jsgen"
 	"github.com/gofaith/goctlr/api/ktgen"
 	"github.com/gofaith/goctlr/api/mdgen"
 	"github.com/gofaith/goctlr/api/nodejsgen"
+	"github.com/gofaith/goctlr/api/postmangen"
+	"github.com/gofaith/goctlr/api/protogen"
+	"github.com/gofaith/goctlr/api/pythongen"
+	"github.com/gofaith/goctlr/api/rustgen"
+	"github.com/gofaith/goctlr/api/stdhttpgen"
+	"github.com/gofaith/goctlr/api/swiftgen"
 	"github.com/gofaith/goctlr/api/tsgen"
 	"github.com/gofaith/goctlr/api/validate"
 	"github.com/gofaith/goctlr/configgen"
@@ -82,6 +93,75 @@ var (
 					},
 					Action: mdgen.MdCommand,
 				},
+				{
+					Name:  "doc",
+					Usage: "generate a documentation site for all api files in the dir",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the dir of the api files",
+						},
+						cli.StringFlag{
+							Name:  "o",
+							Usage: "the output dir, default to doc",
+						},
+						cli.StringFlag{
+							Name:  "format",
+							Usage: "html or md, default to html",
+						},
+						cli.StringFlag{
+							Name:  "lang",
+							Usage: "en or zh, default to en",
+						},
+					},
+					Action: mdgen.DocCommand,
+				},
+				{
+					Name:  "changelog",
+					Usage: "generate a markdown changelog of the api files between two git revisions",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "from",
+							Usage: "the old git revision, e.g. v1.2.0",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "the new git revision, default to HEAD",
+						},
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the dir of the api files",
+						},
+						cli.StringFlag{
+							Name:  "o",
+							Usage: "the output file, default to stdout",
+						},
+					},
+					Action: changelog.ChangelogCommand,
+				},
+				{
+					Name:  "proto",
+					Usage: "generate a protobuf schema with a grpc service for provided api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "o",
+							Usage: "the output proto file, default to the api file with the .proto extension",
+						},
+						cli.StringFlag{
+							Name:  "lock",
+							Usage: "the lock file of the field numbers, default to the output file with the .lock extension",
+						},
+						cli.StringFlag{
+							Name:  "package",
+							Usage: "the proto package, default to the service name",
+						},
+					},
+					Action: protogen.ProtoCommand,
+				},
 				{
 					Name:  "go",
 					Usage: "generate go files for provided api in yaml file",
@@ -96,7 +176,7 @@ var (
 						},
 						cli.StringFlag{
 							Name:  "proto",
-							Usage: ".proto file",
+							Usage: "the proto file of the api, the handlers accept and return json or protobuf",
 						},
 						cli.BoolFlag{
 							Name:  "onlyTypes",
@@ -104,7 +184,11 @@ var (
 						},
 						cli.BoolFlag{
 							Name:  "clitest",
-							Usage: "generate client folder and test folder",
+							Usage: "generate client folder and the tests calling it in test folder",
+						},
+						cli.BoolFlag{
+							Name:  "observability",
+							Usage: "wrap the handlers with prometheus metrics, opentelemetry tracing, request ids and health endpoints",
 						},
 					},
 					Action: gogen.GoCommand,
@@ -130,6 +214,66 @@ var (
 					Action: gingen.GoCommand,
 				},
 
+				{
+					Name:  "stdhttp",
+					Usage: "generate net/http server files without third-party dependencies for provided api in .api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.BoolFlag{
+							Name:  "onlyTypes",
+							Usage: "only generate types",
+						},
+					},
+					Action: stdhttpgen.GoCommand,
+				},
+
+				{
+					Name:  "echo",
+					Usage: "generate echo server files for provided api in .api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.BoolFlag{
+							Name:  "onlyTypes",
+							Usage: "only generate types",
+						},
+					},
+					Action: echogen.GoCommand,
+				},
+
+				{
+					Name:  "chi",
+					Usage: "generate chi server files for provided api in .api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.BoolFlag{
+							Name:  "onlyTypes",
+							Usage: "only generate types",
+						},
+					},
+					Action: chigen.GoCommand,
+				},
+
 				{
 					Name:  "gocli",
 					Usage: "generate go client api files",
@@ -142,6 +286,14 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "pb",
+							Usage: "the import path of the messages compiled from the proto file of the api, to send json or protobuf",
+						},
 					},
 					Action: gocligen.GocliCommand,
 				},
@@ -157,10 +309,18 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
 						cli.StringFlag{
 							Name:  "pkg",
 							Usage: "the package name",
 						},
+						cli.BoolFlag{
+							Name:  "retrofit",
+							Usage: "generate retrofit services for android",
+						},
 					},
 					Action: javagen.JavaCommand,
 				},
@@ -176,6 +336,10 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
 						cli.StringFlag{
 							Name:     "webapi",
 							Usage:    "the web api file path",
@@ -206,9 +370,159 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "package",
+							Usage: "generate a dart package with the name, the sources are put into the lib folder. [optional]",
+						},
+						cli.StringFlag{
+							Name:  "dns",
+							Usage: "the dns TXT record to discover the server from, e.g. _server.example.com. [optional]",
+						},
 					},
 					Action: dartgen.DartCommand,
 				},
+				{
+					Name:  "swift",
+					Usage: "generate swift files for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "package",
+							Usage: "generate a swift package with the name, the sources are put into Sources/<name>. [optional]",
+						},
+					},
+					Action: swiftgen.SwiftCommand,
+				},
+				{
+					Name:  "python",
+					Usage: "generate a python package for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "package",
+							Usage: "the python package name, default to <title>_api. [optional]",
+						},
+					},
+					Action: pythongen.PythonCommand,
+				},
+				{
+					Name:  "rust",
+					Usage: "generate a rust crate for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "crate",
+							Usage: "the crate name, default to <title>-api. [optional]",
+						},
+					},
+					Action: rustgen.RustCommand,
+				},
+				{
+					Name:  "csharp",
+					Usage: "generate a c# project for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "namespace",
+							Usage: "the namespace and project name, default to <Title>Client. [optional]",
+						},
+					},
+					Action: csharpgen.CSharpCommand,
+				},
+				{
+					Name:  "postman",
+					Usage: "generate a postman collection for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "baseurl",
+							Usage: "the value of the baseUrl variable, default to http://localhost:<port>. [optional]",
+						},
+					},
+					Action: postmangen.PostmanCommand,
+				},
+				{
+					Name:  "http",
+					Usage: "generate a .http request file for provided api in api file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "the target dir",
+						},
+						cli.StringFlag{
+							Name:  "api",
+							Usage: "the api file",
+						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
+						cli.StringFlag{
+							Name:  "baseurl",
+							Usage: "the value of the baseUrl variable, default to http://localhost:<port>. [optional]",
+						},
+					},
+					Action: httpgen.HttpCommand,
+				},
 				{
 					Name:  "kt",
 					Usage: "generate kotlin code for provided api file",
@@ -221,10 +535,18 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
 						cli.StringFlag{
 							Name:  "pkg",
 							Usage: "define package name for kotlin file",
 						},
+						cli.BoolFlag{
+							Name:  "retrofit",
+							Usage: "generate retrofit services for android",
+						},
 					},
 					Action: ktgen.KtCommand,
 				},
@@ -240,6 +562,10 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
 					},
 					Action: nodejsgen.NodeJsCommand,
 				},
@@ -255,6 +581,10 @@ var (
 							Name:  "api",
 							Usage: "the api file",
 						},
+						cli.StringFlag{
+							Name:  "version",
+							Usage: "the version of the routes to generate along with the routes without version, e.g. v2, all by default",
+						},
 					},
 					Action: jsgen.JsCommand,
 				},